@@ -3,10 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/cache"
+	"github.com/heyjobs/terranovate/internal/github"
 	"github.com/heyjobs/terranovate/internal/notifier"
 	"github.com/heyjobs/terranovate/internal/scanner"
 	"github.com/heyjobs/terranovate/internal/version"
@@ -20,6 +25,9 @@ var (
 	checkFormat          string
 	checkUnusedProviders bool
 	displayFilter        string
+	checkCommentOnPR     int
+	checkRepo            string
+	checkRefreshAI       bool
 )
 
 // shouldDisplayUpdate determines if an update should be displayed based on filter
@@ -36,6 +44,29 @@ func shouldDisplayUpdate(updateType version.UpdateType, filter string) bool {
 	}
 }
 
+// newAIAnalyzer creates the AI analyzer for breaking change detection with its cache,
+// nil when AI analysis is disabled or its backend is not configured
+func newAIAnalyzer(cfg *config.Config, refresh bool) *ai.Analyzer {
+	if !cfg.OpenAI.Enabled {
+		return nil
+	}
+
+	client, err := ai.NewClient(aiClientConfig(cfg))
+	if err != nil {
+		log.Warn().Err(err).Msg("AI analysis enabled but its backend is not configured (set OPENAI_API_KEY or ANTHROPIC_API_KEY env var or in config)")
+		return nil
+	}
+
+	log.Info().Str("model", client.Model()).Msg("AI-powered breaking change detection enabled")
+	analyzer := ai.NewAdapter(client)
+	if analysisCache, err := newAnalysisCache(cfg); err != nil {
+		log.Warn().Err(err).Msg("failed to create AI analysis cache, every update will query the model")
+	} else {
+		analyzer.SetCache(analysisCache, refresh)
+	}
+	return analyzer
+}
+
 // shouldDisplayAIAnalysis determines if an update with AI analysis should be displayed based on confidence level
 func shouldDisplayAIAnalysis(aiAnalysis *ai.BreakingChangeAnalysis, minConfidence string) bool {
 	// If no AI analysis, always display
@@ -90,13 +121,13 @@ Example:
 			path = "."
 		}
 
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
 		// Create scanner
-		s := scanner.New(
-			path,
-			cfg.Scanner.Exclude,
-			cfg.Scanner.Include,
-			cfg.Scanner.Recursive,
-		)
+		s := newScanner(cfg, path, engine)
 
 		// Detect if using Terramate or Terragrunt
 		tooling := scanner.DetectTooling(path)
@@ -132,14 +163,13 @@ Example:
 			cfg.VersionCheck.MinorOnly,
 			cfg.VersionCheck.IgnoreModules,
 		)
+		checker.SetRegistryHost(engine.RegistryHost())
 
 		// Configure AI analyzer if enabled
-		if cfg.OpenAI.Enabled && cfg.OpenAI.APIKey != "" {
-			log.Info().Msg("AI-powered breaking change detection enabled")
-			aiAnalyzer := ai.NewAdapter(cfg.OpenAI.APIKey, cfg.OpenAI.Model, cfg.OpenAI.BaseURL)
-			checker.SetAIAnalyzer(aiAnalyzer)
-		} else if cfg.OpenAI.Enabled {
-			log.Warn().Msg("AI analysis enabled but no API key configured (set OPENAI_API_KEY env var or in config)")
+		analyzer := newAIAnalyzer(cfg, checkRefreshAI)
+		if analyzer != nil {
+			checker.SetAIAnalyzer(analyzer)
+			checker.SetReleaseNotes(cfg.VersionCheck.ReleaseNotes, cfg.VersionCheck.ReleaseNotesMaxBytes)
 		}
 
 		// Check for updates
@@ -170,6 +200,15 @@ Example:
 			}
 		}
 
+		if analyzer != nil {
+			hits, misses := analyzer.CacheCounts()
+			log.Debug().
+				Int("hits", hits).
+				Int("misses", misses).
+				Bool("refresh", checkRefreshAI).
+				Msg("AI analysis cache")
+		}
+
 		// Check for unused providers if enabled
 		var unusedProviders []scanner.UnusedProviderInfo
 		if checkUnusedProviders && len(providers) > 0 {
@@ -293,6 +332,14 @@ Example:
 			}
 		}
 
+		// Report to the pull request if requested
+		if checkCommentOnPR > 0 {
+			updates, providerUpdates, err = reportToPullRequest(ctx, cfg, checkCommentOnPR, updates, providerUpdates)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Check if markdown format is requested
 		if checkFormat == "markdown" {
 			n := notifier.New("", "")
@@ -382,7 +429,10 @@ Example:
 				fmt.Printf("      %s\n", update.AIAnalysis.Summary)
 				if len(update.AIAnalysis.Details) > 0 {
 					for _, detail := range update.AIAnalysis.Details {
-						fmt.Printf("      • %s\n", detail)
+						fmt.Printf("      • %s\n", detail.Text)
+						for _, citation := range detail.Citations {
+							fmt.Printf("        ↳ %s: %s\n", citation.Source, citation.Text)
+						}
 					}
 				}
 			}
@@ -510,7 +560,10 @@ Example:
 					fmt.Printf("      %s\n", update.AIAnalysis.Summary)
 					if len(update.AIAnalysis.Details) > 0 {
 						for _, detail := range update.AIAnalysis.Details {
-							fmt.Printf("      • %s\n", detail)
+							fmt.Printf("      • %s\n", detail.Text)
+							for _, citation := range detail.Citations {
+								fmt.Printf("        ↳ %s: %s\n", citation.Source, citation.Text)
+							}
 						}
 					}
 				}
@@ -577,6 +630,99 @@ Example:
 	},
 }
 
+// reportToPullRequest posts the check results for dependencies touched by a pull
+// request as a sticky comment and a check run, returning the touched updates
+func reportToPullRequest(ctx context.Context, cfg *config.Config, number int,
+	updates []version.UpdateInfo, providerUpdates []version.ProviderUpdateInfo) ([]version.UpdateInfo, []version.ProviderUpdateInfo, error) {
+	owner, repo := cfg.GitHub.Owner, cfg.GitHub.Repo
+	if checkRepo != "" {
+		owner, repo = "", checkRepo
+	} else if repo == "" {
+		// GitHub Actions exposes the repository as owner/repo
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if owner == "" && repo != "" {
+		if o, r, err := parseRepo(repo); err == nil {
+			owner, repo = o, r
+		}
+	}
+
+	reporter, err := github.NewPRReporter(cfg.GitHub.Token, owner, repo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create PR reporter: %w", err)
+	}
+
+	diff, err := reporter.Diff(ctx, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Only report dependencies whose block the PR diff touches
+	root := gitRepoRoot()
+	var touched []version.UpdateInfo
+	for _, update := range updates {
+		update.Module.FilePath = repoRelativePath(root, update.Module.FilePath)
+		if diff.Touches(update.Module.FilePath, update.Module.Line, update.Module.EndLine) {
+			touched = append(touched, update)
+		}
+	}
+	var touchedProviders []version.ProviderUpdateInfo
+	for _, update := range providerUpdates {
+		update.Provider.FilePath = repoRelativePath(root, update.Provider.FilePath)
+		if diff.Touches(update.Provider.FilePath, update.Provider.Line, update.Provider.EndLine) {
+			touchedProviders = append(touchedProviders, update)
+		}
+	}
+
+	log.Info().
+		Int("pr", number).
+		Int("modules", len(touched)).
+		Int("providers", len(touchedProviders)).
+		Msg("dependencies touched by pull request")
+
+	n := notifier.New("", "")
+	markdown := n.OutputMarkdown(notifier.NotificationData{
+		Updates:         touched,
+		ProviderUpdates: touchedProviders,
+		TotalUpdates:    len(touched),
+		Timestamp:       time.Now(),
+	})
+
+	comment, err := reporter.UpsertComment(ctx, number, markdown)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(os.Stderr, "💬 PR comment: %s\n", comment.GetHTMLURL())
+
+	if _, err := reporter.PublishCheckRun(ctx, diff.HeadSHA, markdown, touched, touchedProviders); err != nil {
+		// Check runs need a GitHub App token (e.g. GITHUB_TOKEN in Actions), so don't fail the comment
+		log.Warn().Err(err).Msg("failed to publish check run")
+	}
+
+	return touched, touchedProviders, nil
+}
+
+// gitRepoRoot returns the top-level directory of the current git repository, or "" outside one
+func gitRepoRoot() string {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// repoRelativePath converts a scanned file path to a slash-separated path relative to the repository root
+func repoRelativePath(root, path string) string {
+	if root != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
 func init() {
 	rootCmd.AddCommand(checkCmd)
 
@@ -588,4 +734,32 @@ func init() {
 		"check for unused providers (default: true)")
 	checkCmd.Flags().StringVar(&displayFilter, "display-filter", "",
 		"filter updates to display: all, major-only, minor-and-above, critical-only (default: all)")
+	checkCmd.Flags().IntVar(&checkCommentOnPR, "comment-on-pr", 0,
+		"post results as a sticky comment and check run on this pull request number, limited to dependencies the PR touches")
+	checkCmd.Flags().StringVar(&checkRepo, "repo", "",
+		"GitHub repository for --comment-on-pr (format: owner/repo, default: config or GITHUB_REPOSITORY)")
+	checkCmd.Flags().BoolVar(&checkRefreshAI, "refresh-ai", false,
+		"query the AI backend again instead of reusing cached analyses, and refresh the cache")
+}
+
+// aiClientConfig returns the settings of the configured AI backend
+func aiClientConfig(cfg *config.Config) ai.ClientConfig {
+	backend := cfg.OpenAI.LLMBackendConfig()
+	return ai.ClientConfig{
+		Backend:   cfg.OpenAI.Backend,
+		APIKey:    backend.APIKey,
+		Model:     backend.Model,
+		BaseURL:   backend.BaseURL,
+		Timeout:   time.Duration(backend.TimeoutSeconds) * time.Second,
+		MaxTokens: backend.MaxTokens,
+	}
+}
+
+// newAnalysisCache opens the cache of AI analyses in the configured cache directory
+func newAnalysisCache(cfg *config.Config) (*cache.ValueCache, error) {
+	ttl := ai.DefaultCacheTTL
+	if cfg.OpenAI.CacheTTLHours > 0 {
+		ttl = time.Duration(cfg.OpenAI.CacheTTLHours) * time.Hour
+	}
+	return cache.NewValueCache(cfg.Terraform.CacheDir, "ai-analysis-cache.json", ttl)
 }
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/heyjobs/terranovate/internal/notifier"
-	"github.com/heyjobs/terranovate/internal/scanner"
 	"github.com/heyjobs/terranovate/internal/version"
 	"github.com/heyjobs/terranovate/pkg/config"
 	"github.com/rs/zerolog/log"
@@ -44,13 +43,13 @@ Example:
 			path = "."
 		}
 
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
 		// Create scanner
-		s := scanner.New(
-			path,
-			cfg.Scanner.Exclude,
-			cfg.Scanner.Include,
-			cfg.Scanner.Recursive,
-		)
+		s := newScanner(cfg, path, engine)
 
 		// Scan for modules
 		log.Info().Str("path", path).Msg("scanning for terraform modules")
@@ -74,6 +73,7 @@ Example:
 			cfg.VersionCheck.MinorOnly,
 			cfg.VersionCheck.IgnoreModules,
 		)
+		checker.SetRegistryHost(engine.RegistryHost())
 
 		// Check for updates
 		log.Info().Msg("checking for module updates")
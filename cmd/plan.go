@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/heyjobs/terranovate/internal/terraform"
 	"github.com/heyjobs/terranovate/pkg/config"
@@ -10,7 +11,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var planPath string
+var (
+	planPath         string
+	planKeepSandbox  bool
+	planArtifactsDir string
+	planWorkspaces   []string
+	planVarFiles     []string
+	planVars         []string
+)
 
 // planCmd represents the plan command
 var planCmd = &cobra.Command{
@@ -22,8 +30,13 @@ to validate that the infrastructure changes are valid.
 This command is typically used after updating module versions to ensure
 the changes don't break the infrastructure.
 
+Workspaces, var files and variables come from the root_settings entry matching
+the path, or from the flags, which replace it. With several workspaces, each is
+selected and planned in turn and the results are combined.
+
 Example:
-  terranovate plan --path ./infrastructure`,
+  terranovate plan --path ./infrastructure
+  terranovate plan --path ./envs/app --workspace staging --workspace prod --var-file vars/{workspace}.tfvars`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -42,23 +55,63 @@ Example:
 			path = "."
 		}
 
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
+		// Plan in a copy of the repository to keep the working tree clean
+		sandbox, err := terraform.NewSandbox(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if planKeepSandbox {
+				fmt.Printf("Sandbox kept at %s\n", sandbox.Dir)
+				return
+			}
+			if err := sandbox.Remove(); err != nil {
+				log.Warn().Err(err).Str("sandbox", sandbox.Dir).Msg("failed to remove plan sandbox")
+			}
+		}()
+
+		workDir, err := sandbox.Path(path)
+		if err != nil {
+			return err
+		}
+
+		env := cfg.Terraform.Env
+		if cache, err := terraform.NewCache(cfg.Terraform.CacheDir); err != nil {
+			log.Warn().Err(err).Msg("failed to create plugin cache, init will download providers")
+		} else {
+			env = cache.Env(env)
+		}
+
 		// Create Terraform runner
-		runner, err := terraform.New(path, cfg.Terraform.BinaryPath, cfg.Terraform.Env)
+		runner, err := terraform.New(workDir, engineBinary(cfg, engine), env)
 		if err != nil {
 			return fmt.Errorf("failed to create terraform runner: %w", err)
 		}
+		if planArtifactsDir != "" {
+			runner.SetArtifactDir(planArtifactsDir)
+		}
 
 		// Run terraform init
-		fmt.Println("Running terraform init...")
+		fmt.Printf("Running %s init...\n", engine.Binary())
 		if err := runner.Init(ctx); err != nil {
 			return fmt.Errorf("terraform init failed: %w", err)
 		}
 		fmt.Println("✓ Terraform init completed")
 
-		// Run terraform plan
-		fmt.Println("Running terraform plan...")
-		planResult, err := runner.Plan(ctx)
+		inputs, err := planInputs(cfg, path)
 		if err != nil {
+			return err
+		}
+
+		// Run terraform plan
+		fmt.Printf("Running %s plan...\n", engine.Binary())
+		planResult, err := runner.PlanWorkspaces(ctx, inputs)
+		if err != nil && planResult == nil {
 			return fmt.Errorf("terraform plan failed: %w", err)
 		}
 
@@ -78,9 +131,23 @@ Example:
 				if planResult.ResourcesDestroy > 0 {
 					fmt.Printf("   - %d resource(s) to destroy\n", planResult.ResourcesDestroy)
 				}
+				if planResult.Text != "" {
+					fmt.Printf("\n%s\n", planResult.Text)
+				}
 			} else {
 				fmt.Println("\n✨ No infrastructure changes required")
 			}
+
+			if artifacts := planResult.Artifacts; artifacts != nil {
+				fmt.Println("\n📦 Plan artifacts:")
+				fmt.Printf("   %s\n   %s\n   %s\n", artifacts.PlanFile, artifacts.JSONFile, artifacts.TextFile)
+			}
+			for _, ws := range planResult.Workspaces {
+				if artifacts := ws.Result.Artifacts; artifacts != nil {
+					fmt.Printf("\n📦 Plan artifacts (workspace %s):\n", ws.Workspace)
+					fmt.Printf("   %s\n   %s\n   %s\n", artifacts.PlanFile, artifacts.JSONFile, artifacts.TextFile)
+				}
+			}
 		} else {
 			fmt.Println("✗ Terraform plan failed")
 			fmt.Println(planResult.ErrorMessage)
@@ -91,9 +158,41 @@ Example:
 	},
 }
 
+// planInputs returns the workspaces and variables to plan path with: the flags when
+// given, otherwise the matching root_settings entry
+func planInputs(cfg *config.Config, path string) (*terraform.PlanInputs, error) {
+	if len(planWorkspaces) == 0 && len(planVarFiles) == 0 && len(planVars) == 0 {
+		return terraform.MatchRootInputs(rootInputs(cfg), path), nil
+	}
+
+	inputs := &terraform.PlanInputs{
+		Workspaces: planWorkspaces,
+		VarFiles:   planVarFiles,
+		Vars:       map[string]string{},
+	}
+	for _, v := range planVars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: must be name=value", v)
+		}
+		inputs.Vars[name] = value
+	}
+	return inputs, nil
+}
+
 func init() {
 	rootCmd.AddCommand(planCmd)
 
 	planCmd.Flags().StringVarP(&planPath, "path", "p", "",
 		"path to Terraform working directory (default: current directory)")
+	planCmd.Flags().StringVar(&planArtifactsDir, "artifacts-dir", "",
+		"directory to save the plan in as plan.tfplan, plan.json and plan.txt")
+	planCmd.Flags().StringSliceVar(&planWorkspaces, "workspace", nil,
+		"workspace to plan, repeatable (default: the root_settings entry or the current workspace)")
+	planCmd.Flags().StringArrayVar(&planVarFiles, "var-file", nil,
+		"file passed as -var-file, relative to the path; {workspace} expands to the workspace")
+	planCmd.Flags().StringArrayVar(&planVars, "var", nil,
+		"variable passed as -var, as name=value")
+	planCmd.Flags().BoolVar(&planKeepSandbox, "keep-sandbox", false,
+		"keep the temporary copy the plan runs in, for debugging")
 }
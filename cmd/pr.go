@@ -3,9 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/heyjobs/terranovate/internal/ai"
 	"github.com/heyjobs/terranovate/internal/github"
 	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/schedule"
 	"github.com/heyjobs/terranovate/internal/terraform"
 	"github.com/heyjobs/terranovate/internal/version"
 	"github.com/heyjobs/terranovate/pkg/config"
@@ -14,10 +18,15 @@ import (
 )
 
 var (
-	prPath   string
-	prRepo   string
-	prOwner  string
-	skipPlan bool
+	prPath          string
+	prRepo          string
+	prOwner         string
+	skipPlan        bool
+	planConcurrency int
+	keepSandbox     bool
+	validationLevel string
+	artifactsDir    string
+	prAIMigrate     bool
 )
 
 // prCmd represents the pr command
@@ -70,13 +79,13 @@ Example:
 			path = "."
 		}
 
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
 		// Create scanner
-		s := scanner.New(
-			path,
-			cfg.Scanner.Exclude,
-			cfg.Scanner.Include,
-			cfg.Scanner.Recursive,
-		)
+		s := newScanner(cfg, path, engine)
 
 		// Scan for modules
 		log.Info().Str("path", path).Msg("scanning for terraform modules")
@@ -100,6 +109,8 @@ Example:
 			cfg.VersionCheck.MinorOnly,
 			cfg.VersionCheck.IgnoreModules,
 		)
+		checker.SetRegistryHost(engine.RegistryHost())
+		checker.SetReleaseNotes(cfg.VersionCheck.ReleaseNotes, cfg.VersionCheck.ReleaseNotesMaxBytes)
 
 		// Check for updates
 		log.Info().Msg("checking for module updates")
@@ -108,13 +119,6 @@ Example:
 			return fmt.Errorf("version check failed: %w", err)
 		}
 
-		if len(updates) == 0 {
-			fmt.Println("✨ All modules are up to date!")
-			return nil
-		}
-
-		fmt.Printf("Found %d update(s) available\n\n", len(updates))
-
 		// Create PR creator
 		prCreator, err := github.NewPRCreator(
 			cfg.GitHub.Token,
@@ -129,17 +133,66 @@ Example:
 			return fmt.Errorf("failed to create PR creator: %w", err)
 		}
 
-		// Create Terraform runner for plan validation
-		var runner *terraform.Runner
-		if !skipPlan {
-			runner, err = terraform.New(path, cfg.Terraform.BinaryPath, cfg.Terraform.Env)
+		if cfg.GitHub.AutoMerge.Enabled {
+			prCreator.SetAutoMergePolicy(autoMergePolicy(cfg.GitHub.AutoMerge))
+		}
+
+		// Validate and plan the affected root modules on each PR branch
+		if validationLevel == "" {
+			validationLevel = cfg.Terraform.ValidationLevel
+		}
+		level, err := terraform.ParseValidationLevel(validationLevel)
+		if err != nil {
+			return err
+		}
+		if skipPlan {
+			level = terraform.ValidationNone
+		}
+		var providerSchemaComp *terraform.ProviderSchemaComparator
+		var planner *terraform.RootPlanner
+		if level != terraform.ValidationNone {
+			binary, err := terraform.LookPath(engineBinary(cfg, engine))
 			if err != nil {
-				log.Warn().Err(err).Msg("failed to create terraform runner, skipping plan validation")
+				log.Warn().Err(err).Msg("skipping plan validation")
+			} else {
+				planner = terraform.NewRootPlanner(path, cfg.Terraform.RootModules, binary, cfg.Terraform.Env)
+				planner.SetConcurrency(planConcurrency)
+				planner.SetKeepSandbox(keepSandbox)
+				planner.SetValidationLevel(level)
+				planner.SetMockProviders(cfg.Terraform.MockProviders)
+				planner.SetRootInputs(rootInputs(cfg))
+				if artifactsDir == "" {
+					artifactsDir = cfg.Terraform.ArtifactsDir
+				}
+				planner.SetArtifactsDir(artifactsDir)
+				providerSchemaComp = terraform.NewProviderSchemaComparator(binary, cfg.Terraform.Env)
+				providerSchemaComp.SetRegistryHost(engine.RegistryHost())
+				if cache, err := terraform.NewCache(cfg.Terraform.CacheDir); err != nil {
+					log.Warn().Err(err).Msg("failed to create plugin cache, plans will download providers")
+				} else {
+					planner.SetCache(cache)
+					providerSchemaComp.SetCache(cache)
+				}
+				prCreator.SetPlanner(planner)
+			}
+		}
+
+		// Propose edits of our module calls for breaking module updates
+		if prAIMigrate {
+			if planner == nil {
+				log.Warn().Msg("--ai-migrate validates the proposed edits with terraform, which is disabled; skipping AI migrations")
+			} else if client, err := ai.NewClient(aiClientConfig(cfg)); err != nil {
+				log.Warn().Err(err).Msg("--ai-migrate needs an AI backend (set OPENAI_API_KEY or ANTHROPIC_API_KEY env var or in config); skipping AI migrations")
+			} else {
+				log.Info().Str("model", client.Model()).Msg("AI-proposed migrations enabled")
+				prCreator.SetMigrator(ai.New(client), planner)
 			}
 		}
 
 		// Create schema comparator
 		schemaComp := terraform.NewSchemaComparator()
+		schemaComp.SetRegistryHost(engine.RegistryHost())
+		schemaComp.SetCacheDir(cfg.Terraform.CacheDir)
 
 		// Scan for providers
 		log.Info().Str("path", path).Msg("scanning for terraform providers")
@@ -162,18 +215,72 @@ Example:
 			}
 		}
 
+		// Read the dependency dashboard back and queue held-back updates a maintainer approved
+		var dashboard *github.Dashboard
+		approved := map[string]bool{}
+		if cfg.GitHub.Dashboard.Enabled {
+			dashboard, err = github.NewDashboard(cfg.GitHub.Token, cfg.GitHub.Owner, cfg.GitHub.Repo, cfg.GitHub.Dashboard.Title)
+			if err != nil {
+				return fmt.Errorf("failed to create dependency dashboard: %w", err)
+			}
+
+			approved, err = dashboard.Approvals(ctx)
+			if err != nil {
+				log.Warn().Err(err).Msg("failed to read dependency dashboard approvals")
+				approved = map[string]bool{}
+			}
+
+			for _, update := range checker.HeldBack() {
+				if approved[github.ModuleKey(update.Module)] && update.LatestVersion != "" {
+					log.Info().Str("module", update.Module.Name).Msg("held-back update approved on dashboard")
+					update.IsOutdated = true
+					update.HeldBackReason = ""
+					updates = append(updates, update)
+				}
+			}
+
+			for _, update := range checker.HeldBackProviders() {
+				if approved[github.ProviderKey(update.Provider)] {
+					log.Info().Str("provider", update.Provider.Name).Msg("held-back provider update approved on dashboard")
+					update.IsOutdated = true
+					update.HeldBackReason = ""
+					providerUpdates = append(providerUpdates, update)
+				}
+			}
+		}
+
 		totalUpdates := len(updates) + len(providerUpdates)
 		if totalUpdates == 0 {
 			fmt.Println("✨ All modules and providers are up to date!")
+			refreshDashboard(ctx, dashboard, s, checker, modules, providers, updates, providerUpdates, approved, cfg.VersionCheck.IgnoreUnusedProviders)
 			return nil
 		}
 
-		fmt.Printf("Found %d update(s) available (%d modules, %d providers)\\n\\n",
+		fmt.Printf("Found %d update(s) available (%d modules, %d providers)\n\n",
 			totalUpdates, len(updates), len(providerUpdates))
 
+		// Enforce the PR schedule and rate limits
+		limiter, err := newPRLimiter(ctx, cfg.GitHub, prCreator, time.Now())
+		if err != nil {
+			return err
+		}
+		var deferred []deferredUpdate
+
+		// Analyze the updates with AI once their schemas are diffed, so the analysis can
+		// cite the breaking schema changes. Set after checking, so that each update is
+		// analyzed once.
+		if analyzer := newAIAnalyzer(cfg, false); analyzer != nil {
+			checker.SetAIAnalyzer(analyzer)
+		}
+
 		// Create PRs for each module update
 		successCount := 0
 		for i, update := range updates {
+			if reason := limiter.deferReason(); reason != "" {
+				deferred = append(deferred, deferredUpdate{update.Module.Name, update.CurrentVersion, update.LatestVersion, reason})
+				continue
+			}
+
 			fmt.Printf("[%d/%d] Processing module %s...\n", i+1, totalUpdates, update.Module.Name)
 
 			// Perform schema comparison
@@ -193,36 +300,11 @@ Example:
 					}
 				}
 			}
-
-			var planResult *terraform.PlanResult
-			if runner != nil && !skipPlan {
-				// Run plan to validate
-				log.Info().Str("module", update.Module.Name).Msg("running terraform plan")
-				if err := runner.Init(ctx); err != nil {
-					log.Warn().Err(err).Msg("terraform init failed, creating PR without plan")
-				} else {
-					planResult, err = runner.Plan(ctx)
-					if err != nil {
-						log.Warn().Err(err).Msg("terraform plan failed, creating PR with error")
-					} else if planResult != nil && planResult.Success {
-						// Analyze resource changes from the plan
-						update.ResourceChanges = terraform.AnalyzeResourceChanges(planResult)
-
-						// Enhance breaking change detection with resource analysis
-						if terraform.HasCriticalChanges(update.ResourceChanges) {
-							update.HasBreakingChange = true
-							if update.BreakingChangeDetails == "" {
-								update.BreakingChangeDetails = "This update will cause resource replacements or deletions. Please review carefully."
-							} else {
-								update.BreakingChangeDetails += " Additionally, this update will cause resource replacements or deletions."
-							}
-						}
-					}
-				}
-			}
+			schemaChanges, _ := update.SchemaChanges.(*terraform.SchemaChanges)
+			checker.AnalyzeUpdate(ctx, &update, terraform.BreakingSchemaChanges(schemaChanges))
 
 			// Create PR
-			pr, err := prCreator.CreatePR(ctx, update, planResult)
+			pr, err := prCreator.CreatePR(ctx, update, nil)
 			if err != nil {
 				log.Error().Err(err).Str("module", update.Module.Name).Msg("failed to create PR")
 				fmt.Printf("  ✗ Failed to create PR: %v\n\n", err)
@@ -230,14 +312,47 @@ Example:
 			}
 
 			successCount++
+			limiter.opened()
 			fmt.Printf("  ✓ PR created: %s\n", pr.GetHTMLURL())
 			fmt.Printf("  #%d: %s\n\n", pr.GetNumber(), pr.GetTitle())
 		}
 
 		// Create PRs for each provider update
+		var resources []scanner.ResourceInfo
 		for i, providerUpdate := range providerUpdates {
+			if reason := limiter.deferReason(); reason != "" {
+				deferred = append(deferred, deferredUpdate{"provider " + providerUpdate.Provider.Name, providerUpdate.CurrentVersion, providerUpdate.LatestVersion, reason})
+				continue
+			}
+
 			fmt.Printf("[%d/%d] Processing provider %s...\n", len(updates)+i+1, totalUpdates, providerUpdate.Provider.Name)
 
+			// Diff the schemas of the resource types we use between both versions
+			if providerSchemaComp != nil && providerUpdate.LatestVersion != "" {
+				if resources == nil {
+					if resources, err = s.ScanResources(); err != nil {
+						log.Warn().Err(err).Msg("resource scan failed")
+					}
+				}
+				schemaChanges, err := providerSchemaComp.CompareProvider(ctx, providerUpdate.Provider, providerUpdate.CurrentVersion, providerUpdate.LatestVersion, resources)
+				if err != nil {
+					log.Warn().Err(err).Str("provider", providerUpdate.Provider.Name).Msg("provider schema comparison failed")
+				} else {
+					providerUpdate.SchemaChanges = schemaChanges
+
+					if terraform.HasBreakingProviderSchemaChanges(schemaChanges) {
+						providerUpdate.HasBreakingChange = true
+						if providerUpdate.BreakingChangeDetails == "" {
+							providerUpdate.BreakingChangeDetails = "This update removes or newly requires resource types or attributes used in this repository."
+						} else {
+							providerUpdate.BreakingChangeDetails += " This update also removes or newly requires resource types or attributes used in this repository."
+						}
+					}
+				}
+			}
+			providerSchemaChanges, _ := providerUpdate.SchemaChanges.(*terraform.ProviderSchemaChanges)
+			checker.AnalyzeProviderUpdate(ctx, &providerUpdate, terraform.BreakingProviderSchemaChanges(providerSchemaChanges))
+
 			// Create PR for provider update
 			pr, err := prCreator.CreateProviderPR(ctx, providerUpdate)
 			if err != nil {
@@ -247,16 +362,184 @@ Example:
 			}
 
 			successCount++
+			limiter.opened()
 			fmt.Printf("  ✓ PR created: %s\n", pr.GetHTMLURL())
 			fmt.Printf("  #%d: %s\n\n", pr.GetNumber(), pr.GetTitle())
 		}
 
-		fmt.Printf("\n✓ Successfully created %d/%d pull request(s)\n", successCount, totalUpdates)
+		fmt.Printf("\n✓ Successfully created %d/%d pull request(s)\n", successCount, totalUpdates-len(deferred))
+
+		if artifactsDir != "" {
+			fmt.Printf("\n📦 Plan artifacts: %s\n", filepath.Join(artifactsDir, terraform.ManifestFile))
+		}
+
+		if len(deferred) > 0 {
+			fmt.Printf("\n⏸️  Deferred %d update(s) to a later run:\n", len(deferred))
+			for _, d := range deferred {
+				fmt.Printf("  - %s %s → %s: %s\n", d.name, d.currentVersion, d.latestVersion, d.reason)
+			}
+		}
+
+		refreshDashboard(ctx, dashboard, s, checker, modules, providers, updates, providerUpdates, approved, cfg.VersionCheck.IgnoreUnusedProviders)
 
 		return nil
 	},
 }
 
+// deferredUpdate is an update whose PR was held over to a later run
+type deferredUpdate struct {
+	name           string
+	currentVersion string
+	latestVersion  string
+	reason         string
+}
+
+// prLimiter enforces the PR schedule, concurrent limit and hourly limit during a pr run
+type prLimiter struct {
+	outsideSchedule string
+	concurrentLimit int
+	open            int
+	hourlyLimit     int
+	createdLastHour int
+}
+
+// newPRLimiter checks the schedule and counts existing Terranovate PRs
+func newPRLimiter(ctx context.Context, cfg config.GitHubConfig, prCreator *github.PRCreator, now time.Time) (*prLimiter, error) {
+	sched, err := schedule.New(cfg.Schedule.Windows, cfg.Schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github.schedule: %w", err)
+	}
+
+	limiter := &prLimiter{
+		concurrentLimit: cfg.PRConcurrentLimit,
+		hourlyLimit:     cfg.PRHourlyLimit,
+	}
+
+	if !sched.Allows(now) {
+		limiter.outsideSchedule = fmt.Sprintf("outside the PR schedule %s", sched)
+		return limiter, nil
+	}
+
+	if limiter.concurrentLimit > 0 || limiter.hourlyLimit > 0 {
+		limiter.open, limiter.createdLastHour, err = prCreator.PRStats(ctx, now.Add(-time.Hour))
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to count open terranovate PRs, limits only apply to this run")
+		}
+		log.Info().
+			Int("open", limiter.open).
+			Int("created_last_hour", limiter.createdLastHour).
+			Msg("existing terranovate PRs")
+	}
+
+	return limiter, nil
+}
+
+// deferReason returns why the next PR must be deferred, or "" if it may be opened
+func (l *prLimiter) deferReason() string {
+	switch {
+	case l.outsideSchedule != "":
+		return l.outsideSchedule
+	case l.concurrentLimit > 0 && l.open >= l.concurrentLimit:
+		return fmt.Sprintf("pr_concurrent_limit reached (%d open PRs)", l.open)
+	case l.hourlyLimit > 0 && l.createdLastHour >= l.hourlyLimit:
+		return fmt.Sprintf("pr_hourly_limit reached (%d PRs created in the last hour)", l.createdLastHour)
+	}
+	return ""
+}
+
+// opened records a PR created in this run
+func (l *prLimiter) opened() {
+	l.open++
+	l.createdLastHour++
+}
+
+// refreshDashboard rewrites the dependency dashboard issue with the results of this run
+func refreshDashboard(ctx context.Context, dashboard *github.Dashboard, s *scanner.Scanner, checker *version.Checker,
+	modules []scanner.ModuleInfo, providers []scanner.ProviderInfo,
+	updates []version.UpdateInfo, providerUpdates []version.ProviderUpdateInfo,
+	approved map[string]bool, ignoreUnusedProviders []string) {
+	if dashboard == nil {
+		return
+	}
+
+	openPRs, err := dashboard.OpenPRs(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to list open pull requests for dashboard")
+	}
+
+	var unusedProviders []scanner.UnusedProviderInfo
+	if len(providers) > 0 {
+		resources, err := s.ScanResources()
+		if err != nil {
+			log.Warn().Err(err).Msg("resource scan failed")
+		} else {
+			unusedProviders = scanner.DetectUnusedProviders(providers, resources, modules, ignoreUnusedProviders)
+		}
+	}
+
+	// Updates promoted from the held-back list stay listed there, with their PR
+	// link once opened or a ticked checkbox while still waiting for one
+	heldBackKeys := make(map[string]bool)
+	for _, update := range checker.HeldBack() {
+		heldBackKeys[github.ModuleKey(update.Module)] = true
+	}
+	for _, update := range checker.HeldBackProviders() {
+		heldBackKeys[github.ProviderKey(update.Provider)] = true
+	}
+
+	var pending []version.UpdateInfo
+	for _, update := range updates {
+		if !heldBackKeys[github.ModuleKey(update.Module)] {
+			pending = append(pending, update)
+		}
+	}
+	var pendingProviders []version.ProviderUpdateInfo
+	for _, update := range providerUpdates {
+		if !heldBackKeys[github.ProviderKey(update.Provider)] {
+			pendingProviders = append(pendingProviders, update)
+		}
+	}
+
+	data := github.DashboardData{
+		Updates:           pending,
+		ProviderUpdates:   pendingProviders,
+		HeldBack:          checker.HeldBack(),
+		HeldBackProviders: checker.HeldBackProviders(),
+		UnusedProviders:   unusedProviders,
+		Failures:          checker.Failures(),
+		OpenPRs:           openPRs,
+		Approved:          approved,
+	}
+
+	issue, err := dashboard.Update(ctx, data)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to update dependency dashboard")
+		return
+	}
+
+	fmt.Printf("📋 Dependency dashboard: %s\n", issue.GetHTMLURL())
+}
+
+// autoMergePolicy converts the auto-merge configuration into a PR creator policy
+func autoMergePolicy(cfg config.AutoMergeConfig) *github.AutoMergePolicy {
+	policy := &github.AutoMergePolicy{
+		MergeMethod:        cfg.MergeMethod,
+		UpdateTypes:        cfg.UpdateTypes,
+		MaxResourceChanges: cfg.MaxResourceChanges,
+	}
+
+	for _, rule := range cfg.PackageRules {
+		policy.Rules = append(policy.Rules, github.AutoMergeRule{
+			Match:              rule.Match,
+			AutoMerge:          rule.AutoMerge,
+			UpdateTypes:        rule.UpdateTypes,
+			MaxResourceChanges: rule.MaxResourceChanges,
+		})
+	}
+
+	return policy
+}
+
 func init() {
 	rootCmd.AddCommand(prCmd)
 
@@ -267,7 +550,17 @@ func init() {
 	prCmd.Flags().StringVar(&prOwner, "owner", "",
 		"GitHub repository owner")
 	prCmd.Flags().BoolVar(&skipPlan, "skip-plan", false,
-		"skip terraform plan validation")
+		"skip terraform plan validation (same as --validation-level none)")
+	prCmd.Flags().StringVar(&validationLevel, "validation-level", "",
+		"how far to validate each PR branch: none, init, validate or plan (default: plan)")
+	prCmd.Flags().IntVar(&planConcurrency, "plan-concurrency", 1,
+		"number of root modules to plan at once")
+	prCmd.Flags().StringVar(&artifactsDir, "artifacts-dir", "",
+		"directory to save each plan in (binary, JSON and text), with a manifest.json for CI upload")
+	prCmd.Flags().BoolVar(&keepSandbox, "keep-sandbox", false,
+		"keep the temporary copies plans run in, for debugging")
+	prCmd.Flags().BoolVar(&prAIMigrate, "ai-migrate", false,
+		"ask the AI backend for edits of module calls broken by an update and commit them once terraform validate passes")
 }
 
 // parseRepo parses owner/repo format
@@ -138,7 +138,7 @@ func TestRootCmdSubcommands(t *testing.T) {
 	}
 
 	// These commands should exist based on the files in cmd/
-	expectedCommands := []string{"scan", "check", "pr", "plan", "notify"}
+	expectedCommands := []string{"scan", "check", "pr", "update", "plan", "notify"}
 	for _, expected := range expectedCommands {
 		if !commandNames[expected] {
 			// Not all commands may be registered yet, so we just log
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/terraform"
 	"github.com/heyjobs/terranovate/pkg/config"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -39,13 +40,13 @@ Example:
 			path = "."
 		}
 
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
 		// Create scanner
-		s := scanner.New(
-			path,
-			cfg.Scanner.Exclude,
-			cfg.Scanner.Include,
-			cfg.Scanner.Recursive,
-		)
+		s := newScanner(cfg, path, engine)
 
 		// Scan for modules
 		log.Info().Str("path", path).Msg("scanning for terraform modules")
@@ -89,3 +90,51 @@ func loadConfig() (*config.Config, error) {
 
 	return config.Load(cfgFile)
 }
+
+// resolveEngine detects whether path is planned with Terraform or OpenTofu
+func resolveEngine(cfg *config.Config, path string) (terraform.Engine, error) {
+	engine, err := terraform.DetectEngine(cfg.Terraform.Engine, path)
+	if err != nil {
+		return "", err
+	}
+	log.Debug().Str("engine", engine.Binary()).Msg("resolved terraform engine")
+	return engine, nil
+}
+
+// engineBinary returns the configured binary path, or the engine's executable
+func engineBinary(cfg *config.Config, engine terraform.Engine) string {
+	if cfg.Terraform.BinaryPath != "" {
+		return cfg.Terraform.BinaryPath
+	}
+	return engine.Binary()
+}
+
+// newScanner creates a scanner for path that also reads .tofu files for OpenTofu
+func newScanner(cfg *config.Config, path string, engine terraform.Engine) *scanner.Scanner {
+	s := scanner.New(
+		path,
+		cfg.Scanner.Exclude,
+		cfg.Scanner.Include,
+		cfg.Scanner.Recursive,
+	)
+	if engine == terraform.EngineOpenTofu {
+		s.EnableOpenTofu()
+	}
+	return s
+}
+
+// rootInputs converts the configured root settings for the root planner
+func rootInputs(cfg *config.Config) []terraform.RootInputs {
+	var inputs []terraform.RootInputs
+	for _, settings := range cfg.Terraform.RootSettings {
+		inputs = append(inputs, terraform.RootInputs{
+			Path: settings.Path,
+			PlanInputs: terraform.PlanInputs{
+				Workspaces: settings.Workspaces,
+				VarFiles:   settings.VarFiles,
+				Vars:       settings.Vars,
+			},
+		})
+	}
+	return inputs
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/heyjobs/terranovate/internal/edit"
+	"github.com/heyjobs/terranovate/internal/version"
+	"github.com/heyjobs/terranovate/pkg/config"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updatePath   string
+	updateDryRun bool
+	updateOnly   string
+	updateType   string
+	updateYes    bool
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Apply module and provider updates to local files",
+	Long: `Update scans for outdated modules and providers and rewrites their versions
+in the local Terraform files, without creating branches or pull requests.
+
+Example:
+  terranovate update --path ./infrastructure --dry-run
+  terranovate update --only vpc,provider:aws --type minor`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		// Load configuration
+		cfg, err := loadConfig()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to load config, using defaults")
+			cfg = config.Default()
+		}
+
+		filter, err := edit.NewFilter(updateOnly, updateType)
+		if err != nil {
+			return err
+		}
+
+		path := updatePath
+		if path == "" {
+			path = "."
+		}
+
+		engine, err := resolveEngine(cfg, path)
+		if err != nil {
+			return err
+		}
+
+		// Create scanner
+		s := newScanner(cfg, path, engine)
+
+		log.Info().Str("path", path).Msg("scanning for terraform modules")
+		modules, err := s.Scan()
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+
+		log.Info().Str("path", path).Msg("scanning for terraform providers")
+		providers, err := s.ScanProviders()
+		if err != nil {
+			log.Warn().Err(err).Msg("provider scan failed")
+			providers = nil
+		}
+
+		// Create version checker
+		checker := version.New(
+			cfg.GitHub.Token,
+			cfg.VersionCheck.SkipPrerelease,
+			cfg.VersionCheck.PatchOnly,
+			cfg.VersionCheck.MinorOnly,
+			cfg.VersionCheck.IgnoreModules,
+		)
+		checker.SetRegistryHost(engine.RegistryHost())
+
+		var updates []version.UpdateInfo
+		if len(modules) > 0 {
+			log.Info().Msg("checking for module updates")
+			updates, err = checker.Check(ctx, modules)
+			if err != nil {
+				return fmt.Errorf("version check failed: %w", err)
+			}
+		}
+
+		var providerUpdates []version.ProviderUpdateInfo
+		if len(providers) > 0 {
+			log.Info().Msg("checking for provider updates")
+			providerUpdates, err = checker.CheckProviders(ctx, providers)
+			if err != nil {
+				log.Warn().Err(err).Msg("provider version check failed")
+				providerUpdates = nil
+			}
+		}
+
+		// Stage the selected updates in memory
+		changes := edit.NewChangeset()
+		var applied []string
+		for _, update := range updates {
+			if !update.IsOutdated || !filter.MatchModule(update) {
+				continue
+			}
+			if err := changes.AddModule(update); err != nil {
+				log.Warn().Err(err).Str("module", update.Module.Name).Msg("failed to update module")
+				fmt.Printf("  ✗ %s: %v\n", update.Module.Name, err)
+				continue
+			}
+			applied = append(applied, fmt.Sprintf("%s %s → %s (%s)",
+				update.Module.Name, update.CurrentVersion, update.LatestVersion, update.UpdateType))
+		}
+
+		for _, update := range providerUpdates {
+			if !update.IsOutdated || !filter.MatchProvider(update) {
+				continue
+			}
+			if err := changes.AddProvider(update); err != nil {
+				log.Warn().Err(err).Str("provider", update.Provider.Name).Msg("failed to update provider")
+				fmt.Printf("  ✗ provider %s: %v\n", update.Provider.Name, err)
+				continue
+			}
+			applied = append(applied, fmt.Sprintf("provider %s %s → %s (%s)",
+				update.Provider.Name, update.CurrentVersion, update.LatestVersion, update.UpdateType))
+		}
+
+		files := changes.Files()
+		if len(files) == 0 {
+			fmt.Println("✨ Nothing to update!")
+			return nil
+		}
+
+		fmt.Printf("%d update(s) in %d file(s):\n", len(applied), len(files))
+		for _, line := range applied {
+			fmt.Printf("  - %s\n", line)
+		}
+		fmt.Println()
+
+		if updateDryRun {
+			diff, err := changes.Diff()
+			if err != nil {
+				return fmt.Errorf("failed to render diff: %w", err)
+			}
+			fmt.Print(diff)
+			return nil
+		}
+
+		if !updateYes && !confirm("Apply these updates?") {
+			fmt.Println("Aborted, no files were changed.")
+			return nil
+		}
+
+		if err := changes.Write(); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			fmt.Printf("  ✓ Updated %s\n", file.Path)
+		}
+
+		return nil
+	},
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().StringVarP(&updatePath, "path", "p", "",
+		"path to scan for Terraform files (default: current directory)")
+	updateCmd.Flags().BoolVar(&updateDryRun, "dry-run", false,
+		"print a unified diff of the changes without writing any files")
+	updateCmd.Flags().StringVar(&updateOnly, "only", "",
+		"comma-separated modules to update; use provider:<name> for providers (e.g. vpc,provider:aws)")
+	updateCmd.Flags().StringVar(&updateType, "type", "",
+		"highest update type to apply: patch, minor or major")
+	updateCmd.Flags().BoolVarP(&updateYes, "yes", "y", false,
+		"apply updates without asking for confirmation")
+}
@@ -1,205 +1,337 @@
 package ai
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-// Analyzer provides AI-powered breaking change detection using OpenAI API
+// promptVersion identifies the prompt in cache keys; bump it whenever buildPrompt or
+// the response format changes, so analyses of an older prompt are not reused
+const promptVersion = 2
+
+// maxAttempts bounds how often the model is asked when its answer is malformed
+const maxAttempts = 3
+
+// DefaultCacheTTL is how long a cached analysis is reused; the answer for an update
+// only changes with its evidence, the model or the prompt, which are part of the cache key
+const DefaultCacheTTL = 30 * 24 * time.Hour
+
+// Analyzer provides AI-powered breaking change detection using a language model
 type Analyzer struct {
-	apiKey     string
-	model      string
-	baseURL    string
-	httpClient *http.Client
+	client LLMClient
+
+	cache   AnalysisCache // Optional cache of previous analyses
+	refresh bool          // Query the model even when the cache has an analysis
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// AnalysisCache persists analyses between runs, such as a cache.ValueCache
+type AnalysisCache interface {
+	Get(key string, v interface{}) bool
+	Set(key string, v interface{})
 }
 
 // BreakingChangeAnalysis represents the AI analysis result
 type BreakingChangeAnalysis struct {
 	HasBreakingChanges bool     `json:"has_breaking_changes"`
 	Summary            string   `json:"summary"`
-	Details            []string `json:"details"`
-	Confidence         string   `json:"confidence"` // high, medium, low
+	Details            []Detail `json:"details"`
+	Confidence         string   `json:"confidence"` // high, medium, low, computed from evidence coverage
+}
+
+// Detail is one change the analysis reports, with the evidence it came from
+type Detail struct {
+	Text      string     `json:"text"`
+	Citations []Evidence `json:"citations"`
+}
+
+// String renders the detail followed by its citations
+func (d Detail) String() string {
+	var cites []string
+	for _, c := range d.Citations {
+		cites = append(cites, fmt.Sprintf("%s: %q", c.Source, c.Text))
+	}
+	if len(cites) == 0 {
+		return d.Text
+	}
+	return fmt.Sprintf("%s [%s]", d.Text, strings.Join(cites, "; "))
 }
 
 // AIAnalysis is an alias for BreakingChangeAnalysis to match version package expectations
 type AIAnalysis = BreakingChangeAnalysis
 
-// New creates a new AI analyzer instance
-func New(apiKey, model, baseURL string) *Analyzer {
-	return &Analyzer{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+// AnalysisRequest describes an update to analyze and the evidence available for it
+type AnalysisRequest struct {
+	Name           string
+	Source         string
+	CurrentVersion string
+	LatestVersion  string
+	ChangelogURL   string
+
+	// Release notes of the versions after current up to latest, newest first
+	ReleaseNotes []ReleaseNote
+
+	// The release notes were cut or dropped to fit a size limit
+	ReleaseNotesTruncated bool
+
+	// Breaking API changes a schema diff found, one per entry, when the caller diffed
+	// the schemas of both versions
+	SchemaChanges []string
+}
+
+// ReleaseNote is the release notes of one version
+type ReleaseNote struct {
+	Version string
+	Body    string
+}
+
+// New creates a new AI analyzer asking the given client
+func New(client LLMClient) *Analyzer {
+	return &Analyzer{client: client}
+}
+
+// SetCache enables reusing the analyses of an update across runs. With refresh the
+// model is queried again and the cached analysis replaced.
+func (a *Analyzer) SetCache(cache AnalysisCache, refresh bool) {
+	a.cache = cache
+	a.refresh = refresh
 }
 
-// AnalyzeBreakingChanges analyzes changelog/release notes for breaking changes
-func (a *Analyzer) AnalyzeBreakingChanges(ctx context.Context, moduleName, currentVersion, latestVersion, changelogURL string) (*AIAnalysis, error) {
-	if a.apiKey == "" {
-		return nil, fmt.Errorf("OpenAI API key not configured")
+// CacheCounts returns how many analyses were read from the cache and how many had
+// to query the model
+func (a *Analyzer) CacheCounts() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.hits, a.misses
+}
+
+// AnalyzeBreakingChanges analyzes the release notes and schema changes of an update
+// for breaking changes. The model answers in a JSON schema citing the evidence of
+// every detail; malformed answers are retried up to maxAttempts times. The confidence
+// is computed from how well the cited evidence covers the breaking changes the
+// evidence announces. Analyses without evidence, e.g. when the release notes could not
+// be fetched, are never cached, so a later run with evidence answers again.
+func (a *Analyzer) AnalyzeBreakingChanges(ctx context.Context, req AnalysisRequest) (*AIAnalysis, error) {
+	evidence := collectEvidence(req)
+	cacheable := a.cache != nil && len(evidence) > 0
+
+	key := a.cacheKey(req.Source, req.CurrentVersion, req.LatestVersion, evidence, req.ReleaseNotesTruncated)
+	if cacheable {
+		var cached BreakingChangeAnalysis
+		hit := !a.refresh && a.cache.Get(key, &cached)
+		a.mu.Lock()
+		if hit {
+			a.hits++
+		} else {
+			a.misses++
+		}
+		a.mu.Unlock()
+		if hit {
+			log.Debug().Str("module", req.Name).Str("key", key).Msg("using cached AI analysis")
+			return &cached, nil
+		}
 	}
 
-	prompt := a.buildPrompt(moduleName, currentVersion, latestVersion, changelogURL)
+	prompt := buildPrompt(req, evidence)
 
 	log.Debug().
-		Str("module", moduleName).
-		Str("current", currentVersion).
-		Str("latest", latestVersion).
+		Str("module", req.Name).
+		Str("current", req.CurrentVersion).
+		Str("latest", req.LatestVersion).
+		Int("evidence", len(evidence)).
 		Msg("analyzing breaking changes with AI")
 
-	response, err := a.callOpenAI(ctx, prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+	var analysis *BreakingChangeAnalysis
+	var parseErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		attemptPrompt := prompt
+		if parseErr != nil {
+			attemptPrompt += fmt.Sprintf("\n\nYour previous answer was rejected: %v. Answer again with JSON that matches the schema exactly.", parseErr)
+		}
+
+		response, err := a.client.CompleteJSON(ctx, attemptPrompt, analysisSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call AI API: %w", err)
+		}
+
+		analysis, parseErr = parseResponse(response, evidence)
+		if parseErr == nil {
+			break
+		}
+		log.Debug().Err(parseErr).Str("module", req.Name).Int("attempt", attempt).Msg("malformed AI response")
 	}
-
-	analysis, err := a.parseResponse(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse AI response after %d attempts: %w", maxAttempts, parseErr)
 	}
 
+	analysis.Confidence = computeConfidence(analysis, evidence, req.ReleaseNotesTruncated)
+
 	log.Debug().
-		Str("module", moduleName).
+		Str("module", req.Name).
 		Bool("breaking_changes", analysis.HasBreakingChanges).
 		Str("confidence", analysis.Confidence).
 		Msg("AI analysis completed")
 
+	if cacheable {
+		a.cache.Set(key, analysis)
+	}
+
 	return analysis, nil
 }
 
-// buildPrompt creates the prompt for OpenAI
-func (a *Analyzer) buildPrompt(moduleName, currentVersion, latestVersion, changelogURL string) string {
+// cacheKey identifies an analysis by everything its answer depends on: the update,
+// its evidence, the model and the prompt
+func (a *Analyzer) cacheKey(source, currentVersion, latestVersion string, evidence []Evidence, truncated bool) string {
+	return strings.Join([]string{
+		source,
+		strings.TrimPrefix(currentVersion, "v"),
+		strings.TrimPrefix(latestVersion, "v"),
+		evidenceHash(evidence, truncated),
+		a.client.Model(),
+		fmt.Sprintf("prompt-v%d", promptVersion),
+	}, "|")
+}
+
+// evidenceHash is a short hash of the evidence an analysis is asked on, so that new
+// release notes or schema changes of an update are analyzed again
+func evidenceHash(evidence []Evidence, truncated bool) string {
+	h := sha256.New()
+	for _, e := range evidence {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", e.ID, e.Source, e.Text)
+	}
+	fmt.Fprintf(h, "truncated=%t", truncated)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// buildPrompt creates the prompt for the language model, listing the evidence by ID
+func buildPrompt(req AnalysisRequest, evidence []Evidence) string {
+	var lines strings.Builder
+	for _, e := range evidence {
+		lines.WriteString(fmt.Sprintf("[%s] %s: %s\n", e.ID, e.Source, e.Text))
+	}
+	if req.ReleaseNotesTruncated {
+		lines.WriteString("(The release notes were truncated.)\n")
+	}
+
+	citing := `Every detail must cite in "evidence" the IDs of the lines above it is based on, e.g. ["R3", "S1"], and only report changes the evidence mentions.`
+	if len(evidence) == 0 {
+		lines.WriteString("No release notes could be fetched and no schema changes are known.\n")
+		citing = `With no evidence to cite, leave "details" empty and base your answer on version number patterns.`
+	}
+
 	return fmt.Sprintf(`You are an expert in analyzing Terraform module and provider updates for breaking changes.
 
 Analyze the upgrade from version %s to %s for the following module/provider:
 Module/Provider: %s
+Source: %s
 Changelog URL: %s
 
+Evidence, one line per schema change (S) and release note line (R):
+%s
 Please analyze if this update contains breaking changes. Consider:
 1. API changes (removed variables, changed types, new required variables)
 2. Resource replacements or deletions
 3. Major behavioral changes
 4. Deprecations that affect functionality
 
-Respond ONLY with valid JSON in this exact format (no markdown, no code blocks):
+Respond ONLY with JSON matching the response schema (no markdown, no code blocks):
 {
   "has_breaking_changes": true or false,
   "summary": "Brief summary of the changes (1-2 sentences)",
-  "details": ["detail 1", "detail 2", "detail 3"],
-  "confidence": "high, medium, or low"
+  "details": [{"text": "One breaking or notable change", "evidence": ["R1"]}]
 }
 
-Important: Base your analysis on version number patterns and Terraform best practices. If you cannot determine with certainty, set confidence to "low" or "medium".`, currentVersion, latestVersion, moduleName, changelogURL)
+Important: %s`, req.CurrentVersion, req.LatestVersion, req.Name, req.Source, req.ChangelogURL, lines.String(), citing)
 }
 
-// callOpenAI makes a request to the OpenAI API
-func (a *Analyzer) callOpenAI(ctx context.Context, prompt string) (string, error) {
-	reqBody := map[string]interface{}{
-		"model": a.model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.3, // Lower temperature for more consistent/deterministic results
-		"max_tokens":  1000,
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	endpoint := a.baseURL + "/chat/completions"
-	log.Debug().
-		Str("endpoint", endpoint).
-		Str("model", a.model).
-		Str("api_key_prefix", maskAPIKey(a.apiKey)).
-		Msg("calling AI API")
-
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
+// analysisResponse is the JSON an analysis is answered in. Pointers tell missing
+// fields from zero values.
+type analysisResponse struct {
+	HasBreakingChanges *bool    `json:"has_breaking_changes"`
+	Summary            *string  `json:"summary"`
+	Details            []detail `json:"details"`
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+// detail is a detail as answered by the model, citing evidence by ID
+type detail struct {
+	Text     string   `json:"text"`
+	Evidence []string `json:"evidence"`
+}
 
-	resp, err := a.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
+// parseResponse strictly parses an answer: it must be a single JSON object with exactly
+// the fields of analysisSchema, every detail must cite known evidence, and breaking
+// changes need a detail unless there is no evidence to cite
+func parseResponse(response string, evidence []Evidence) (*BreakingChangeAnalysis, error) {
+	decoder := json.NewDecoder(strings.NewReader(strings.TrimSpace(response)))
+	decoder.DisallowUnknownFields()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	var answer analysisResponse
+	if err := decoder.Decode(&answer); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API returned status %d: %s", resp.StatusCode, string(body))
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected content after the JSON object")
 	}
 
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-			Type    string `json:"type"`
-		} `json:"error"`
+	if answer.HasBreakingChanges == nil {
+		return nil, fmt.Errorf("has_breaking_changes is missing")
 	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	if answer.Summary == nil || strings.TrimSpace(*answer.Summary) == "" {
+		return nil, fmt.Errorf("summary is missing")
 	}
-
-	if result.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s (%s)", result.Error.Message, result.Error.Type)
+	if answer.Details == nil {
+		return nil, fmt.Errorf("details is missing")
 	}
 
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+	byID := make(map[string]Evidence, len(evidence))
+	for _, e := range evidence {
+		byID[e.ID] = e
 	}
 
-	return result.Choices[0].Message.Content, nil
-}
-
-// parseResponse parses the AI response into structured data
-func (a *Analyzer) parseResponse(response string) (*BreakingChangeAnalysis, error) {
-	// Try to find JSON in the response (in case the model wrapped it in markdown)
-	jsonStart := bytes.Index([]byte(response), []byte("{"))
-	jsonEnd := bytes.LastIndex([]byte(response), []byte("}"))
-
-	if jsonStart == -1 || jsonEnd == -1 {
-		return nil, fmt.Errorf("no JSON found in response")
+	analysis := &BreakingChangeAnalysis{
+		HasBreakingChanges: *answer.HasBreakingChanges,
+		Summary:            strings.TrimSpace(*answer.Summary),
+		Details:            []Detail{},
 	}
-
-	jsonContent := response[jsonStart : jsonEnd+1]
-
-	var analysis BreakingChangeAnalysis
-	if err := json.Unmarshal([]byte(jsonContent), &analysis); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	for i, d := range answer.Details {
+		if strings.TrimSpace(d.Text) == "" {
+			return nil, fmt.Errorf("detail %d has no text", i+1)
+		}
+		if len(d.Evidence) == 0 {
+			return nil, fmt.Errorf("detail %d cites no evidence", i+1)
+		}
+
+		parsed := Detail{Text: strings.TrimSpace(d.Text)}
+		seen := map[string]bool{}
+		for _, id := range d.Evidence {
+			e, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("detail %d cites unknown evidence %q", i+1, id)
+			}
+			if !seen[id] {
+				seen[id] = true
+				parsed.Citations = append(parsed.Citations, e)
+			}
+		}
+		analysis.Details = append(analysis.Details, parsed)
 	}
-
-	// Validate confidence level
-	if analysis.Confidence != "high" && analysis.Confidence != "medium" && analysis.Confidence != "low" {
-		analysis.Confidence = "low"
+	// Without evidence the prompt asks for no details, and the answer is rated low
+	if analysis.HasBreakingChanges && len(analysis.Details) == 0 && len(evidence) > 0 {
+		return nil, fmt.Errorf("breaking changes reported with no details")
 	}
 
-	return &analysis, nil
+	return analysis, nil
 }
 
 // maskAPIKey masks an API key for logging, showing only first 4 and last 4 characters
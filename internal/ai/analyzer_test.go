@@ -0,0 +1,227 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// mapCache is an in-memory AnalysisCache
+type mapCache map[string]BreakingChangeAnalysis
+
+func (c mapCache) Get(key string, v interface{}) bool {
+	analysis, ok := c[key]
+	if ok {
+		*v.(*BreakingChangeAnalysis) = analysis
+	}
+	return ok
+}
+
+func (c mapCache) Set(key string, v interface{}) {
+	c[key] = *v.(*BreakingChangeAnalysis)
+}
+
+func TestAnalyzerCache(t *testing.T) {
+	ctx := context.Background()
+	cache := mapCache{}
+	client := &FakeClient{Responses: []string{
+		`{"has_breaking_changes": false, "summary": "first", "details": []}`,
+		`{"has_breaking_changes": false, "summary": "second", "details": []}`,
+	}}
+
+	notes := []ReleaseNote{{Version: "5.0.0", Body: "* Bug fixes"}}
+
+	analyzer := New(client)
+	analyzer.SetCache(cache, false)
+
+	for _, current := range []string{"4.0.0", "v4.0.0"} {
+		analysis, err := analyzer.AnalyzeBreakingChanges(ctx, AnalysisRequest{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", CurrentVersion: current, LatestVersion: "5.0.0", ReleaseNotes: notes})
+		if err != nil {
+			t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+		}
+		if analysis.Summary != "first" {
+			t.Errorf("AnalyzeBreakingChanges() summary = %q, want first", analysis.Summary)
+		}
+	}
+	if len(client.Prompts) != 1 {
+		t.Errorf("model queried %d times, want 1", len(client.Prompts))
+	}
+	if hits, misses := analyzer.CacheCounts(); hits != 1 || misses != 1 {
+		t.Errorf("CacheCounts() = %d, %d, want 1, 1", hits, misses)
+	}
+
+	// Another source is another update
+	if _, err := analyzer.AnalyzeBreakingChanges(ctx, AnalysisRequest{Name: "vpc", Source: "acme/vpc/aws", CurrentVersion: "4.0.0", LatestVersion: "5.0.0", ReleaseNotes: notes}); err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	if len(client.Prompts) != 2 {
+		t.Errorf("model queried %d times, want 2", len(client.Prompts))
+	}
+
+	// Refreshing queries the model and replaces the cached analysis
+	refreshing := New(client)
+	refreshing.SetCache(cache, true)
+	analysis, err := refreshing.AnalyzeBreakingChanges(ctx, AnalysisRequest{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", CurrentVersion: "4.0.0", LatestVersion: "5.0.0", ReleaseNotes: notes})
+	if err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	if analysis.Summary != "second" || len(client.Prompts) != 3 {
+		t.Errorf("refresh returned %q after %d queries, want second after 3", analysis.Summary, len(client.Prompts))
+	}
+	if hits, misses := refreshing.CacheCounts(); hits != 0 || misses != 1 {
+		t.Errorf("CacheCounts() = %d, %d, want 0, 1", hits, misses)
+	}
+	key := analyzer.cacheKey("terraform-aws-modules/vpc/aws", "4.0.0", "5.0.0", collectEvidence(AnalysisRequest{ReleaseNotes: notes}), false)
+	if cached := cache[key]; cached.Summary != "second" {
+		t.Errorf("cached summary = %q, want second", cached.Summary)
+	}
+}
+
+func TestAnalyzerCacheEvidence(t *testing.T) {
+	ctx := context.Background()
+	cache := mapCache{}
+	client := &FakeClient{Responses: []string{
+		`{"has_breaking_changes": false, "summary": "guessed", "details": []}`,
+		`{"has_breaking_changes": false, "summary": "guessed again", "details": []}`,
+		`{"has_breaking_changes": false, "summary": "from notes", "details": []}`,
+		`{"has_breaking_changes": true, "summary": "from schema", "details": [{"text": "x removed", "evidence": ["S1"]}]}`,
+	}}
+
+	analyzer := New(client)
+	analyzer.SetCache(cache, false)
+	req := AnalysisRequest{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", CurrentVersion: "4.0.0", LatestVersion: "5.0.0"}
+
+	// Answers without evidence are not cached
+	for _, want := range []string{"guessed", "guessed again"} {
+		analysis, err := analyzer.AnalyzeBreakingChanges(ctx, req)
+		if err != nil {
+			t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+		}
+		if analysis.Summary != want {
+			t.Errorf("AnalyzeBreakingChanges() summary = %q, want %q", analysis.Summary, want)
+		}
+	}
+	if len(cache) != 0 {
+		t.Errorf("cached %d analyses without evidence, want none", len(cache))
+	}
+
+	// Other evidence for the same update is analyzed again
+	req.ReleaseNotes = []ReleaseNote{{Version: "5.0.0", Body: "* Bug fixes"}}
+	if _, err := analyzer.AnalyzeBreakingChanges(ctx, req); err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	req.SchemaChanges = []string{"Removed variable x"}
+	analysis, err := analyzer.AnalyzeBreakingChanges(ctx, req)
+	if err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	if analysis.Summary != "from schema" || len(client.Prompts) != 4 {
+		t.Errorf("got %q after %d queries, want from schema after 4", analysis.Summary, len(client.Prompts))
+	}
+	if len(cache) != 2 {
+		t.Errorf("cached %d analyses, want 2", len(cache))
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	evidence := []Evidence{
+		{ID: "S1", Source: "schema diff", Text: "Removed variable enable_classiclink", Breaking: true},
+		{ID: "R1", Source: "release notes 5.0.0", Text: "Drop support for EC2-Classic", Breaking: true},
+	}
+
+	tests := []struct {
+		name      string
+		response  string
+		wantErr   string
+		wantCites []string
+	}{
+		{
+			name:      "cited details",
+			response:  `{"has_breaking_changes": true, "summary": "Drops EC2-Classic.", "details": [{"text": "enable_classiclink was removed", "evidence": ["S1", "R1", "S1"]}]}`,
+			wantCites: []string{"S1", "R1"},
+		},
+		{
+			name:     "no breaking changes",
+			response: `{"has_breaking_changes": false, "summary": "Bug fixes only.", "details": []}`,
+		},
+		{name: "prose around JSON", response: "Sure: {\"has_breaking_changes\": false, \"summary\": \"x\", \"details\": []}", wantErr: "invalid JSON"},
+		{name: "trailing content", response: `{"has_breaking_changes": false, "summary": "x", "details": []} {}`, wantErr: "unexpected content"},
+		{name: "self-reported confidence", response: `{"has_breaking_changes": false, "summary": "x", "details": [], "confidence": "high"}`, wantErr: "unknown field"},
+		{name: "missing flag", response: `{"summary": "x", "details": []}`, wantErr: "has_breaking_changes is missing"},
+		{name: "empty summary", response: `{"has_breaking_changes": false, "summary": " ", "details": []}`, wantErr: "summary is missing"},
+		{name: "missing details", response: `{"has_breaking_changes": false, "summary": "x"}`, wantErr: "details is missing"},
+		{name: "uncited detail", response: `{"has_breaking_changes": true, "summary": "x", "details": [{"text": "y", "evidence": []}]}`, wantErr: "cites no evidence"},
+		{name: "unknown evidence", response: `{"has_breaking_changes": true, "summary": "x", "details": [{"text": "y", "evidence": ["R7"]}]}`, wantErr: `unknown evidence "R7"`},
+		{name: "breaking without details", response: `{"has_breaking_changes": true, "summary": "x", "details": []}`, wantErr: "no details"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := parseResponse(tt.response, evidence)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseResponse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseResponse() error = %v", err)
+			}
+
+			var cites []string
+			for _, d := range analysis.Details {
+				for _, c := range d.Citations {
+					cites = append(cites, c.ID)
+				}
+			}
+			if strings.Join(cites, ",") != strings.Join(tt.wantCites, ",") {
+				t.Errorf("citations = %v, want %v", cites, tt.wantCites)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRetriesMalformedResponses(t *testing.T) {
+	req := AnalysisRequest{
+		Name:           "vpc",
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+		ReleaseNotes:   []ReleaseNote{{Version: "5.0.0", Body: "## BREAKING CHANGES\n\n- Drop support for EC2-Classic\n\n## Features\n\n- Add IPv6 support"}},
+	}
+
+	client := &FakeClient{Responses: []string{
+		`{"has_breaking_changes": true, "summary": "Drops EC2-Classic.", "details": [], "confidence": "high"}`,
+		`{"has_breaking_changes": true, "summary": "Drops EC2-Classic.", "details": [{"text": "EC2-Classic is no longer supported", "evidence": ["R1"]}]}`,
+	}}
+	analysis, err := New(client).AnalyzeBreakingChanges(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	if len(client.Prompts) != 2 || !strings.Contains(client.Prompts[1], "previous answer was rejected") {
+		t.Errorf("model queried %d times, want a retry telling why the answer was rejected", len(client.Prompts))
+	}
+	if !strings.Contains(client.Prompts[0], "[R1] release notes 5.0.0: - Drop support for EC2-Classic") {
+		t.Errorf("prompt does not list the evidence:\n%s", client.Prompts[0])
+	}
+	if analysis.Confidence != "high" || analysis.Details[0].Citations[0].Text != "- Drop support for EC2-Classic" {
+		t.Errorf("AnalyzeBreakingChanges() = %+v, want the cited line with high confidence", analysis)
+	}
+
+	// Without evidence, breaking changes are accepted without details and rated low
+	guess := &FakeClient{Responses: []string{`{"has_breaking_changes": true, "summary": "Major version bump.", "details": []}`}}
+	analysis, err = New(guess).AnalyzeBreakingChanges(context.Background(), AnalysisRequest{Name: "vpc", CurrentVersion: "4.0.0", LatestVersion: "5.0.0"})
+	if err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() without evidence error = %v", err)
+	}
+	if !analysis.HasBreakingChanges || analysis.Confidence != "low" || len(guess.Prompts) != 1 {
+		t.Errorf("AnalyzeBreakingChanges() without evidence = %+v after %d queries, want breaking with low confidence after 1", analysis, len(guess.Prompts))
+	}
+
+	malformed := &FakeClient{Responses: []string{"not JSON"}}
+	if _, err := New(malformed).AnalyzeBreakingChanges(context.Background(), req); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("AnalyzeBreakingChanges() error = %v, want failure after 3 attempts", err)
+	}
+	if len(malformed.Prompts) != maxAttempts {
+		t.Errorf("model queried %d times, want %d", len(malformed.Prompts), maxAttempts)
+	}
+}
@@ -0,0 +1,437 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Backends an LLMClient can be created for
+const (
+	BackendOpenAI    = "openai"    // OpenAI or any API compatible with its chat completions
+	BackendAnthropic = "anthropic" // Anthropic Messages API
+	BackendOllama    = "ollama"    // Ollama or another local server with its chat API
+	BackendFake      = "fake"      // Deterministic offline responses, for tests and dry runs
+)
+
+// LLMClient sends a prompt to a language model and returns the text of its response
+type LLMClient interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+
+	// CompleteJSON constrains the response to a JSON object matching schema, using
+	// the structured output or tool calling support of the backend
+	CompleteJSON(ctx context.Context, prompt string, schema ResponseSchema) (string, error)
+
+	// Model names the model answering, for logs and cache keys
+	Model() string
+}
+
+// ResponseSchema is the JSON schema a structured response must match. Objects in it
+// should list every property as required and disallow additional properties, which
+// strict structured outputs demand.
+type ResponseSchema struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+}
+
+// ClientConfig configures the LLMClient of a backend. Empty fields fall back to the
+// defaults of the backend.
+type ClientConfig struct {
+	Backend   string
+	APIKey    string
+	Model     string
+	BaseURL   string
+	Timeout   time.Duration
+	MaxTokens int
+}
+
+// backendDefaults are the model, base URL and timeout each backend uses unless configured
+var backendDefaults = map[string]ClientConfig{
+	BackendOpenAI:    {Model: "gpt-4o-mini", BaseURL: "https://api.openai.com/v1", Timeout: 30 * time.Second},
+	BackendAnthropic: {Model: "claude-3-5-haiku-latest", BaseURL: "https://api.anthropic.com/v1", Timeout: 30 * time.Second},
+	BackendOllama:    {Model: "llama3.1", BaseURL: "http://localhost:11434", Timeout: 120 * time.Second},
+	BackendFake:      {Model: "fake"},
+}
+
+// defaultMaxTokens bounds the length of responses unless configured
+const defaultMaxTokens = 1000
+
+// temperature is low for consistent, repeatable answers
+const temperature = 0.3
+
+// NewClient creates the LLMClient of the configured backend, defaulting to openai
+func NewClient(cfg ClientConfig) (LLMClient, error) {
+	if cfg.Backend == "" {
+		cfg.Backend = BackendOpenAI
+	}
+	defaults, ok := backendDefaults[cfg.Backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown AI backend %q (want openai, anthropic, ollama or fake)", cfg.Backend)
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaults.Model
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultMaxTokens
+	}
+
+	switch cfg.Backend {
+	case BackendOpenAI:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key not configured")
+		}
+		return &OpenAIClient{config: cfg, httpClient: &http.Client{Timeout: cfg.Timeout}}, nil
+	case BackendAnthropic:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("Anthropic API key not configured")
+		}
+		return &AnthropicClient{config: cfg, httpClient: &http.Client{Timeout: cfg.Timeout}}, nil
+	case BackendOllama:
+		return &OllamaClient{config: cfg, httpClient: &http.Client{Timeout: cfg.Timeout}}, nil
+	default:
+		return &FakeClient{}, nil
+	}
+}
+
+// OpenAIClient calls the chat completions API of OpenAI and compatible services,
+// such as Azure OpenAI or Langdock
+type OpenAIClient struct {
+	config     ClientConfig
+	httpClient *http.Client
+}
+
+// Model returns the configured model
+func (c *OpenAIClient) Model() string {
+	return c.config.Model
+}
+
+// Complete sends the prompt as a single user message
+func (c *OpenAIClient) Complete(ctx context.Context, prompt string) (string, error) {
+	return c.complete(ctx, prompt, nil)
+}
+
+// CompleteJSON requests a strict json_schema response format
+func (c *OpenAIClient) CompleteJSON(ctx context.Context, prompt string, schema ResponseSchema) (string, error) {
+	return c.complete(ctx, prompt, &schema)
+}
+
+// complete sends the prompt, constraining the response to schema when set
+func (c *OpenAIClient) complete(ctx context.Context, prompt string, schema *ResponseSchema) (string, error) {
+	reqBody := map[string]interface{}{
+		"model": c.config.Model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": prompt,
+			},
+		},
+		"temperature": temperature,
+		"max_tokens":  c.config.MaxTokens,
+	}
+	if schema != nil {
+		reqBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":        schema.Name,
+				"description": schema.Description,
+				"schema":      schema.Schema,
+				"strict":      true,
+			},
+		}
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + c.config.APIKey}
+	body, err := postJSON(ctx, c.httpClient, c.config.BaseURL+"/chat/completions", headers, reqBody, c.config)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if result.Error != nil {
+		return "", fmt.Errorf("OpenAI API error: %s (%s)", result.Error.Message, result.Error.Type)
+	}
+
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
+
+// anthropicVersion is the Messages API version requests are made against
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient calls the Anthropic Messages API
+type AnthropicClient struct {
+	config     ClientConfig
+	httpClient *http.Client
+}
+
+// Model returns the configured model
+func (c *AnthropicClient) Model() string {
+	return c.config.Model
+}
+
+// Complete sends the prompt as a single user message and joins the text blocks of
+// the response
+func (c *AnthropicClient) Complete(ctx context.Context, prompt string) (string, error) {
+	return c.complete(ctx, prompt, nil)
+}
+
+// CompleteJSON forces a call of a tool whose input schema is the response schema,
+// and returns the input of the call
+func (c *AnthropicClient) CompleteJSON(ctx context.Context, prompt string, schema ResponseSchema) (string, error) {
+	return c.complete(ctx, prompt, &schema)
+}
+
+// complete sends the prompt, answering through a tool call matching schema when set
+func (c *AnthropicClient) complete(ctx context.Context, prompt string, schema *ResponseSchema) (string, error) {
+	reqBody := map[string]interface{}{
+		"model": c.config.Model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": prompt,
+			},
+		},
+		"temperature": temperature,
+		"max_tokens":  c.config.MaxTokens,
+	}
+	if schema != nil {
+		reqBody["tools"] = []map[string]interface{}{
+			{
+				"name":         schema.Name,
+				"description":  schema.Description,
+				"input_schema": schema.Schema,
+			},
+		}
+		reqBody["tool_choice"] = map[string]string{"type": "tool", "name": schema.Name}
+	}
+
+	headers := map[string]string{
+		"x-api-key":         c.config.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+	body, err := postJSON(ctx, c.httpClient, c.config.BaseURL+"/messages", headers, reqBody, c.config)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if result.Error != nil {
+		return "", fmt.Errorf("Anthropic API error: %s (%s)", result.Error.Message, result.Error.Type)
+	}
+
+	if schema != nil {
+		for _, block := range result.Content {
+			if block.Type == "tool_use" && block.Name == schema.Name {
+				return string(block.Input), nil
+			}
+		}
+		return "", fmt.Errorf("no %s tool call in response", schema.Name)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no text in response")
+	}
+
+	return text.String(), nil
+}
+
+// OllamaClient calls the chat API of a local Ollama server. Local servers need no
+// API key.
+type OllamaClient struct {
+	config     ClientConfig
+	httpClient *http.Client
+}
+
+// Model returns the configured model
+func (c *OllamaClient) Model() string {
+	return c.config.Model
+}
+
+// Complete sends the prompt as a single user message, without streaming
+func (c *OllamaClient) Complete(ctx context.Context, prompt string) (string, error) {
+	return c.complete(ctx, prompt, nil)
+}
+
+// CompleteJSON passes the schema as the format of the response
+func (c *OllamaClient) CompleteJSON(ctx context.Context, prompt string, schema ResponseSchema) (string, error) {
+	return c.complete(ctx, prompt, &schema)
+}
+
+// complete sends the prompt, constraining the response to schema when set
+func (c *OllamaClient) complete(ctx context.Context, prompt string, schema *ResponseSchema) (string, error) {
+	reqBody := map[string]interface{}{
+		"model": c.config.Model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": prompt,
+			},
+		},
+		"stream": false,
+		"options": map[string]interface{}{
+			"temperature": temperature,
+			"num_predict": c.config.MaxTokens,
+		},
+	}
+	if schema != nil {
+		reqBody["format"] = schema.Schema
+	}
+
+	body, err := postJSON(ctx, c.httpClient, c.config.BaseURL+"/api/chat", nil, reqBody, c.config)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Error string `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if result.Error != "" {
+		return "", fmt.Errorf("Ollama error: %s", result.Error)
+	}
+
+	if result.Message.Content == "" {
+		return "", fmt.Errorf("no message in response")
+	}
+
+	return result.Message.Content, nil
+}
+
+// FakeClient answers without a model. It returns Responses in turn, repeating the
+// last one, or a fixed analysis that reports no breaking changes. Prompts records
+// every prompt it was sent; structured responses are not checked against their schema.
+type FakeClient struct {
+	Responses []string
+	Prompts   []string
+}
+
+// fakeResponse is the answer of a FakeClient without configured responses
+const fakeResponse = `{"has_breaking_changes": false, "summary": "Offline analysis: no language model was queried.", "details": []}`
+
+// Model returns "fake"
+func (c *FakeClient) Model() string {
+	return BackendFake
+}
+
+// Complete records the prompt and returns the next response
+func (c *FakeClient) Complete(ctx context.Context, prompt string) (string, error) {
+	c.Prompts = append(c.Prompts, prompt)
+
+	if len(c.Responses) == 0 {
+		return fakeResponse, nil
+	}
+	i := len(c.Prompts) - 1
+	if i >= len(c.Responses) {
+		i = len(c.Responses) - 1
+	}
+	return c.Responses[i], nil
+}
+
+// CompleteJSON records the prompt and returns the next response
+func (c *FakeClient) CompleteJSON(ctx context.Context, prompt string, schema ResponseSchema) (string, error) {
+	return c.Complete(ctx, prompt)
+}
+
+// postJSON posts a JSON request and returns the body of a 200 response
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, reqBody interface{}, cfg ClientConfig) ([]byte, error) {
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	log.Debug().
+		Str("backend", cfg.Backend).
+		Str("endpoint", endpoint).
+		Str("model", cfg.Model).
+		Str("api_key_prefix", maskAPIKey(cfg.APIKey)).
+		Msg("calling AI API")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s API returned status %d: %s", cfg.Backend, resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
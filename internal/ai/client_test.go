@@ -0,0 +1,272 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       ClientConfig
+		wantModel string
+		wantErr   string
+	}{
+		{name: "openai by default", cfg: ClientConfig{APIKey: "sk-test"}, wantModel: "gpt-4o-mini"},
+		{name: "openai without key", cfg: ClientConfig{Backend: BackendOpenAI}, wantErr: "API key not configured"},
+		{name: "anthropic", cfg: ClientConfig{Backend: BackendAnthropic, APIKey: "key", Model: "custom"}, wantModel: "custom"},
+		{name: "anthropic without key", cfg: ClientConfig{Backend: BackendAnthropic}, wantErr: "API key not configured"},
+		{name: "ollama needs no key", cfg: ClientConfig{Backend: BackendOllama}, wantModel: "llama3.1"},
+		{name: "fake", cfg: ClientConfig{Backend: BackendFake}, wantModel: "fake"},
+		{name: "unknown backend", cfg: ClientConfig{Backend: "gemini"}, wantErr: "unknown AI backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewClient() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if got := client.Model(); got != tt.wantModel {
+				t.Errorf("Model() = %q, want %q", got, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestClientsComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  string
+		path     string
+		response interface{}
+		check    func(t *testing.T, r *http.Request, body map[string]interface{})
+	}{
+		{
+			name:     "openai",
+			backend:  BackendOpenAI,
+			path:     "/chat/completions",
+			response: map[string]interface{}{"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": "answer"}}}},
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if got := r.Header.Get("Authorization"); got != "Bearer key" {
+					t.Errorf("Authorization = %q", got)
+				}
+				if body["max_tokens"] != float64(200) {
+					t.Errorf("max_tokens = %v, want 200", body["max_tokens"])
+				}
+			},
+		},
+		{
+			name:    "anthropic",
+			backend: BackendAnthropic,
+			path:    "/messages",
+			response: map[string]interface{}{"content": []interface{}{
+				map[string]string{"type": "text", "text": "ans"},
+				map[string]string{"type": "text", "text": "wer"},
+			}},
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if got := r.Header.Get("x-api-key"); got != "key" {
+					t.Errorf("x-api-key = %q", got)
+				}
+				if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+					t.Errorf("anthropic-version = %q", got)
+				}
+				if body["max_tokens"] != float64(200) {
+					t.Errorf("max_tokens = %v, want 200", body["max_tokens"])
+				}
+			},
+		},
+		{
+			name:     "ollama",
+			backend:  BackendOllama,
+			path:     "/api/chat",
+			response: map[string]interface{}{"message": map[string]string{"content": "answer"}},
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if body["stream"] != false {
+					t.Errorf("stream = %v, want false", body["stream"])
+				}
+				options, _ := body["options"].(map[string]interface{})
+				if options["num_predict"] != float64(200) {
+					t.Errorf("num_predict = %v, want 200", options["num_predict"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("request to %s, want %s", r.URL.Path, tt.path)
+				}
+				var body map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				if body["model"] != "test-model" {
+					t.Errorf("model = %v, want test-model", body["model"])
+				}
+				tt.check(t, r, body)
+				json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				Backend:   tt.backend,
+				APIKey:    "key",
+				Model:     "test-model",
+				BaseURL:   server.URL + "/",
+				Timeout:   5 * time.Second,
+				MaxTokens: 200,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			got, err := client.Complete(context.Background(), "prompt")
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got != "answer" {
+				t.Errorf("Complete() = %q, want answer", got)
+			}
+		})
+	}
+}
+
+func TestClientsCompleteJSON(t *testing.T) {
+	schema := ResponseSchema{
+		Name:   "answer",
+		Schema: map[string]interface{}{"type": "object", "required": []string{"ok"}, "properties": map[string]interface{}{"ok": map[string]string{"type": "boolean"}}},
+	}
+
+	tests := []struct {
+		name     string
+		backend  string
+		response interface{}
+		check    func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:     "openai",
+			backend:  BackendOpenAI,
+			response: map[string]interface{}{"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": `{"ok": true}`}}}},
+			check: func(t *testing.T, body map[string]interface{}) {
+				format, _ := body["response_format"].(map[string]interface{})
+				jsonSchema, _ := format["json_schema"].(map[string]interface{})
+				if format["type"] != "json_schema" || jsonSchema["name"] != "answer" || jsonSchema["strict"] != true || jsonSchema["schema"] == nil {
+					t.Errorf("response_format = %v, want a strict json_schema named answer", body["response_format"])
+				}
+			},
+		},
+		{
+			name:    "anthropic",
+			backend: BackendAnthropic,
+			response: map[string]interface{}{"content": []interface{}{
+				map[string]string{"type": "text", "text": "Calling the tool."},
+				map[string]interface{}{"type": "tool_use", "name": "answer", "input": map[string]bool{"ok": true}},
+			}},
+			check: func(t *testing.T, body map[string]interface{}) {
+				tools, _ := body["tools"].([]interface{})
+				if len(tools) != 1 || tools[0].(map[string]interface{})["input_schema"] == nil {
+					t.Errorf("tools = %v, want one tool with the schema as input", body["tools"])
+				}
+				choice, _ := body["tool_choice"].(map[string]interface{})
+				if choice["type"] != "tool" || choice["name"] != "answer" {
+					t.Errorf("tool_choice = %v, want the answer tool", body["tool_choice"])
+				}
+			},
+		},
+		{
+			name:     "ollama",
+			backend:  BackendOllama,
+			response: map[string]interface{}{"message": map[string]string{"content": `{"ok": true}`}},
+			check: func(t *testing.T, body map[string]interface{}) {
+				format, _ := body["format"].(map[string]interface{})
+				if format["type"] != "object" {
+					t.Errorf("format = %v, want the schema", body["format"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				tt.check(t, body)
+				json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{Backend: tt.backend, APIKey: "key", BaseURL: server.URL})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			got, err := client.CompleteJSON(context.Background(), "prompt", schema)
+			if err != nil {
+				t.Fatalf("CompleteJSON() error = %v", err)
+			}
+			var answer struct{ OK bool }
+			if err := json.Unmarshal([]byte(got), &answer); err != nil || !answer.OK {
+				t.Errorf("CompleteJSON() = %q, want {\"ok\": true}", got)
+			}
+		})
+	}
+}
+
+func TestClientErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"type": "rate_limit_error", "message": "slow down"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Backend: BackendAnthropic, APIKey: "key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.Complete(context.Background(), "prompt"); err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Errorf("Complete() error = %v, want status 429", err)
+	}
+}
+
+func TestFakeClient(t *testing.T) {
+	analyzer := New(&FakeClient{})
+	analysis, err := analyzer.AnalyzeBreakingChanges(context.Background(), AnalysisRequest{
+		Name:           "vpc",
+		Source:         "terraform-aws-modules/vpc/aws",
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeBreakingChanges() error = %v", err)
+	}
+	if analysis.HasBreakingChanges || analysis.Confidence != "low" {
+		t.Errorf("AnalyzeBreakingChanges() = %+v, want no breaking changes with low confidence", analysis)
+	}
+
+	fake := &FakeClient{Responses: []string{"first", "second"}}
+	for _, want := range []string{"first", "second", "second"} {
+		if got, _ := fake.Complete(context.Background(), "prompt"); got != want {
+			t.Errorf("Complete() = %q, want %q", got, want)
+		}
+	}
+	if len(fake.Prompts) != 3 {
+		t.Errorf("Prompts = %d, want 3", len(fake.Prompts))
+	}
+}
@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxEvidenceText bounds the text of one piece of evidence in prompts and citations
+const maxEvidenceText = 300
+
+// breakingMarker matches the ways release notes announce a breaking change, such as
+// "BREAKING CHANGES", "⚠ Breaking" or "feat!:"
+var breakingMarker = regexp.MustCompile(`(?i)\bbreaking\b|^\s*[-*]?\s*\w+(\([^)]*\))?!:`)
+
+// breakingDenial matches phrases that deny a breaking change, such as "No breaking
+// changes", "not a breaking change", "Non-breaking:" or "Breaking changes: none"
+var breakingDenial = regexp.MustCompile(`(?i)\bnon[-\s]?breaking\b|\b(no|not|without|zero)\s+(\w+\s+){0,2}breaking\b|\bbreaking\s+changes?\s*[:\-–]\s*(none|n/?a)\b`)
+
+// markdownHeading matches a markdown heading line
+var markdownHeading = regexp.MustCompile(`^\s*#{1,6}\s`)
+
+// Evidence is a release note line or schema change an analysis may cite
+type Evidence struct {
+	ID       string `json:"id"`     // R1, R2, ... for release note lines, S1, S2, ... for schema changes
+	Source   string `json:"source"` // e.g. "release notes 5.0.0" or "schema diff"
+	Text     string `json:"text"`
+	Breaking bool   `json:"breaking"` // the evidence itself announces a breaking change
+}
+
+// AnnouncesBreakingChange reports whether a release note line announces a breaking
+// change, ignoring the phrases that deny one
+func AnnouncesBreakingChange(line string) bool {
+	return breakingMarker.MatchString(breakingDenial.ReplaceAllString(line, ""))
+}
+
+// collectEvidence numbers the schema changes and release note lines of a request.
+// Schema changes are breaking by definition; release note lines are breaking when they
+// announce a breaking change or are listed under a heading that does, unless they deny one.
+func collectEvidence(req AnalysisRequest) []Evidence {
+	var evidence []Evidence
+
+	for _, change := range req.SchemaChanges {
+		change = strings.TrimSpace(change)
+		if change == "" {
+			continue
+		}
+		evidence = append(evidence, Evidence{
+			ID:       fmt.Sprintf("S%d", countPrefix(evidence, "S")+1),
+			Source:   "schema diff",
+			Text:     truncateEvidence(change),
+			Breaking: true,
+		})
+	}
+
+	for _, note := range req.ReleaseNotes {
+		underBreakingHeading := false
+		for _, line := range strings.Split(note.Body, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if markdownHeading.MatchString(line) {
+				underBreakingHeading = AnnouncesBreakingChange(line)
+				continue
+			}
+			evidence = append(evidence, Evidence{
+				ID:       fmt.Sprintf("R%d", countPrefix(evidence, "R")+1),
+				Source:   "release notes " + note.Version,
+				Text:     truncateEvidence(line),
+				Breaking: (underBreakingHeading && !breakingDenial.MatchString(line)) || AnnouncesBreakingChange(line),
+			})
+		}
+	}
+
+	return evidence
+}
+
+// countPrefix counts the evidence whose ID starts with prefix
+func countPrefix(evidence []Evidence, prefix string) int {
+	n := 0
+	for _, e := range evidence {
+		if strings.HasPrefix(e.ID, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// truncateEvidence cuts a line to maxEvidenceText bytes without splitting a rune
+func truncateEvidence(text string) string {
+	if len(text) <= maxEvidenceText {
+		return text
+	}
+	cut := maxEvidenceText
+	for cut > 0 && !isRuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+// isRuneStart reports whether b starts a UTF-8 encoded rune
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// computeConfidence rates an analysis by how well its citations cover the evidence
+// announcing breaking changes, rather than trusting the model's own estimate:
+//   - without any evidence, the model guessed from version numbers: low
+//   - no breaking changes found although evidence announces some: low; with truncated
+//     release notes the missing part may hold some: medium; otherwise high
+//   - breaking changes found that no evidence announces: medium
+//   - otherwise by the share of breaking evidence cited: all high, half medium, less low
+func computeConfidence(analysis *BreakingChangeAnalysis, evidence []Evidence, truncated bool) string {
+	if len(evidence) == 0 {
+		return "low"
+	}
+
+	breaking := map[string]bool{}
+	for _, e := range evidence {
+		if e.Breaking {
+			breaking[e.ID] = true
+		}
+	}
+
+	if !analysis.HasBreakingChanges {
+		switch {
+		case len(breaking) > 0:
+			return "low"
+		case truncated:
+			return "medium"
+		default:
+			return "high"
+		}
+	}
+
+	if len(breaking) == 0 {
+		return "medium"
+	}
+
+	cited := map[string]bool{}
+	for _, d := range analysis.Details {
+		for _, c := range d.Citations {
+			if breaking[c.ID] {
+				cited[c.ID] = true
+			}
+		}
+	}
+
+	switch coverage := float64(len(cited)) / float64(len(breaking)); {
+	case coverage >= 1:
+		return "high"
+	case coverage >= 0.5:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// analysisSchema constrains the answer of an analysis; parseResponse enforces it
+var analysisSchema = ResponseSchema{
+	Name:        "breaking_change_analysis",
+	Description: "Breaking changes of a Terraform module or provider update, citing evidence by ID",
+	Schema: map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"has_breaking_changes", "summary", "details"},
+		"properties": map[string]interface{}{
+			"has_breaking_changes": map[string]interface{}{"type": "boolean"},
+			"summary":              map[string]interface{}{"type": "string"},
+			"details": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"text", "evidence"},
+					"properties": map[string]interface{}{
+						"text": map[string]interface{}{"type": "string"},
+						"evidence": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	},
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectEvidence(t *testing.T) {
+	evidence := collectEvidence(AnalysisRequest{
+		SchemaChanges: []string{"Removed variable enable_classiclink", ""},
+		ReleaseNotes: []ReleaseNote{
+			{Version: "5.0.0", Body: "## ⚠ BREAKING CHANGES\n\n* Drop EC2-Classic\n* Require AWS provider 5.0\n\n### Features\n\n* Add IPv6 support\n* feat!: rename outputs"},
+			{Version: "4.1.0", Body: "Bug fixes\n\n" + strings.Repeat("x", 400)},
+		},
+	})
+
+	want := []struct {
+		id, source string
+		breaking   bool
+	}{
+		{"S1", "schema diff", true},
+		{"R1", "release notes 5.0.0", true},
+		{"R2", "release notes 5.0.0", true},
+		{"R3", "release notes 5.0.0", false},
+		{"R4", "release notes 5.0.0", true},
+		{"R5", "release notes 4.1.0", false},
+		{"R6", "release notes 4.1.0", false},
+	}
+	if len(evidence) != len(want) {
+		t.Fatalf("collectEvidence() = %+v, want %d entries", evidence, len(want))
+	}
+	for i, w := range want {
+		if e := evidence[i]; e.ID != w.id || e.Source != w.source || e.Breaking != w.breaking {
+			t.Errorf("evidence[%d] = %+v, want %s from %s breaking %t", i, e, w.id, w.source, w.breaking)
+		}
+	}
+	if got := evidence[6].Text; len(got) > maxEvidenceText+len("…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("long line not truncated: %d bytes", len(got))
+	}
+}
+
+func TestAnnouncesBreakingChange(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"## ⚠ BREAKING CHANGES", true},
+		{"* Breaking: drop support for Terraform 0.13", true},
+		{"* feat(vpc)!: require azs", true},
+		{"* Add IPv6 support", false},
+		{"* Fix nonbreaking typo", false},
+		{"No breaking changes in this release", false},
+		{"This is not a breaking change", false},
+		{"Upgrade without any breaking changes", false},
+		{"* Non-breaking: add outputs", false},
+		{"* non breaking refactoring", false},
+		{"Breaking changes: none", false},
+		{"No breaking changes to inputs, but breaking: outputs were renamed", true},
+	}
+
+	for _, tt := range tests {
+		if got := AnnouncesBreakingChange(tt.line); got != tt.want {
+			t.Errorf("AnnouncesBreakingChange(%q) = %t, want %t", tt.line, got, tt.want)
+		}
+	}
+
+	// A line denying breaking changes is not breaking under a breaking heading either
+	evidence := collectEvidence(AnalysisRequest{ReleaseNotes: []ReleaseNote{
+		{Version: "5.0.0", Body: "## Breaking Changes\n\nNo breaking changes in this release\n* Drop EC2-Classic"},
+	}})
+	if len(evidence) != 2 || evidence[0].Breaking || !evidence[1].Breaking {
+		t.Errorf("collectEvidence() = %+v, want only the second line breaking", evidence)
+	}
+}
+
+func TestComputeConfidence(t *testing.T) {
+	breaking := []Evidence{
+		{ID: "S1", Breaking: true},
+		{ID: "R1", Breaking: true},
+		{ID: "R2"},
+	}
+	notBreaking := []Evidence{{ID: "R1"}}
+	citing := func(ids ...string) []Detail {
+		var citations []Evidence
+		for _, id := range ids {
+			citations = append(citations, Evidence{ID: id})
+		}
+		return []Detail{{Text: "change", Citations: citations}}
+	}
+
+	tests := []struct {
+		name      string
+		analysis  BreakingChangeAnalysis
+		evidence  []Evidence
+		truncated bool
+		want      string
+	}{
+		{name: "no evidence", analysis: BreakingChangeAnalysis{}, want: "low"},
+		{name: "not breaking, nothing announced", analysis: BreakingChangeAnalysis{}, evidence: notBreaking, want: "high"},
+		{name: "not breaking, truncated notes", analysis: BreakingChangeAnalysis{}, evidence: notBreaking, truncated: true, want: "medium"},
+		{name: "not breaking against evidence", analysis: BreakingChangeAnalysis{}, evidence: breaking, want: "low"},
+		{name: "breaking, nothing announced", analysis: BreakingChangeAnalysis{HasBreakingChanges: true, Details: citing("R1")}, evidence: notBreaking, want: "medium"},
+		{name: "all breaking evidence cited", analysis: BreakingChangeAnalysis{HasBreakingChanges: true, Details: citing("S1", "R1")}, evidence: breaking, want: "high"},
+		{name: "half cited", analysis: BreakingChangeAnalysis{HasBreakingChanges: true, Details: citing("R1", "R2")}, evidence: breaking, want: "medium"},
+		{name: "only other evidence cited", analysis: BreakingChangeAnalysis{HasBreakingChanges: true, Details: citing("R2")}, evidence: breaking, want: "low"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeConfidence(&tt.analysis, tt.evidence, tt.truncated); got != tt.want {
+				t.Errorf("computeConfidence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
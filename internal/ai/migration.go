@@ -0,0 +1,191 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Operations of an ArgumentEdit
+const (
+	OpRename = "rename" // rename the argument to NewName, keeping its value
+	OpSet    = "set"    // set the argument to Value, adding it if missing
+	OpRemove = "remove" // remove the argument
+)
+
+// MigrationRequest describes a breaking module update and our call of the module
+type MigrationRequest struct {
+	ModuleName     string
+	Source         string
+	CurrentVersion string
+	LatestVersion  string
+	ModuleCall     string // HCL of our module block, already on the latest version
+	SchemaChanges  string // breaking API changes of the module, one per line
+	ReleaseNotes   string
+}
+
+// MigrationPatch is the structured answer a model proposes a migration in: edits to
+// the arguments of our module call and moved blocks for resources the module renamed
+type MigrationPatch struct {
+	Summary   string         `json:"summary"`
+	Arguments []ArgumentEdit `json:"arguments"`
+	Moved     []MovedBlock   `json:"moved"`
+}
+
+// ArgumentEdit is one edit of an argument of the module call
+type ArgumentEdit struct {
+	Op      string `json:"op"` // OpRename, OpSet or OpRemove
+	Name    string `json:"name"`
+	NewName string `json:"new_name,omitempty"` // OpRename only
+	Value   string `json:"value,omitempty"`    // OpSet only, an HCL expression
+	Reason  string `json:"reason"`
+}
+
+// MovedBlock moves the state of a resource the module renamed. Both addresses are
+// relative to the module, e.g. aws_security_group.this.
+type MovedBlock struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// MigrationResult is the outcome of proposing a migration for a PR
+type MigrationResult struct {
+	Model    string
+	Patch    MigrationPatch
+	Applied  bool   // the edits passed validation and are committed on the PR branch
+	Rejected string // why the edits were not applied
+}
+
+// Empty reports whether the patch proposes no edits
+func (p MigrationPatch) Empty() bool {
+	return len(p.Arguments) == 0 && len(p.Moved) == 0
+}
+
+// ProposeMigration asks the model for the edits our module call needs after a
+// breaking update. The answer is not validated against the configuration; callers
+// apply it with edit.ApplyMigration and validate the result.
+func (a *Analyzer) ProposeMigration(ctx context.Context, req MigrationRequest) (*MigrationPatch, error) {
+	log.Debug().
+		Str("module", req.ModuleName).
+		Str("current", req.CurrentVersion).
+		Str("latest", req.LatestVersion).
+		Msg("proposing migration with AI")
+
+	response, err := a.client.Complete(ctx, buildMigrationPrompt(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call AI API: %w", err)
+	}
+
+	patch, err := parseMigrationPatch(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AI migration: %w", err)
+	}
+
+	log.Debug().
+		Str("module", req.ModuleName).
+		Int("arguments", len(patch.Arguments)).
+		Int("moved", len(patch.Moved)).
+		Msg("AI migration proposed")
+
+	return patch, nil
+}
+
+// Model names the model proposals come from
+func (a *Analyzer) Model() string {
+	return a.client.Model()
+}
+
+// buildMigrationPrompt creates the prompt asking for a migration patch
+func buildMigrationPrompt(req MigrationRequest) string {
+	schemaChanges := req.SchemaChanges
+	if schemaChanges == "" {
+		schemaChanges = "None detected."
+	}
+	notes := req.ReleaseNotes
+	if notes == "" {
+		notes = "None available."
+	}
+
+	return fmt.Sprintf(`You are an expert in migrating Terraform configurations across breaking module updates.
+
+Our configuration calls the module %s (source %s), which was just updated from version %s to %s:
+
+%s
+
+Breaking API changes of the module between these versions:
+%s
+
+Release notes of the versions in between, newest first:
+%s
+
+Propose the smallest set of edits that makes our module call work with version %s:
+- "rename" an argument the module renamed, keeping our value
+- "set" an argument the module newly requires, or whose value must change; the value is an HCL expression
+- "remove" an argument the module no longer accepts
+- a moved block for every resource the module renamed, with addresses relative to the module (e.g. "aws_security_group.this")
+
+Only propose edits the changes or release notes above justify, and never edit source, version, count, for_each, providers or depends_on. If nothing needs to change, answer with empty lists.
+
+Respond ONLY with valid JSON in this exact format (no markdown, no code blocks):
+{
+  "summary": "One sentence describing the migration",
+  "arguments": [
+    {"op": "rename", "name": "old_name", "new_name": "new_name", "reason": "why"},
+    {"op": "set", "name": "argument", "value": "\"an HCL expression\"", "reason": "why"},
+    {"op": "remove", "name": "argument", "reason": "why"}
+  ],
+  "moved": [
+    {"from": "aws_instance.old", "to": "aws_instance.new", "reason": "why"}
+  ]
+}`, req.ModuleName, req.Source, req.CurrentVersion, req.LatestVersion, req.ModuleCall,
+		schemaChanges, notes, req.LatestVersion)
+}
+
+// parseMigrationPatch strictly parses a migration patch: unknown fields, unknown
+// operations and edits missing their values are errors
+func parseMigrationPatch(response string) (*MigrationPatch, error) {
+	jsonStart := strings.Index(response, "{")
+	jsonEnd := strings.LastIndex(response, "}")
+	if jsonStart == -1 || jsonEnd < jsonStart {
+		return nil, fmt.Errorf("no JSON found in response")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(response[jsonStart : jsonEnd+1])))
+	decoder.DisallowUnknownFields()
+
+	var patch MigrationPatch
+	if err := decoder.Decode(&patch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	for i, edit := range patch.Arguments {
+		if edit.Name == "" {
+			return nil, fmt.Errorf("argument edit %d has no name", i+1)
+		}
+		switch edit.Op {
+		case OpRename:
+			if edit.NewName == "" {
+				return nil, fmt.Errorf("rename of %q has no new_name", edit.Name)
+			}
+		case OpSet:
+			if strings.TrimSpace(edit.Value) == "" {
+				return nil, fmt.Errorf("set of %q has no value", edit.Name)
+			}
+		case OpRemove:
+		default:
+			return nil, fmt.Errorf("argument edit of %q has unknown op %q", edit.Name, edit.Op)
+		}
+	}
+	for i, moved := range patch.Moved {
+		if moved.From == "" || moved.To == "" {
+			return nil, fmt.Errorf("moved block %d needs both from and to", i+1)
+		}
+	}
+
+	return &patch, nil
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrationPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{
+			name: "valid patch in markdown",
+			response: "```json\n" + `{"summary": "Rename and move", "arguments": [
+				{"op": "rename", "name": "enable_classiclink", "new_name": "enable_dns", "reason": "renamed in 5.0.0"},
+				{"op": "set", "name": "azs", "value": "[\"eu-west-1a\"]", "reason": "now required"},
+				{"op": "remove", "name": "legacy", "reason": "removed"}
+			], "moved": [{"from": "aws_eip.nat", "to": "aws_eip.this", "reason": "renamed"}]}` + "\n```",
+		},
+		{name: "empty patch", response: `{"summary": "Nothing to do", "arguments": [], "moved": []}`},
+		{name: "no JSON", response: "I cannot help with that", wantErr: "no JSON"},
+		{name: "unknown field", response: `{"summary": "", "arguments": [], "edits": []}`, wantErr: "unknown field"},
+		{name: "unknown op", response: `{"arguments": [{"op": "replace", "name": "a"}]}`, wantErr: "unknown op"},
+		{name: "rename without new name", response: `{"arguments": [{"op": "rename", "name": "a"}]}`, wantErr: "no new_name"},
+		{name: "set without value", response: `{"arguments": [{"op": "set", "name": "a"}]}`, wantErr: "no value"},
+		{name: "moved without to", response: `{"moved": [{"from": "aws_eip.nat"}]}`, wantErr: "needs both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := parseMigrationPatch(tt.response)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseMigrationPatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMigrationPatch() error = %v", err)
+			}
+			if patch.Summary == "" {
+				t.Error("parseMigrationPatch() lost the summary")
+			}
+		})
+	}
+}
+
+func TestProposeMigration(t *testing.T) {
+	client := &FakeClient{Responses: []string{`{"summary": "Rename", "arguments": [{"op": "rename", "name": "a", "new_name": "b", "reason": "renamed"}], "moved": []}`}}
+	analyzer := New(client)
+
+	patch, err := analyzer.ProposeMigration(context.Background(), MigrationRequest{
+		ModuleName:     "vpc",
+		Source:         "terraform-aws-modules/vpc/aws",
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+		ModuleCall:     "module \"vpc\" {\n  a = 1\n}",
+		SchemaChanges:  "- Removed variable a (number)",
+	})
+	if err != nil {
+		t.Fatalf("ProposeMigration() error = %v", err)
+	}
+	if len(patch.Arguments) != 1 || patch.Arguments[0].NewName != "b" {
+		t.Errorf("ProposeMigration() = %+v, want a rename to b", patch)
+	}
+
+	prompt := client.Prompts[0]
+	for _, want := range []string{"module \"vpc\" {", "Removed variable a", "Release notes of the versions in between, newest first:\nNone available."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package cache
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -55,18 +54,9 @@ func newCache(cacheDir string, ttl time.Duration, memoryOnly bool) (*RepositoryC
 		return NewMemoryOnly(ttl), nil
 	}
 
-	if cacheDir == "" {
-		// Default to user's cache directory
-		userCacheDir, err := os.UserCacheDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get user cache directory: %w", err)
-		}
-		cacheDir = filepath.Join(userCacheDir, "terranovate")
-	}
-
-	// Create cache directory if it doesn't exist
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	cacheDir, err := prepareDir(cacheDir)
+	if err != nil {
+		return nil, err
 	}
 
 	cache := &RepositoryCache{
@@ -95,7 +85,7 @@ func (c *RepositoryCache) Get(repo string) ([]string, bool) {
 	}
 
 	// Check if entry has expired
-	if time.Since(entry.CachedAt) > entry.TTL {
+	if entry.expired() {
 		log.Debug().Str("repository", repo).Msg("cache entry expired")
 		return nil, false
 	}
@@ -136,8 +126,7 @@ func (c *RepositoryCache) Clear() error {
 	c.entries = make(map[string]*CacheEntry)
 
 	// Remove cache file
-	cacheFile := filepath.Join(c.cacheDir, "repository-cache.json")
-	if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(c.file()); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove cache file: %w", err)
 	}
 
@@ -152,34 +141,16 @@ func (c *RepositoryCache) load() error {
 		return nil
 	}
 
-	cacheFile := filepath.Join(c.cacheDir, "repository-cache.json")
-
-	data, err := os.ReadFile(cacheFile)
+	// Expired entries are dropped during load
+	total, err := loadFile(c.file(), c.entries)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // No cache file yet, not an error
-		}
-		return fmt.Errorf("failed to read cache file: %w", err)
-	}
-
-	var entries map[string]*CacheEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return fmt.Errorf("failed to parse cache file: %w", err)
-	}
-
-	// Remove expired entries during load
-	validEntries := 0
-	for repo, entry := range entries {
-		if time.Since(entry.CachedAt) <= entry.TTL {
-			c.entries[repo] = entry
-			validEntries++
-		}
+		return err
 	}
 
 	log.Debug().
-		Int("total", len(entries)).
-		Int("valid", validEntries).
-		Int("expired", len(entries)-validEntries).
+		Int("total", total).
+		Int("valid", len(c.entries)).
+		Int("expired", total-len(c.entries)).
 		Msg("loaded cache from disk")
 
 	return nil
@@ -195,18 +166,17 @@ func (c *RepositoryCache) save() error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	cacheFile := filepath.Join(c.cacheDir, "repository-cache.json")
-
-	data, err := json.MarshalIndent(c.entries, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal cache: %w", err)
-	}
+	return saveFile(c.file(), c.entries)
+}
 
-	if err := os.WriteFile(cacheFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write cache file: %w", err)
-	}
+// file returns the path the cache is persisted to
+func (c *RepositoryCache) file() string {
+	return filepath.Join(c.cacheDir, "repository-cache.json")
+}
 
-	return nil
+// expired reports whether the entry outlived its TTL
+func (e *CacheEntry) expired() bool {
+	return expired(e.CachedAt, e.TTL)
 }
 
 // Stats returns cache statistics
@@ -220,7 +190,7 @@ func (c *RepositoryCache) Stats() CacheStats {
 	}
 
 	for _, entry := range c.entries {
-		if time.Since(entry.CachedAt) <= entry.TTL {
+		if !entry.expired() {
 			stats.ValidEntries++
 		} else {
 			stats.ExpiredEntries++
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// expiring is a cache entry that expires a TTL after it was cached
+type expiring interface {
+	expired() bool
+}
+
+// expired reports whether an entry cached at cachedAt outlived its TTL
+func expired(cachedAt time.Time, ttl time.Duration) bool {
+	return time.Since(cachedAt) > ttl
+}
+
+// prepareDir returns the cache directory, terranovate in the user cache directory
+// when cacheDir is empty, and creates it if it doesn't exist
+func prepareDir(cacheDir string) (string, error) {
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user cache directory: %w", err)
+		}
+		cacheDir = filepath.Join(userCacheDir, "terranovate")
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return cacheDir, nil
+}
+
+// loadFile adds the unexpired entries saved to file to entries and returns how many
+// the file held. A missing file is an empty cache.
+func loadFile[E expiring](file string, entries map[string]E) (int, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil // No cache file yet, not an error
+		}
+		return 0, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var saved map[string]E
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("failed to parse cache file: %w", err)
+	}
+
+	for key, entry := range saved {
+		if !entry.expired() {
+			entries[key] = entry
+		}
+	}
+
+	return len(saved), nil
+}
+
+// saveFile writes entries to file
+func saveFile(file string, entries interface{}) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ValueCache stores JSON-encoded values under string keys, persisted to one file of
+// the cache directory, e.g. the results of AI analyses
+type ValueCache struct {
+	mu      sync.RWMutex
+	entries map[string]*ValueEntry
+	file    string
+	ttl     time.Duration
+}
+
+// ValueEntry represents a cached value
+type ValueEntry struct {
+	// JSON encoding of the value
+	Value json.RawMessage `json:"value"`
+
+	// Timestamp when this entry was cached
+	CachedAt time.Time `json:"cached_at"`
+
+	// Time to live for this entry
+	TTL time.Duration `json:"ttl"`
+}
+
+// NewValueCache creates a value cache persisted to name in cacheDir, which defaults to
+// terranovate in the user cache directory like the repository cache
+func NewValueCache(cacheDir, name string, ttl time.Duration) (*ValueCache, error) {
+	cacheDir, err := prepareDir(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ValueCache{
+		entries: make(map[string]*ValueEntry),
+		file:    filepath.Join(cacheDir, name),
+		ttl:     ttl,
+	}
+
+	if err := cache.load(); err != nil {
+		log.Warn().Err(err).Str("file", cache.file).Msg("failed to load cache from disk, starting fresh")
+	}
+
+	return cache, nil
+}
+
+// Get decodes the value cached under key into v, reporting whether there was an
+// unexpired value
+func (c *ValueCache) Get(key string, v interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[key]
+	if !exists || entry.expired() {
+		return false
+	}
+	if err := json.Unmarshal(entry.Value, v); err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("ignoring undecodable cache entry")
+		return false
+	}
+
+	return true
+}
+
+// Set caches v under key and writes the cache to disk. Unlike the repository cache
+// it saves synchronously, since values are expensive to recompute and a run may end
+// right after the last Set.
+func (c *ValueCache) Set(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("failed to encode cache value")
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &ValueEntry{
+		Value:    data,
+		CachedAt: time.Now(),
+		TTL:      c.ttl,
+	}
+	c.mu.Unlock()
+
+	if err := c.save(); err != nil {
+		log.Warn().Err(err).Msg("failed to save cache to disk")
+	}
+}
+
+// load reads the cache from disk, dropping expired entries
+func (c *ValueCache) load() error {
+	total, err := loadFile(c.file, c.entries)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().
+		Str("file", c.file).
+		Int("total", total).
+		Int("valid", len(c.entries)).
+		Msg("loaded cache from disk")
+
+	return nil
+}
+
+// save writes the cache to disk
+func (c *ValueCache) save() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return saveFile(c.file, c.entries)
+}
+
+// expired reports whether the entry outlived its TTL
+func (e *ValueEntry) expired() bool {
+	return expired(e.CachedAt, e.TTL)
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValueCache(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	type analysis struct {
+		Summary string   `json:"summary"`
+		Details []string `json:"details"`
+	}
+
+	c, err := NewValueCache(tmpDir, "values.json", time.Hour)
+	if err != nil {
+		t.Fatalf("NewValueCache() error = %v", err)
+	}
+
+	var got analysis
+	if c.Get("vpc|4.0.0|5.0.0", &got) {
+		t.Fatal("Get() found a value in an empty cache")
+	}
+
+	want := analysis{Summary: "breaking", Details: []string{"removed variable"}}
+	c.Set("vpc|4.0.0|5.0.0", want)
+
+	// A new cache reads the value from disk
+	reloaded, err := NewValueCache(tmpDir, "values.json", time.Hour)
+	if err != nil {
+		t.Fatalf("NewValueCache() error = %v", err)
+	}
+	if !reloaded.Get("vpc|4.0.0|5.0.0", &got) {
+		t.Fatal("Get() missed a value saved by another cache")
+	}
+	if got.Summary != want.Summary || len(got.Details) != 1 || got.Details[0] != want.Details[0] {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValueCache_Expired(t *testing.T) {
+	c, err := NewValueCache(t.TempDir(), "values.json", -time.Second)
+	if err != nil {
+		t.Fatalf("NewValueCache() error = %v", err)
+	}
+
+	c.Set("key", "value")
+
+	var got string
+	if c.Get("key", &got) {
+		t.Errorf("Get() returned expired value %q", got)
+	}
+}
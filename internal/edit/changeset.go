@@ -0,0 +1,146 @@
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/heyjobs/terranovate/internal/version"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// FileChange is the pending content of one edited file
+type FileChange struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Changeset collects version edits in memory so they can be reviewed as a diff
+// before anything is written. Several updates to the same file stack up.
+type Changeset struct {
+	files map[string]*FileChange
+}
+
+// NewChangeset creates an empty changeset
+func NewChangeset() *Changeset {
+	return &Changeset{files: make(map[string]*FileChange)}
+}
+
+// AddModule applies a module update to the changeset
+func (c *Changeset) AddModule(update version.UpdateInfo) error {
+	change, err := c.file(update.Module.FilePath)
+	if err != nil {
+		return err
+	}
+
+	after, err := SetModuleVersion(change.After, change.Path, update.Module.Name, update.Module.Line,
+		update.LatestVersion, update.Module.SourceType == "git")
+	if err != nil {
+		return err
+	}
+
+	change.After = after
+	return nil
+}
+
+// AddProvider applies a provider update to the changeset
+func (c *Changeset) AddProvider(update version.ProviderUpdateInfo) error {
+	change, err := c.file(update.Provider.FilePath)
+	if err != nil {
+		return err
+	}
+
+	after, err := SetProviderVersion(change.After, change.Path, update.Provider.Name, update.Provider.Line, update.LatestVersion)
+	if err != nil {
+		return err
+	}
+
+	change.After = after
+	return nil
+}
+
+// Files returns the files whose content changed, sorted by path
+func (c *Changeset) Files() []*FileChange {
+	var files []*FileChange
+	for _, change := range c.files {
+		if !bytes.Equal(change.Before, change.After) {
+			files = append(files, change)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// Diff returns a unified diff of all pending changes
+func (c *Changeset) Diff() (string, error) {
+	var out strings.Builder
+	for _, change := range c.Files() {
+		diff, err := change.Diff()
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(diff)
+	}
+	return out.String(), nil
+}
+
+// Write writes all changed files to disk
+func (c *Changeset) Write() error {
+	for _, change := range c.Files() {
+		if err := os.WriteFile(change.Path, change.After, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Diff returns a unified diff of the file change
+func (f *FileChange) Diff() (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(f.Before)),
+		B:        difflib.SplitLines(string(f.After)),
+		FromFile: "a/" + filepath.ToSlash(f.Path),
+		ToFile:   "b/" + filepath.ToSlash(f.Path),
+		Context:  3,
+	})
+}
+
+// file returns the pending change for a path, reading it from disk on first use
+func (c *Changeset) file(filePath string) (*FileChange, error) {
+	filePath = filepath.Clean(filePath)
+	if change, ok := c.files[filePath]; ok {
+		return change, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	change := &FileChange{Path: filePath, Before: content, After: content}
+	c.files[filePath] = change
+	return change, nil
+}
+
+// UpdateModule rewrites the module version in its file on disk
+func UpdateModule(update version.UpdateInfo) error {
+	c := NewChangeset()
+	if err := c.AddModule(update); err != nil {
+		return err
+	}
+	return c.Write()
+}
+
+// UpdateProvider rewrites the provider version constraint in its file on disk
+func UpdateProvider(update version.ProviderUpdateInfo) error {
+	c := NewChangeset()
+	if err := c.AddProvider(update); err != nil {
+		return err
+	}
+	return c.Write()
+}
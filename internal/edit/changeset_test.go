@@ -0,0 +1,125 @@
+package edit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+func TestChangeset(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainTF := filepath.Join(tmpDir, "main.tf")
+	src := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+`
+	if err := os.WriteFile(mainTF, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewChangeset()
+	if err := c.AddModule(version.UpdateInfo{
+		Module:        scanner.ModuleInfo{Name: "vpc", FilePath: mainTF, Line: 10},
+		LatestVersion: "5.0.0",
+	}); err != nil {
+		t.Fatalf("AddModule() error = %v", err)
+	}
+	if err := c.AddProvider(version.ProviderUpdateInfo{
+		Provider:      scanner.ProviderInfo{Name: "aws", FilePath: mainTF, Line: 3},
+		LatestVersion: "5.0",
+	}); err != nil {
+		t.Fatalf("AddProvider() error = %v", err)
+	}
+
+	files := c.Files()
+	if len(files) != 1 {
+		t.Fatalf("Files() returned %d files, want 1", len(files))
+	}
+
+	diff, err := c.Diff()
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	for _, want := range []string{`-      version = "~> 4.0"`, `+      version = "~> 5.0"`, `-  version = "4.0.0"`, `+  version = "5.0.0"`} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Diff() missing %q:\n%s", want, diff)
+		}
+	}
+
+	// Nothing is written until Write is called
+	content, _ := os.ReadFile(mainTF)
+	if string(content) != src {
+		t.Error("file changed before Write()")
+	}
+
+	if err := c.Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	content, _ = os.ReadFile(mainTF)
+	if string(content) != string(files[0].After) {
+		t.Errorf("file content after Write() =\n%s", content)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	vpcMinor := version.UpdateInfo{Module: scanner.ModuleInfo{Name: "vpc"}, UpdateType: version.UpdateTypeMinor}
+	eksMajor := version.UpdateInfo{Module: scanner.ModuleInfo{Name: "eks"}, UpdateType: version.UpdateTypeMajor}
+	awsPatch := version.ProviderUpdateInfo{Provider: scanner.ProviderInfo{Name: "aws"}, UpdateType: version.UpdateTypePatch}
+	googleMajor := version.ProviderUpdateInfo{Provider: scanner.ProviderInfo{Name: "google"}, UpdateType: version.UpdateTypeMajor}
+
+	tests := []struct {
+		name       string
+		only       string
+		maxType    string
+		wantVPC    bool
+		wantEKS    bool
+		wantAWS    bool
+		wantGoogle bool
+	}{
+		{name: "no filter", wantVPC: true, wantEKS: true, wantAWS: true, wantGoogle: true},
+		{name: "modules and providers by name", only: "vpc, provider:aws", wantVPC: true, wantAWS: true},
+		{name: "modules only", only: "eks", wantEKS: true},
+		{name: "providers only", only: "provider:google", wantGoogle: true},
+		{name: "max type minor", maxType: "minor", wantVPC: true, wantAWS: true},
+		{name: "max type patch", maxType: "patch", wantAWS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.only, tt.maxType)
+			if err != nil {
+				t.Fatalf("NewFilter() error = %v", err)
+			}
+			if got := f.MatchModule(vpcMinor); got != tt.wantVPC {
+				t.Errorf("MatchModule(vpc) = %v, want %v", got, tt.wantVPC)
+			}
+			if got := f.MatchModule(eksMajor); got != tt.wantEKS {
+				t.Errorf("MatchModule(eks) = %v, want %v", got, tt.wantEKS)
+			}
+			if got := f.MatchProvider(awsPatch); got != tt.wantAWS {
+				t.Errorf("MatchProvider(aws) = %v, want %v", got, tt.wantAWS)
+			}
+			if got := f.MatchProvider(googleMajor); got != tt.wantGoogle {
+				t.Errorf("MatchProvider(google) = %v, want %v", got, tt.wantGoogle)
+			}
+		})
+	}
+
+	if _, err := NewFilter("", "huge"); err == nil {
+		t.Error("NewFilter() error = nil for invalid update type")
+	}
+}
@@ -0,0 +1,97 @@
+package edit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+// updateTypeRank orders update types from least to most disruptive
+var updateTypeRank = map[version.UpdateType]int{
+	version.UpdateTypePatch: 1,
+	version.UpdateTypeMinor: 2,
+	version.UpdateTypeMajor: 3,
+}
+
+// Filter selects which updates to apply
+type Filter struct {
+	modules   map[string]bool
+	providers map[string]bool
+	maxType   version.UpdateType
+}
+
+// NewFilter builds a filter from a comma-separated list of names and a maximum update type.
+// Plain names select modules, "provider:<name>" selects providers. An empty list selects
+// everything. maxType (patch, minor or major) limits updates to that type and below.
+func NewFilter(only, maxType string) (*Filter, error) {
+	f := &Filter{}
+
+	for _, name := range strings.Split(only, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if provider, ok := strings.CutPrefix(name, "provider:"); ok {
+			if f.providers == nil {
+				f.providers = make(map[string]bool)
+			}
+			f.providers[provider] = true
+			continue
+		}
+
+		if f.modules == nil {
+			f.modules = make(map[string]bool)
+		}
+		f.modules[strings.TrimPrefix(name, "module:")] = true
+	}
+
+	if maxType != "" {
+		t := version.UpdateType(strings.ToLower(maxType))
+		if _, ok := updateTypeRank[t]; !ok {
+			return nil, fmt.Errorf("invalid update type %q: must be patch, minor or major", maxType)
+		}
+		f.maxType = t
+	}
+
+	return f, nil
+}
+
+// MatchModule reports whether a module update is selected
+func (f *Filter) MatchModule(update version.UpdateInfo) bool {
+	if f.modules != nil && !f.modules[update.Module.Name] {
+		return false
+	}
+	if f.modules == nil && f.providers != nil {
+		// Only providers were requested
+		return false
+	}
+	return f.allowsType(update.UpdateType)
+}
+
+// MatchProvider reports whether a provider update is selected
+func (f *Filter) MatchProvider(update version.ProviderUpdateInfo) bool {
+	if f.providers != nil && !f.providers[update.Provider.Name] {
+		return false
+	}
+	if f.providers == nil && f.modules != nil {
+		// Only modules were requested
+		return false
+	}
+	return f.allowsType(update.UpdateType)
+}
+
+// allowsType reports whether an update type is within the maximum update type
+func (f *Filter) allowsType(t version.UpdateType) bool {
+	if f.maxType == "" {
+		return true
+	}
+
+	rank, ok := updateTypeRank[t]
+	if !ok {
+		// Unknown update types (e.g. git refs that aren't semver) are only applied without a type filter
+		return false
+	}
+	return rank <= updateTypeRank[f.maxType]
+}
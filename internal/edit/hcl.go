@@ -0,0 +1,271 @@
+package edit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// textEdit replaces the bytes in [start, end) of a file
+type textEdit struct {
+	start, end  int
+	replacement []byte
+}
+
+// SetModuleVersion rewrites the version of a module block in HCL source. The block
+// is located by name and, when several blocks share the name, by its start line.
+// Only the version expression and the ref= query parameter of a git source change;
+// the rest of the file is kept byte-for-byte.
+func SetModuleVersion(src []byte, filename, name string, line int, latestVersion string, gitSource bool) ([]byte, error) {
+	body, err := parseBody(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	block := findModuleBlock(body, name, line)
+	if block == nil {
+		return nil, fmt.Errorf("module %q not found in %s", name, filename)
+	}
+
+	if gitSource {
+		source, ok := block.Body.Attributes["source"]
+		if !ok {
+			return nil, fmt.Errorf("module %q has no source attribute", name)
+		}
+		current, ok := stringLiteral(source.Expr)
+		if !ok {
+			return nil, fmt.Errorf("module %q source is not a string literal", name)
+		}
+
+		newSource, err := replaceGitRef(current, latestVersion)
+		if err != nil {
+			return nil, fmt.Errorf("module %q: %w", name, err)
+		}
+		return applyEdits(src, replaceExpr(source.Expr, newSource)), nil
+	}
+
+	if versionAttr, ok := block.Body.Attributes["version"]; ok {
+		current, ok := stringLiteral(versionAttr.Expr)
+		if !ok {
+			return nil, fmt.Errorf("module %q version is not a string literal", name)
+		}
+		return applyEdits(src, replaceExpr(versionAttr.Expr, ConstraintWithVersion(current, latestVersion))), nil
+	}
+
+	// No version attribute yet: insert one right after the source attribute
+	source, ok := block.Body.Attributes["source"]
+	if !ok {
+		return nil, fmt.Errorf("module %q has no source attribute", name)
+	}
+
+	// Reuse the indentation of the source attribute
+	start := source.SrcRange.Start.Byte
+	lineStart := start
+	for lineStart > 0 && src[lineStart-1] != '\n' {
+		lineStart--
+	}
+	indent := src[lineStart:start]
+
+	insertion := fmt.Sprintf("\n%sversion = %s", indent, hclwrite.TokensForValue(cty.StringVal(latestVersion)).Bytes())
+
+	end := source.SrcRange.End.Byte
+	return applyEdits(src, textEdit{start: end, end: end, replacement: []byte(insertion)}), nil
+}
+
+// SetProviderVersion rewrites the version constraint of a required_providers entry,
+// keeping the constraint operator and the rest of the file byte-for-byte
+func SetProviderVersion(src []byte, filename, name string, line int, latestVersion string) ([]byte, error) {
+	body, err := parseBody(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	attr := findProviderAttr(body, name, line)
+	if attr == nil {
+		return nil, fmt.Errorf("could not find provider %q in required_providers of %s", name, filename)
+	}
+
+	obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, fmt.Errorf("could not find provider version to update in file %s", filename)
+	}
+
+	for _, item := range obj.Items {
+		if objectKey(item.KeyExpr) != "version" {
+			continue
+		}
+		current, ok := stringLiteral(item.ValueExpr)
+		if !ok {
+			return nil, fmt.Errorf("provider %q version is not a string literal", name)
+		}
+		return applyEdits(src, replaceExpr(item.ValueExpr, ConstraintWithVersion(current, latestVersion))), nil
+	}
+
+	return nil, fmt.Errorf("could not find provider version to update in file %s", filename)
+}
+
+// parseBody parses HCL source into its native syntax body
+func parseBody(src []byte, filename string) (*hclsyntax.Body, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", filename, diags.Error())
+	}
+	return file.Body.(*hclsyntax.Body), nil
+}
+
+// findModuleBlock finds a module block by name, preferring the one starting at line
+func findModuleBlock(body *hclsyntax.Body, name string, line int) *hclsyntax.Block {
+	var first *hclsyntax.Block
+	for _, block := range body.Blocks {
+		if block.Type != "module" || len(block.Labels) == 0 || block.Labels[0] != name {
+			continue
+		}
+		if line == 0 || block.DefRange().Start.Line == line {
+			return block
+		}
+		if first == nil {
+			first = block
+		}
+	}
+
+	// The line is stale, e.g. the file changed since it was scanned
+	return first
+}
+
+// findProviderAttr finds a required_providers entry by name, preferring the one starting at line
+func findProviderAttr(body *hclsyntax.Body, name string, line int) *hclsyntax.Attribute {
+	var first *hclsyntax.Attribute
+	for _, tfBlock := range body.Blocks {
+		if tfBlock.Type != "terraform" {
+			continue
+		}
+		for _, rpBlock := range tfBlock.Body.Blocks {
+			if rpBlock.Type != "required_providers" {
+				continue
+			}
+			attr, ok := rpBlock.Body.Attributes[name]
+			if !ok {
+				continue
+			}
+			if line == 0 || attr.SrcRange.Start.Line == line {
+				return attr
+			}
+			if first == nil {
+				first = attr
+			}
+		}
+	}
+	return first
+}
+
+// objectKey returns the name of an object constructor key, bare or quoted
+func objectKey(expr hclsyntax.Expression) string {
+	if key, ok := expr.(*hclsyntax.ObjectConsKeyExpr); ok {
+		if keyword := hcl.ExprAsKeyword(key.Wrapped); keyword != "" {
+			return keyword
+		}
+		expr = key.Wrapped
+	}
+	if s, ok := stringLiteral(expr); ok {
+		return s
+	}
+	return ""
+}
+
+// stringLiteral returns the value of a plain quoted string expression without interpolations
+func stringLiteral(expr hclsyntax.Expression) (string, bool) {
+	tmpl, ok := expr.(*hclsyntax.TemplateExpr)
+	if !ok || !tmpl.IsStringLiteral() {
+		return "", false
+	}
+
+	val, diags := tmpl.Value(nil)
+	if diags.HasErrors() || !val.Type().Equals(cty.String) || val.IsNull() {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+// replaceExpr builds an edit replacing an expression with a quoted string
+func replaceExpr(expr hclsyntax.Expression, value string) textEdit {
+	rng := expr.Range()
+	return textEdit{
+		start:       rng.Start.Byte,
+		end:         rng.End.Byte,
+		replacement: hclwrite.TokensForValue(cty.StringVal(value)).Bytes(),
+	}
+}
+
+// applyEdits applies non-overlapping edits to src
+func applyEdits(src []byte, edits ...textEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	out := append([]byte{}, src...)
+	for _, edit := range edits {
+		out = append(out[:edit.start], append(append([]byte{}, edit.replacement...), out[edit.end:]...)...)
+	}
+	return out
+}
+
+// replaceGitRef sets the ref query parameter of a git module source, keeping the
+// "v" prefix style of the current ref
+func replaceGitRef(source, latestVersion string) (string, error) {
+	q := strings.Index(source, "?")
+	if q == -1 {
+		return "", fmt.Errorf("git source has no ref parameter")
+	}
+
+	// Only the query string is edited, so a //subdir path stays untouched
+	params := strings.Split(source[q+1:], "&")
+	for i, param := range params {
+		if !strings.HasPrefix(param, "ref=") {
+			continue
+		}
+
+		currentRef, err := url.QueryUnescape(strings.TrimPrefix(param, "ref="))
+		if err != nil {
+			currentRef = strings.TrimPrefix(param, "ref=")
+		}
+
+		newRef := latestVersion
+		if strings.HasPrefix(currentRef, "v") && !strings.HasPrefix(newRef, "v") {
+			newRef = "v" + newRef
+		}
+		params[i] = "ref=" + newRef
+
+		return source[:q+1] + strings.Join(params, "&"), nil
+	}
+
+	return "", fmt.Errorf("git source has no ref parameter")
+}
+
+// ConstraintWithVersion replaces the version in a constraint, preserving its operator
+// Examples:
+//
+//	"~> 5.0"   -> "~> 6.0"
+//	">= 5.0.0" -> ">= 6.0.0"
+//	"5.0.0"    -> "6.0.0"
+func ConstraintWithVersion(oldValue, latestVersion string) string {
+	constraint := ""
+	if strings.HasPrefix(oldValue, "~>") {
+		constraint = "~> "
+	} else if strings.HasPrefix(oldValue, ">=") {
+		constraint = ">= "
+	} else if strings.HasPrefix(oldValue, "<=") {
+		constraint = "<= "
+	} else if strings.HasPrefix(oldValue, "=") {
+		constraint = "= "
+	} else if strings.HasPrefix(oldValue, ">") {
+		constraint = "> "
+	} else if strings.HasPrefix(oldValue, "<") {
+		constraint = "< "
+	}
+
+	return constraint + latestVersion
+}
@@ -0,0 +1,235 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetModuleVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		module    string
+		line      int
+		latest    string
+		gitSource bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "only the named module changes",
+			src: `module "a" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+
+module "b" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0" # pinned
+}
+`,
+			module: "b",
+			latest: "5.0.0",
+			want: `module "a" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+
+module "b" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0" # pinned
+}
+`,
+		},
+		{
+			name: "aligned attributes and constraint operator are kept",
+			src: `# Networking
+module "vpc" {
+  source     = "terraform-aws-modules/vpc/aws"
+  version    = "~> 4.0"
+  cidr       = "10.0.0.0/16"  // odd spacing stays
+}
+`,
+			module: "vpc",
+			latest: "5.1.0",
+			want: `# Networking
+module "vpc" {
+  source     = "terraform-aws-modules/vpc/aws"
+  version    = "~> 5.1.0"
+  cidr       = "10.0.0.0/16"  // odd spacing stays
+}
+`,
+		},
+		{
+			name: "git ref without v prefix stays without it",
+			src: `module "app" {
+  source = "git::https://github.com/example/app.git//modules/app?ref=1.2.0&depth=1"
+}
+`,
+			module:    "app",
+			latest:    "1.3.0",
+			gitSource: true,
+			want: `module "app" {
+  source = "git::https://github.com/example/app.git//modules/app?ref=1.3.0&depth=1"
+}
+`,
+		},
+		{
+			name: "git ref with v prefix keeps it",
+			src: `module "app" {
+  source = "git::https://github.com/example/app.git?ref=v1.2.0"
+}
+`,
+			module:    "app",
+			latest:    "1.3.0",
+			gitSource: true,
+			want: `module "app" {
+  source = "git::https://github.com/example/app.git?ref=v1.3.0"
+}
+`,
+		},
+		{
+			name: "version is inserted after source",
+			src: `module "vpc" {
+    source = "terraform-aws-modules/vpc/aws"
+
+    cidr = "10.0.0.0/16"
+}
+`,
+			module: "vpc",
+			latest: "5.0.0",
+			want: `module "vpc" {
+    source = "terraform-aws-modules/vpc/aws"
+    version = "5.0.0"
+
+    cidr = "10.0.0.0/16"
+}
+`,
+		},
+		{
+			name: "line selects among duplicate names",
+			src: `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+`,
+			module: "vpc",
+			line:   5,
+			latest: "5.0.0",
+			want: `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+}
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+`,
+		},
+		{
+			name:    "missing module",
+			src:     `module "vpc" { source = "x" }`,
+			module:  "eks",
+			latest:  "1.0.0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetModuleVersion([]byte(tt.src), "main.tf", tt.module, tt.line, tt.latest, tt.gitSource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetModuleVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("SetModuleVersion() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetProviderVersion(t *testing.T) {
+	src := `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 4.0" # keep comment
+      configuration_aliases = [aws.east]
+    }
+    awscc = { source = "hashicorp/awscc", version = ">= 0.1.0" }
+  }
+}
+`
+	want := `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0" # keep comment
+      configuration_aliases = [aws.east]
+    }
+    awscc = { source = "hashicorp/awscc", version = ">= 0.1.0" }
+  }
+}
+`
+
+	got, err := SetProviderVersion([]byte(src), "versions.tf", "aws", 5, "5.0")
+	if err != nil {
+		t.Fatalf("SetProviderVersion() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("SetProviderVersion() =\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = SetProviderVersion([]byte(src), "versions.tf", "awscc", 0, "1.0.0")
+	if err != nil {
+		t.Fatalf("SetProviderVersion() error = %v", err)
+	}
+	if want := `awscc = { source = "hashicorp/awscc", version = ">= 1.0.0" }`; !strings.Contains(string(got), want) {
+		t.Errorf("SetProviderVersion() did not update inline object\n%s", got)
+	}
+
+	if _, err := SetProviderVersion([]byte(src), "versions.tf", "google", 0, "1.0.0"); err == nil {
+		t.Error("SetProviderVersion() error = nil for missing provider")
+	}
+}
+
+func TestConstraintWithVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldValue string
+		latest   string
+		want     string
+	}{
+		{name: "simple version", oldValue: "4.0.0", latest: "5.0.0", want: "5.0.0"},
+		{name: "pessimistic constraint", oldValue: "~> 4.0", latest: "5.0", want: "~> 5.0"},
+		{name: "compact pessimistic constraint", oldValue: "~>4.0", latest: "5.0", want: "~> 5.0"},
+		{name: "greater than or equal", oldValue: ">= 4.0.0", latest: "5.0.0", want: ">= 5.0.0"},
+		{name: "exact version with equals", oldValue: "= 4.0.0", latest: "5.0.0", want: "= 5.0.0"},
+		{name: "less than or equal", oldValue: "<= 4.0.0", latest: "5.0.0", want: "<= 5.0.0"},
+		{name: "greater than", oldValue: "> 3.0.0", latest: "4.0.0", want: "> 4.0.0"},
+		{name: "less than", oldValue: "< 5.0.0", latest: "6.0.0", want: "< 6.0.0"},
+		{name: "no operator", oldValue: "noquotes", latest: "5.0.0", want: "5.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConstraintWithVersion(tt.oldValue, tt.latest); got != tt.want {
+				t.Errorf("ConstraintWithVersion(%q, %q) = %q, want %q", tt.oldValue, tt.latest, got, tt.want)
+			}
+		})
+	}
+
+	// A version that is not a quoted string is left to the user rather than rewritten
+	src := "terraform {\n  required_providers {\n    aws = { source = \"hashicorp/aws\", version = noquotes }\n  }\n}\n"
+	if _, err := SetProviderVersion([]byte(src), "versions.tf", "aws", 0, "5.0.0"); err == nil {
+		t.Error("SetProviderVersion() error = nil for unquoted version")
+	}
+}
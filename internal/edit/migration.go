@@ -0,0 +1,194 @@
+package edit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/heyjobs/terranovate/internal/ai"
+)
+
+// moduleMetaArguments are the arguments of a module block a migration must not edit
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// ModuleBlock returns the source of a module block, located like in SetModuleVersion
+func ModuleBlock(src []byte, filename, name string, line int) (string, error) {
+	body, err := parseBody(src, filename)
+	if err != nil {
+		return "", err
+	}
+
+	block := findModuleBlock(body, name, line)
+	if block == nil {
+		return "", fmt.Errorf("module %q not found in %s", name, filename)
+	}
+
+	rng := block.Range()
+	return string(src[rng.Start.Byte:rng.End.Byte]), nil
+}
+
+// ApplyMigration applies the argument edits of a migration patch to a module block
+// and appends its moved blocks, with addresses prefixed by the module, to the end of
+// the file. Every value and address must parse and the result is parsed again, so a
+// malformed patch is an error rather than a broken file.
+func ApplyMigration(src []byte, filename, name string, line int, patch ai.MigrationPatch) ([]byte, error) {
+	body, err := parseBody(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	block := findModuleBlock(body, name, line)
+	if block == nil {
+		return nil, fmt.Errorf("module %q not found in %s", name, filename)
+	}
+
+	var edits []textEdit
+	touched := map[string]bool{}
+	touch := func(arg string) error {
+		if moduleMetaArguments[arg] {
+			return fmt.Errorf("migration may not edit the %s argument", arg)
+		}
+		if !hclsyntax.ValidIdentifier(arg) {
+			return fmt.Errorf("invalid argument name %q", arg)
+		}
+		if touched[arg] {
+			return fmt.Errorf("migration edits the %s argument twice", arg)
+		}
+		touched[arg] = true
+		return nil
+	}
+
+	for _, argEdit := range patch.Arguments {
+		if err := touch(argEdit.Name); err != nil {
+			return nil, err
+		}
+		attr, exists := block.Body.Attributes[argEdit.Name]
+
+		switch argEdit.Op {
+		case ai.OpRename:
+			if !exists {
+				return nil, fmt.Errorf("cannot rename %s: module %q does not set it", argEdit.Name, name)
+			}
+			if err := touch(argEdit.NewName); err != nil {
+				return nil, err
+			}
+			if _, taken := block.Body.Attributes[argEdit.NewName]; taken {
+				return nil, fmt.Errorf("cannot rename %s: module %q already sets %s", argEdit.Name, name, argEdit.NewName)
+			}
+			edits = append(edits, textEdit{
+				start:       attr.NameRange.Start.Byte,
+				end:         attr.NameRange.End.Byte,
+				replacement: []byte(argEdit.NewName),
+			})
+
+		case ai.OpSet:
+			value := strings.TrimSpace(argEdit.Value)
+			if _, diags := hclsyntax.ParseExpression([]byte(value), "value", hcl.InitialPos); diags.HasErrors() {
+				return nil, fmt.Errorf("invalid value for %s: %s", argEdit.Name, diags.Error())
+			}
+			if exists {
+				rng := attr.Expr.Range()
+				edits = append(edits, textEdit{start: rng.Start.Byte, end: rng.End.Byte, replacement: []byte(value)})
+				continue
+			}
+
+			// Add the argument on its own line before the closing brace
+			insertAt := lineStart(src, block.CloseBraceRange.Start.Byte)
+			if strings.TrimSpace(string(src[insertAt:block.CloseBraceRange.Start.Byte])) != "" {
+				return nil, fmt.Errorf("cannot add %s: module %q is written on one line", argEdit.Name, name)
+			}
+			edits = append(edits, textEdit{
+				start:       insertAt,
+				end:         insertAt,
+				replacement: []byte(fmt.Sprintf("%s%s = %s\n", argumentIndent(src, block), argEdit.Name, value)),
+			})
+
+		case ai.OpRemove:
+			if !exists {
+				return nil, fmt.Errorf("cannot remove %s: module %q does not set it", argEdit.Name, name)
+			}
+			start := lineStart(src, attr.SrcRange.Start.Byte)
+			end := attr.SrcRange.End.Byte
+			for end < len(src) && src[end] != '\n' {
+				end++
+			}
+			if end < len(src) {
+				end++
+			}
+			edits = append(edits, textEdit{start: start, end: end})
+
+		default:
+			return nil, fmt.Errorf("unknown migration op %q", argEdit.Op)
+		}
+	}
+
+	if len(patch.Moved) > 0 {
+		var moved strings.Builder
+		if len(src) > 0 && src[len(src)-1] != '\n' {
+			moved.WriteString("\n")
+		}
+		for _, m := range patch.Moved {
+			from, err := movedAddress(name, m.From)
+			if err != nil {
+				return nil, err
+			}
+			to, err := movedAddress(name, m.To)
+			if err != nil {
+				return nil, err
+			}
+			moved.WriteString(fmt.Sprintf("\nmoved {\n  from = %s\n  to   = %s\n}\n", from, to))
+		}
+		edits = append(edits, textEdit{start: len(src), end: len(src), replacement: []byte(moved.String())})
+	}
+
+	out := applyEdits(src, edits...)
+	if _, err := parseBody(out, filename); err != nil {
+		return nil, fmt.Errorf("migration produces invalid HCL: %w", err)
+	}
+	return out, nil
+}
+
+// movedAddress prefixes a resource address inside a module with the module call
+func movedAddress(module, address string) (string, error) {
+	address = strings.TrimSpace(address)
+	prefix := "module." + module + "."
+	if !strings.HasPrefix(address, prefix) {
+		if strings.HasPrefix(address, "module.") {
+			return "", fmt.Errorf("moved address %q is outside module %q", address, module)
+		}
+		address = prefix + address
+	}
+	if _, diags := hclsyntax.ParseTraversalAbs([]byte(address), "moved", hcl.InitialPos); diags.HasErrors() {
+		return "", fmt.Errorf("invalid moved address %q: %s", address, diags.Error())
+	}
+	return address, nil
+}
+
+// lineStart returns the offset of the start of the line containing offset
+func lineStart(src []byte, offset int) int {
+	for offset > 0 && src[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+// argumentIndent returns the indentation of the arguments of a block, two spaces
+// when it has none on their own line
+func argumentIndent(src []byte, block *hclsyntax.Block) string {
+	for _, attr := range block.Body.Attributes {
+		start := attr.SrcRange.Start.Byte
+		indent := src[lineStart(src, start):start]
+		if strings.TrimSpace(string(indent)) == "" {
+			return string(indent)
+		}
+	}
+	return "  "
+}
@@ -0,0 +1,113 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/ai"
+)
+
+func TestApplyMigration(t *testing.T) {
+	src := `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+
+  enable_classiclink = true
+  legacy_tags        = {}
+  cidr               = "10.0.0.0/16"
+}
+`
+
+	tests := []struct {
+		name    string
+		patch   ai.MigrationPatch
+		want    string
+		wantErr string
+	}{
+		{
+			name: "rename, set, add and remove",
+			patch: ai.MigrationPatch{
+				Arguments: []ai.ArgumentEdit{
+					{Op: ai.OpRename, Name: "enable_classiclink", NewName: "enable_dns_hostnames"},
+					{Op: ai.OpSet, Name: "cidr", Value: `"10.1.0.0/16"`},
+					{Op: ai.OpSet, Name: "azs", Value: `["eu-west-1a", "eu-west-1b"]`},
+					{Op: ai.OpRemove, Name: "legacy_tags"},
+				},
+				Moved: []ai.MovedBlock{{From: "aws_eip.nat", To: "module.vpc.aws_eip.this"}},
+			},
+			want: `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+
+  enable_dns_hostnames = true
+  cidr               = "10.1.0.0/16"
+  azs = ["eu-west-1a", "eu-west-1b"]
+}
+
+moved {
+  from = module.vpc.aws_eip.nat
+  to   = module.vpc.aws_eip.this
+}
+`,
+		},
+		{
+			name:    "meta-argument",
+			patch:   ai.MigrationPatch{Arguments: []ai.ArgumentEdit{{Op: ai.OpSet, Name: "version", Value: `"6.0.0"`}}},
+			wantErr: "may not edit the version argument",
+		},
+		{
+			name:    "rename of an unset argument",
+			patch:   ai.MigrationPatch{Arguments: []ai.ArgumentEdit{{Op: ai.OpRename, Name: "azs", NewName: "zones"}}},
+			wantErr: "does not set it",
+		},
+		{
+			name:    "rename onto a set argument",
+			patch:   ai.MigrationPatch{Arguments: []ai.ArgumentEdit{{Op: ai.OpRename, Name: "legacy_tags", NewName: "cidr"}}},
+			wantErr: "already sets cidr",
+		},
+		{
+			name:    "invalid value",
+			patch:   ai.MigrationPatch{Arguments: []ai.ArgumentEdit{{Op: ai.OpSet, Name: "cidr", Value: `"10.0.0.0/16`}}},
+			wantErr: "invalid value for cidr",
+		},
+		{
+			name:    "moved outside the module",
+			patch:   ai.MigrationPatch{Moved: []ai.MovedBlock{{From: "module.other.aws_eip.nat", To: "aws_eip.this"}}},
+			wantErr: "outside module",
+		},
+		{
+			name:    "invalid moved address",
+			patch:   ai.MigrationPatch{Moved: []ai.MovedBlock{{From: "aws_eip.nat", To: "aws_eip.this this"}}},
+			wantErr: "invalid moved address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMigration([]byte(src), "main.tf", "vpc", 1, tt.patch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyMigration() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyMigration() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ApplyMigration() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleBlock(t *testing.T) {
+	src := "locals {}\n\nmodule \"vpc\" {\n  source = \"x\"\n}\n"
+	got, err := ModuleBlock([]byte(src), "main.tf", "vpc", 3)
+	if err != nil {
+		t.Fatalf("ModuleBlock() error = %v", err)
+	}
+	if want := "module \"vpc\" {\n  source = \"x\"\n}"; got != want {
+		t.Errorf("ModuleBlock() = %q, want %q", got, want)
+	}
+}
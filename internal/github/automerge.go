@@ -0,0 +1,297 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/heyjobs/terranovate/internal/terraform"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+// AutoMergePolicy decides which update PRs get GitHub auto-merge enabled
+type AutoMergePolicy struct {
+	// Merge method used once required checks pass: merge, squash or rebase
+	MergeMethod string
+
+	// Update types eligible for auto-merge (e.g. patch)
+	UpdateTypes []string
+
+	// Maximum number of in-place resource modifications a clean plan may contain
+	MaxResourceChanges int
+
+	// Package rules, the first rule matching a dependency overrides the defaults
+	Rules []AutoMergeRule
+}
+
+// AutoMergeRule overrides the auto-merge policy for matching dependencies
+type AutoMergeRule struct {
+	// Glob patterns matched against the module/provider name and source
+	Match []string
+
+	// Enable or disable auto-merge for matching dependencies (nil keeps the default)
+	AutoMerge *bool
+
+	// Update types eligible for auto-merge (empty keeps the default)
+	UpdateTypes []string
+
+	// Maximum in-place resource modifications (nil keeps the default)
+	MaxResourceChanges *int
+}
+
+// AutoMergeDecision records whether a PR qualifies for auto-merge and why
+type AutoMergeDecision struct {
+	Eligible bool
+	Reasons  []string
+}
+
+// effectivePolicy holds the policy values after applying package rules
+type effectivePolicy struct {
+	enabled            bool
+	updateTypes        []string
+	maxResourceChanges int
+	rule               string
+}
+
+// resolve applies the first package rule matching the dependency
+func (p *AutoMergePolicy) resolve(name, source string) effectivePolicy {
+	eff := effectivePolicy{
+		enabled:            true,
+		updateTypes:        p.UpdateTypes,
+		maxResourceChanges: p.MaxResourceChanges,
+	}
+
+	for _, rule := range p.Rules {
+		pattern, ok := rule.matches(name, source)
+		if !ok {
+			continue
+		}
+
+		eff.rule = pattern
+		if rule.AutoMerge != nil {
+			eff.enabled = *rule.AutoMerge
+		}
+		if len(rule.UpdateTypes) > 0 {
+			eff.updateTypes = rule.UpdateTypes
+		}
+		if rule.MaxResourceChanges != nil {
+			eff.maxResourceChanges = *rule.MaxResourceChanges
+		}
+		break
+	}
+
+	return eff
+}
+
+// matches returns the first pattern matching the dependency name or source
+func (r AutoMergeRule) matches(name, source string) (string, bool) {
+	for _, pattern := range r.Match {
+		if matchGlob(pattern, name) || matchGlob(pattern, source) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchGlob reports whether value matches the glob pattern, where * also matches slashes
+func matchGlob(pattern, value string) bool {
+	if value == "" {
+		return false
+	}
+
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expr, value)
+	return err == nil && matched
+}
+
+// autoMergeCheck is a dependency-specific condition of auto-merge: it blocks the PR
+// with blocker, or adds reason (if any) to why the PR qualifies
+type autoMergeCheck struct {
+	blocked bool
+	blocker string
+	reason  string
+}
+
+// Evaluate decides whether a module update PR qualifies for auto-merge. diff holds the
+// changes the update introduces over the base branch, nil to judge the whole plan.
+func (p *AutoMergePolicy) Evaluate(update version.UpdateInfo, planResult *terraform.PlanResult, diff *terraform.PlanDiff) AutoMergeDecision {
+	schemaChanges, _ := update.SchemaChanges.(*terraform.SchemaChanges)
+	return p.evaluate(update.Module.Name, update.Module.Source, update.UpdateType, update.HasBreakingChange, planResult, diff,
+		autoMergeCheck{
+			blocked: terraform.HasBreakingSchemaChanges(schemaChanges),
+			blocker: "the module has breaking API/schema changes",
+			reason:  "no breaking API/schema changes",
+		},
+		autoMergeCheck{
+			blocked: update.AIMigration != nil && update.AIMigration.Applied,
+			blocker: "the PR carries AI-proposed edits that need human review",
+		},
+	)
+}
+
+// EvaluateProvider decides whether a provider update PR qualifies for auto-merge. diff
+// holds the changes the update introduces over the base branch, nil to judge the whole plan.
+func (p *AutoMergePolicy) EvaluateProvider(update version.ProviderUpdateInfo, planResult *terraform.PlanResult, diff *terraform.PlanDiff) AutoMergeDecision {
+	return p.evaluate(update.Provider.Name, update.Provider.Source, update.UpdateType, update.HasBreakingChange, planResult, diff)
+}
+
+// evaluate applies the policy shared by module and provider updates, resolved for the
+// dependency's name and source: the allowed update types, the breaking change flag,
+// the dependency-specific checks and the plan
+func (p *AutoMergePolicy) evaluate(name, source string, updateType version.UpdateType, breaking bool, planResult *terraform.PlanResult, diff *terraform.PlanDiff, checks ...autoMergeCheck) AutoMergeDecision {
+	eff := p.resolve(name, source)
+
+	var blockers, reasons []string
+	if !eff.enabled {
+		return AutoMergeDecision{Reasons: []string{fmt.Sprintf("auto-merge is disabled by package rule `%s`", eff.rule)}}
+	}
+	if eff.rule != "" {
+		reasons = append(reasons, fmt.Sprintf("package rule `%s` applies", eff.rule))
+	}
+
+	if allowed, reason := updateTypeAllowed(updateType, eff.updateTypes); allowed {
+		reasons = append(reasons, reason)
+	} else {
+		blockers = append(blockers, reason)
+	}
+
+	for _, check := range checks {
+		if check.blocked {
+			blockers = append(blockers, check.blocker)
+		} else if check.reason != "" {
+			reasons = append(reasons, check.reason)
+		}
+	}
+
+	if breaking {
+		blockers = append(blockers, "the update is flagged as a breaking change")
+	}
+
+	if clean, reason := planIsClean(planResult, diff, eff.maxResourceChanges); clean {
+		reasons = append(reasons, reason)
+	} else {
+		blockers = append(blockers, reason)
+	}
+
+	if len(blockers) > 0 {
+		return AutoMergeDecision{Reasons: blockers}
+	}
+	return AutoMergeDecision{Eligible: true, Reasons: reasons}
+}
+
+// updateTypeAllowed checks the update type against the allowed list
+func updateTypeAllowed(updateType version.UpdateType, allowed []string) (bool, string) {
+	for _, t := range allowed {
+		if strings.EqualFold(t, string(updateType)) {
+			return true, fmt.Sprintf("%s updates are allowed to auto-merge", updateType)
+		}
+	}
+
+	if updateType == "" {
+		updateType = version.UpdateTypeUnknown
+	}
+	if len(allowed) == 0 {
+		return false, "no update types are allowed to auto-merge"
+	}
+	return false, fmt.Sprintf("%s updates are not allowed to auto-merge (allowed: %s)", updateType, strings.Join(allowed, ", "))
+}
+
+// planIsClean reports whether a plan has no changes, or only a bounded number of in-place
+// modifications. With a diff only the changes the update introduces count, so drift
+// already planned on the base branch does not block the update.
+func planIsClean(planResult *terraform.PlanResult, diff *terraform.PlanDiff, maxResourceChanges int) (bool, string) {
+	if planResult == nil {
+		return false, "no terraform plan result is available to prove the update is safe"
+	}
+	if !planResult.Success {
+		return false, "terraform plan failed"
+	}
+
+	hasChanges := planResult.HasChanges
+	add, change, destroy := planResult.ResourcesAdd, planResult.ResourcesChange, planResult.ResourcesDestroy
+	drift := ""
+	if diff != nil {
+		hasChanges = diff.HasChanges()
+		add = len(diff.Creations) + len(diff.Replacements)
+		change = len(diff.Updates)
+		destroy = len(diff.Deletions) + len(diff.Replacements)
+		if len(diff.Drift) > 0 {
+			drift = fmt.Sprintf(" (ignoring %d change(s) already planned on the base branch)", len(diff.Drift))
+		}
+	}
+
+	if !hasChanges {
+		return true, "terraform plan shows no changes caused by the update" + drift
+	}
+	if add > 0 || destroy > 0 {
+		return false, fmt.Sprintf("terraform plan creates, replaces or destroys resources (%d to add, %d to destroy)", add, destroy)
+	}
+	if change > maxResourceChanges {
+		return false, fmt.Sprintf("terraform plan modifies %d resource(s) in place, more than the allowed %d",
+			change, maxResourceChanges)
+	}
+	return true, fmt.Sprintf("terraform plan only modifies %d resource(s) in place (allowed: %d)%s",
+		change, maxResourceChanges, drift)
+}
+
+// renderAutoMergeDecision writes the auto-merge section of a PR body
+func renderAutoMergeDecision(body *strings.Builder, decision *AutoMergeDecision) {
+	if decision == nil {
+		return
+	}
+
+	body.WriteString("### Auto-merge\n\n")
+	if decision.Eligible {
+		body.WriteString("✅ This PR qualifies for auto-merge and will be merged once required checks pass:\n\n")
+	} else {
+		body.WriteString("⏸️ This PR does not qualify for auto-merge:\n\n")
+	}
+	for _, reason := range decision.Reasons {
+		body.WriteString(fmt.Sprintf("- %s\n", reason))
+	}
+	body.WriteString("\n")
+}
+
+// enableAutoMerge turns on GitHub auto-merge for a pull request through the GraphQL API
+func (p *PRCreator) enableAutoMerge(ctx context.Context, pr *github.PullRequest) error {
+	method := "SQUASH"
+	if p.autoMerge.MergeMethod != "" {
+		method = strings.ToUpper(p.autoMerge.MergeMethod)
+	}
+
+	payload := map[string]interface{}{
+		"query": `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`,
+		"variables": map[string]interface{}{
+			"id":     pr.GetNodeID(),
+			"method": method,
+		},
+	}
+
+	req, err := p.client.NewRequest("POST", "graphql", payload)
+	if err != nil {
+		return fmt.Errorf("failed to build auto-merge request: %w", err)
+	}
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := p.client.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("failed to enable auto-merge: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		var msgs []string
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("failed to enable auto-merge: %s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/terraform"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+func TestAutoMergePolicyEvaluate(t *testing.T) {
+	disabled := false
+	two := 2
+
+	policy := &AutoMergePolicy{
+		UpdateTypes: []string{"patch"},
+		Rules: []AutoMergeRule{
+			{Match: []string{"legacy-*"}, AutoMerge: &disabled},
+			{Match: []string{"terraform-aws-modules/*"}, UpdateTypes: []string{"patch", "minor"}, MaxResourceChanges: &two},
+		},
+	}
+
+	cleanPlan := &terraform.PlanResult{Success: true, HasChanges: false}
+	modifyPlan := &terraform.PlanResult{Success: true, HasChanges: true, ResourcesChange: 2}
+	replacePlan := &terraform.PlanResult{Success: true, HasChanges: true, ResourcesAdd: 1, ResourcesDestroy: 1}
+	driftOnly := &terraform.PlanDiff{Drift: []terraform.ResourceChange{
+		{Address: "aws_instance.a", Action: []string{"delete", "create"}},
+		{Address: "aws_s3_bucket.b", Action: []string{"update"}},
+	}}
+	driftAndUpdate := &terraform.PlanDiff{
+		Updates: []terraform.ResourceChange{{Address: "aws_s3_bucket.b", Action: []string{"update"}}},
+		Drift:   []terraform.ResourceChange{{Address: "aws_instance.a", Action: []string{"delete", "create"}}},
+	}
+
+	tests := []struct {
+		name         string
+		update       version.UpdateInfo
+		planResult   *terraform.PlanResult
+		diff         *terraform.PlanDiff
+		wantEligible bool
+		wantReason   string
+	}{
+		{
+			name:         "patch update with clean plan",
+			update:       moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch),
+			planResult:   cleanPlan,
+			wantEligible: true,
+			wantReason:   "terraform plan shows no changes",
+		},
+		{
+			name:       "minor update not allowed by default",
+			update:     moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypeMinor),
+			planResult: cleanPlan,
+			wantReason: "minor updates are not allowed to auto-merge (allowed: patch)",
+		},
+		{
+			name:       "no plan result",
+			update:     moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch),
+			planResult: nil,
+			wantReason: "no terraform plan result",
+		},
+		{
+			name:       "in-place modifications above default limit",
+			update:     moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch),
+			planResult: modifyPlan,
+			wantReason: "modifies 2 resource(s) in place, more than the allowed 0",
+		},
+		{
+			name:       "replacements are never clean",
+			update:     moduleUpdate("vpc", "terraform-aws-modules/vpc/aws", version.UpdateTypePatch),
+			planResult: replacePlan,
+			wantReason: "creates, replaces or destroys resources",
+		},
+		{
+			name:         "drift on the base branch is ignored",
+			update:       moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch),
+			planResult:   replacePlan,
+			diff:         driftOnly,
+			wantEligible: true,
+			wantReason:   "ignoring 2 change(s) already planned on the base branch",
+		},
+		{
+			name:       "modifications of the update count besides drift",
+			update:     moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch),
+			planResult: replacePlan,
+			diff:       driftAndUpdate,
+			wantReason: "modifies 1 resource(s) in place, more than the allowed 0",
+		},
+		{
+			name:         "package rule allows minor updates and modifications",
+			update:       moduleUpdate("vpc", "terraform-aws-modules/vpc/aws", version.UpdateTypeMinor),
+			planResult:   modifyPlan,
+			wantEligible: true,
+			wantReason:   "package rule `terraform-aws-modules/*` applies",
+		},
+		{
+			name:       "package rule disables auto-merge",
+			update:     moduleUpdate("legacy-network", "git::https://example.com/net.git", version.UpdateTypePatch),
+			planResult: cleanPlan,
+			wantReason: "auto-merge is disabled by package rule `legacy-*`",
+		},
+		{
+			name: "breaking schema changes",
+			update: func() version.UpdateInfo {
+				u := moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch)
+				u.SchemaChanges = &terraform.SchemaChanges{
+					HasChanges:  true,
+					RemovedVars: []terraform.VariableChange{{Name: "name"}},
+				}
+				return u
+			}(),
+			planResult: cleanPlan,
+			wantReason: "breaking API/schema changes",
+		},
+		{
+			name: "AI-proposed edits",
+			update: func() version.UpdateInfo {
+				u := moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch)
+				u.AIMigration = &ai.MigrationResult{Applied: true}
+				return u
+			}(),
+			planResult: cleanPlan,
+			wantReason: "AI-proposed edits that need human review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.update, tt.planResult, tt.diff)
+			if decision.Eligible != tt.wantEligible {
+				t.Errorf("Eligible = %v, want %v (reasons: %v)", decision.Eligible, tt.wantEligible, decision.Reasons)
+			}
+			if !strings.Contains(strings.Join(decision.Reasons, "\n"), tt.wantReason) {
+				t.Errorf("Reasons = %v, want one containing %q", decision.Reasons, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGeneratePRBodyAutoMerge(t *testing.T) {
+	creator := &PRCreator{}
+	update := moduleUpdate("app", "git::https://example.com/app.git", version.UpdateTypePatch)
+	planResult := &terraform.PlanResult{Success: true, Output: "No changes."}
+
+	if body := creator.generatePRBody(update, planResult, nil); strings.Contains(body, "Auto-merge") {
+		t.Error("generatePRBody() mentions auto-merge without a policy")
+	}
+
+	creator.SetAutoMergePolicy(&AutoMergePolicy{UpdateTypes: []string{"patch"}})
+	body := creator.generatePRBody(update, planResult, nil)
+	if !strings.Contains(body, "This PR qualifies for auto-merge") {
+		t.Errorf("generatePRBody() does not explain auto-merge eligibility\nBody:\n%s", body)
+	}
+
+	providerBody := creator.generateProviderPRBody(version.ProviderUpdateInfo{
+		Provider:       scanner.ProviderInfo{Name: "aws", Source: "hashicorp/aws"},
+		CurrentVersion: "5.0.0",
+		LatestVersion:  "5.0.1",
+		UpdateType:     version.UpdateTypePatch,
+	}, nil)
+	if !strings.Contains(providerBody, "does not qualify for auto-merge") {
+		t.Errorf("generateProviderPRBody() does not explain why auto-merge was skipped\nBody:\n%s", providerBody)
+	}
+}
+
+func moduleUpdate(name, source string, updateType version.UpdateType) version.UpdateInfo {
+	return version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: name, Source: source, FilePath: "main.tf", Line: 1},
+		CurrentVersion: "1.0.0",
+		LatestVersion:  "1.0.1",
+		UpdateType:     updateType,
+		IsOutdated:     true,
+	}
+}
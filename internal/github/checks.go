@@ -0,0 +1,313 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/heyjobs/terranovate/internal/version"
+	"github.com/rs/zerolog/log"
+)
+
+// CheckCommentMarker identifies the sticky check comment on a pull request
+const CheckCommentMarker = "<!-- terranovate:check -->"
+
+// CheckRunName is the name of the check run published by the check command
+const CheckRunName = "Terranovate"
+
+// maxAnnotationsPerRequest is the GitHub limit of annotations per check run request
+const maxAnnotationsPerRequest = 50
+
+// maxCheckSummaryLength keeps the check run summary under GitHub's 65535 character limit
+const maxCheckSummaryLength = 60000
+
+// hunkHeader matches the new-file range of a unified diff hunk header
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// PRReporter posts check results to a pull request
+type PRReporter struct {
+	client *github.Client
+	owner  string
+	repo   string
+}
+
+// PRDiff holds the lines a pull request changes, keyed by repository-relative file path
+type PRDiff struct {
+	HeadSHA string
+
+	// Changed lines of the new file; a nil slice means the whole file changed
+	Files map[string][]int
+}
+
+// NewPRReporter creates a new PR reporter instance
+func NewPRReporter(token, owner, repo string) (*PRReporter, error) {
+	if token == "" {
+		return nil, fmt.Errorf("github token is required")
+	}
+
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("owner and repo are required")
+	}
+
+	return &PRReporter{
+		client: newClient(token),
+		owner:  owner,
+		repo:   repo,
+	}, nil
+}
+
+// Diff fetches the head commit and changed lines of a pull request
+func (r *PRReporter) Diff(ctx context.Context, number int) (*PRDiff, error) {
+	pr, _, err := r.client.PullRequests.Get(ctx, r.owner, r.repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request #%d: %w", number, err)
+	}
+
+	diff := &PRDiff{
+		HeadSHA: pr.GetHead().GetSHA(),
+		Files:   make(map[string][]int),
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := r.client.PullRequests.ListFiles(ctx, r.owner, r.repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request files: %w", err)
+		}
+
+		for _, file := range files {
+			if file.GetStatus() == "removed" {
+				continue
+			}
+			diff.Files[file.GetFilename()] = ParsePatchLines(file.GetPatch())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return diff, nil
+}
+
+// ParsePatchLines returns the new-file line numbers a unified diff patch adds or
+// removes next to. It returns nil when the patch is empty (binary or too large),
+// meaning the whole file should be treated as changed.
+func ParsePatchLines(patch string) []int {
+	if patch == "" {
+		return nil
+	}
+
+	lines := []int{}
+	newLine := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			newLine, _ = strconv.Atoi(m[1])
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, newLine)
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			// A removed line touches the line now in its place
+			lines = append(lines, newLine)
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+		default:
+			newLine++
+		}
+	}
+
+	return lines
+}
+
+// Touches reports whether the pull request changes any line in [start, end] of a file
+func (d *PRDiff) Touches(path string, start, end int) bool {
+	lines, ok := d.Files[filepath.ToSlash(filepath.Clean(path))]
+	if !ok {
+		return false
+	}
+	if lines == nil {
+		return true
+	}
+	if end < start {
+		end = start
+	}
+
+	for _, line := range lines {
+		if line >= start && line <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// UpsertComment creates or updates the sticky check comment on a pull request
+func (r *PRReporter) UpsertComment(ctx context.Context, number int, body string) (*github.IssueComment, error) {
+	body = CheckCommentMarker + "\n" + body
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := r.client.Issues.ListComments(ctx, r.owner, r.repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request comments: %w", err)
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), CheckCommentMarker) {
+				updated, _, err := r.client.Issues.EditComment(ctx, r.owner, r.repo, comment.GetID(), &github.IssueComment{
+					Body: github.String(body),
+				})
+				if err != nil {
+					return nil, fmt.Errorf("failed to update check comment: %w", err)
+				}
+				return updated, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	created, _, err := r.client.Issues.CreateComment(ctx, r.owner, r.repo, number, &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check comment: %w", err)
+	}
+
+	return created, nil
+}
+
+// PublishCheckRun publishes a completed check run with annotations for outdated and breaking dependencies
+func (r *PRReporter) PublishCheckRun(ctx context.Context, headSHA, summary string,
+	updates []version.UpdateInfo, providerUpdates []version.ProviderUpdateInfo) (*github.CheckRun, error) {
+	annotations := CheckAnnotations(updates, providerUpdates)
+	if len(summary) > maxCheckSummaryLength {
+		summary = summary[:maxCheckSummaryLength] + "\n\n*Summary truncated, see the PR comment for the full report.*"
+	}
+	title, conclusion := checkRunOutcome(updates, providerUpdates)
+
+	first := annotations
+	if len(first) > maxAnnotationsPerRequest {
+		first = first[:maxAnnotationsPerRequest]
+	}
+
+	run, _, err := r.client.Checks.CreateCheckRun(ctx, r.owner, r.repo, github.CreateCheckRunOptions{
+		Name:       CheckRunName,
+		HeadSHA:    headSHA,
+		Status:     github.String("completed"),
+		Conclusion: github.String(conclusion),
+		Output: &github.CheckRunOutput{
+			Title:       github.String(title),
+			Summary:     github.String(summary),
+			Annotations: first,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check run: %w", err)
+	}
+
+	// GitHub accepts at most 50 annotations per request, send the rest as updates
+	for i := maxAnnotationsPerRequest; i < len(annotations); i += maxAnnotationsPerRequest {
+		end := i + maxAnnotationsPerRequest
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+
+		if _, _, err := r.client.Checks.UpdateCheckRun(ctx, r.owner, r.repo, run.GetID(), github.UpdateCheckRunOptions{
+			Name: CheckRunName,
+			Output: &github.CheckRunOutput{
+				Title:       github.String(title),
+				Summary:     github.String(summary),
+				Annotations: annotations[i:end],
+			},
+		}); err != nil {
+			log.Warn().Err(err).Msg("failed to add check run annotations")
+			break
+		}
+	}
+
+	return run, nil
+}
+
+// CheckAnnotations builds check run annotations at the location of each outdated dependency
+func CheckAnnotations(updates []version.UpdateInfo, providerUpdates []version.ProviderUpdateInfo) []*github.CheckRunAnnotation {
+	var annotations []*github.CheckRunAnnotation
+
+	for _, update := range updates {
+		message := fmt.Sprintf("Module %s can be updated from %s to %s.", update.Module.Name, update.CurrentVersion, update.LatestVersion)
+		annotations = append(annotations, newAnnotation(update.Module.FilePath, update.Module.Line,
+			fmt.Sprintf("Outdated module: %s", update.Module.Name), message,
+			update.HasBreakingChange, update.BreakingChangeDetails))
+	}
+
+	for _, update := range providerUpdates {
+		message := fmt.Sprintf("Provider %s can be updated from %s to %s.", update.Provider.Source, update.CurrentVersion, update.LatestVersion)
+		annotations = append(annotations, newAnnotation(update.Provider.FilePath, update.Provider.Line,
+			fmt.Sprintf("Outdated provider: %s", update.Provider.Name), message,
+			update.HasBreakingChange, update.BreakingChangeDetails))
+	}
+
+	return annotations
+}
+
+// newAnnotation builds a single annotation, raising the level for breaking updates
+func newAnnotation(path string, line int, title, message string, breaking bool, breakingDetails string) *github.CheckRunAnnotation {
+	level := "notice"
+	if breaking {
+		level = "warning"
+		title = "Breaking update: " + strings.TrimPrefix(strings.TrimPrefix(title, "Outdated module: "), "Outdated provider: ")
+		if breakingDetails != "" {
+			message += " " + breakingDetails
+		}
+	}
+
+	if line < 1 {
+		line = 1
+	}
+
+	return &github.CheckRunAnnotation{
+		Path:            github.String(filepath.ToSlash(filepath.Clean(path))),
+		StartLine:       github.Int(line),
+		EndLine:         github.Int(line),
+		AnnotationLevel: github.String(level),
+		Title:           github.String(title),
+		Message:         github.String(message),
+	}
+}
+
+// checkRunOutcome returns the check run title and conclusion for the reported updates
+func checkRunOutcome(updates []version.UpdateInfo, providerUpdates []version.ProviderUpdateInfo) (string, string) {
+	total := len(updates) + len(providerUpdates)
+	if total == 0 {
+		return "All dependencies are up to date", "success"
+	}
+
+	breaking := 0
+	for _, update := range updates {
+		if update.HasBreakingChange {
+			breaking++
+		}
+	}
+	for _, update := range providerUpdates {
+		if update.HasBreakingChange {
+			breaking++
+		}
+	}
+
+	title := fmt.Sprintf("%d update(s) available", total)
+	if breaking > 0 {
+		title += fmt.Sprintf(", %d with potential breaking changes", breaking)
+	}
+	return title, "neutral"
+}
@@ -0,0 +1,130 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+func TestParsePatchLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []int
+	}{
+		{
+			name:  "empty patch means whole file",
+			patch: "",
+			want:  nil,
+		},
+		{
+			name: "single changed line",
+			patch: `@@ -1,4 +1,4 @@
+ module "vpc" {
+   source  = "terraform-aws-modules/vpc/aws"
+-  version = "5.0.0"
++  version = "5.1.0"
+ }`,
+			want: []int{3, 3},
+		},
+		{
+			name: "multiple hunks",
+			patch: `@@ -2,2 +2,3 @@
+ a
++b
+ c
+@@ -20,2 +21,2 @@
+ x
+-y
+\ No newline at end of file`,
+			want: []int{3, 22},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePatchLines(tt.patch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePatchLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRDiffTouches(t *testing.T) {
+	diff := &PRDiff{
+		Files: map[string][]int{
+			"network/main.tf": {12},
+			"versions.tf":     nil,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		start, end int
+		want       bool
+	}{
+		{name: "changed line inside block", path: "network/main.tf", start: 10, end: 14, want: true},
+		{name: "changed line outside block", path: "network/main.tf", start: 1, end: 5, want: false},
+		{name: "uncleaned path", path: "./network/main.tf", start: 12, end: 12, want: true},
+		{name: "whole file changed", path: "versions.tf", start: 3, end: 3, want: true},
+		{name: "file not in diff", path: "storage/main.tf", start: 1, end: 100, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.Touches(tt.path, tt.start, tt.end); got != tt.want {
+				t.Errorf("Touches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAnnotations(t *testing.T) {
+	updates := []version.UpdateInfo{
+		{
+			Module:                scanner.ModuleInfo{Name: "vpc", FilePath: "./network/main.tf", Line: 10},
+			CurrentVersion:        "4.0.0",
+			LatestVersion:         "5.0.0",
+			UpdateType:            version.UpdateTypeMajor,
+			HasBreakingChange:     true,
+			BreakingChangeDetails: "Major version update.",
+		},
+	}
+	providerUpdates := []version.ProviderUpdateInfo{
+		{
+			Provider:       scanner.ProviderInfo{Name: "aws", Source: "hashicorp/aws", FilePath: "versions.tf", Line: 4},
+			CurrentVersion: "5.0.0",
+			LatestVersion:  "5.1.0",
+			UpdateType:     version.UpdateTypeMinor,
+		},
+	}
+
+	annotations := CheckAnnotations(updates, providerUpdates)
+	if len(annotations) != 2 {
+		t.Fatalf("CheckAnnotations() returned %d annotations, want 2", len(annotations))
+	}
+
+	if got := annotations[0].GetPath(); got != "network/main.tf" {
+		t.Errorf("annotation path = %s, want network/main.tf", got)
+	}
+	if got := annotations[0].GetAnnotationLevel(); got != "warning" {
+		t.Errorf("breaking annotation level = %s, want warning", got)
+	}
+	if got := annotations[1].GetAnnotationLevel(); got != "notice" {
+		t.Errorf("outdated annotation level = %s, want notice", got)
+	}
+	if got := annotations[1].GetStartLine(); got != 4 {
+		t.Errorf("annotation line = %d, want 4", got)
+	}
+
+	if _, conclusion := checkRunOutcome(nil, nil); conclusion != "success" {
+		t.Errorf("conclusion without updates = %s, want success", conclusion)
+	}
+	if title, conclusion := checkRunOutcome(updates, providerUpdates); conclusion != "neutral" || title != "2 update(s) available, 1 with potential breaking changes" {
+		t.Errorf("checkRunOutcome() = %q, %q", title, conclusion)
+	}
+}
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersLocations are the CODEOWNERS paths GitHub reads, in order of precedence
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// CodeownersRule is a single CODEOWNERS line mapping a path pattern to owners
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string
+	regex   *regexp.Regexp
+}
+
+// LoadCodeowners reads the CODEOWNERS file of a repository, returning nil if there is none
+func LoadCodeowners(repoRoot string) ([]CodeownersRule, error) {
+	for _, location := range codeownersLocations {
+		content, err := os.ReadFile(filepath.Join(repoRoot, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		return ParseCodeowners(string(content)), nil
+	}
+
+	return nil, nil
+}
+
+// ParseCodeowners parses the content of a CODEOWNERS file
+func ParseCodeowners(content string) []CodeownersRule {
+	var rules []CodeownersRule
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Strip trailing comments
+		if idx := strings.Index(line, " #"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		fields := strings.Fields(line)
+		rules = append(rules, CodeownersRule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			regex:   codeownersPatternRegex(fields[0]),
+		})
+	}
+
+	return rules
+}
+
+// codeownersPatternRegex converts a gitignore-style CODEOWNERS pattern to a regular expression
+func codeownersPatternRegex(pattern string) *regexp.Regexp {
+	// Patterns without a slash (other than a trailing one) match at any depth
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	// A pattern matching a directory also matches everything below it
+	if dirOnly {
+		expr.WriteString("/.*$")
+	} else {
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.MustCompile(expr.String())
+}
+
+// CodeownersFor returns the owners of a repository-relative path; the last matching rule wins
+func CodeownersFor(rules []CodeownersRule, path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.regex == nil {
+			rule.regex = codeownersPatternRegex(rule.Pattern)
+		}
+		if rule.regex.MatchString(path) {
+			return rule.Owners
+		}
+	}
+
+	return nil
+}
+
+// SplitOwners splits CODEOWNERS owners into user logins and team slugs for a review request.
+// Email owners cannot be requested for review and are skipped.
+func SplitOwners(owners []string) (users, teams []string) {
+	seenUsers := make(map[string]bool)
+	seenTeams := make(map[string]bool)
+
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") {
+			continue
+		}
+		owner = strings.TrimPrefix(owner, "@")
+
+		if idx := strings.Index(owner, "/"); idx != -1 {
+			team := owner[idx+1:]
+			if team != "" && !seenTeams[team] {
+				seenTeams[team] = true
+				teams = append(teams, team)
+			}
+			continue
+		}
+
+		if !seenUsers[owner] {
+			seenUsers[owner] = true
+			users = append(users, owner)
+		}
+	}
+
+	return users, teams
+}
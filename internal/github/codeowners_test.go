@@ -0,0 +1,79 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodeownersFor(t *testing.T) {
+	rules := ParseCodeowners(`# Default owners
+*                 @heyjobs/platform-team
+
+/network/         @heyjobs/platform-team @alice
+warehouse/        @heyjobs/data-engineering
+*.tfvars          @bob
+docs/**/*.md      docs@heyjobs.com
+/modules/legacy   # no owners
+`)
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{name: "default owner", path: "main.tf", want: []string{"@heyjobs/platform-team"}},
+		{name: "anchored directory", path: "network/vpc/main.tf", want: []string{"@heyjobs/platform-team", "@alice"}},
+		{name: "unanchored directory at depth", path: "envs/prod/warehouse/main.tf", want: []string{"@heyjobs/data-engineering"}},
+		{name: "extension glob", path: "network/prod.tfvars", want: []string{"@bob"}},
+		{name: "double star", path: "docs/a/b/readme.md", want: []string{"docs@heyjobs.com"}},
+		{name: "rule without owners", path: "modules/legacy/main.tf", want: []string{}},
+		{name: "leading dot slash", path: "./warehouse/main.tf", want: []string{"@heyjobs/data-engineering"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CodeownersFor(rules, tt.path)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CodeownersFor(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitOwners(t *testing.T) {
+	users, teams := SplitOwners([]string{"@alice", "@heyjobs/platform-team", "docs@heyjobs.com", "@alice"})
+
+	if !reflect.DeepEqual(users, []string{"alice"}) {
+		t.Errorf("users = %v, want [alice]", users)
+	}
+	if !reflect.DeepEqual(teams, []string{"platform-team"}) {
+		t.Errorf("teams = %v, want [platform-team]", teams)
+	}
+}
+
+func TestReviewersFor(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/network/ @heyjobs/platform-team\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	creator := &PRCreator{workingDir: tmpDir, reviewers: []string{"fallback"}}
+
+	got := creator.reviewersFor(filepath.Join(tmpDir, "network", "main.tf"))
+	if !reflect.DeepEqual(got.TeamReviewers, []string{"platform-team"}) || len(got.Reviewers) != 0 {
+		t.Errorf("reviewersFor(network/main.tf) = %+v, want team platform-team", got)
+	}
+
+	got = creator.reviewersFor(filepath.Join(tmpDir, "warehouse", "main.tf"))
+	if !reflect.DeepEqual(got.Reviewers, []string{"fallback"}) {
+		t.Errorf("reviewersFor(warehouse/main.tf) = %+v, want configured reviewers", got)
+	}
+}
@@ -0,0 +1,356 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/version"
+	"github.com/rs/zerolog/log"
+)
+
+// dashboardMarker identifies the dependency dashboard issue among all open issues
+const dashboardMarker = "<!-- terranovate:dashboard -->"
+
+// approvalPattern matches a ticked checkbox row and captures its dependency key, up
+// to the end of the comment since file paths in keys may contain spaces
+var approvalPattern = regexp.MustCompile(`(?m)^\s*-\s*\[[xX]\]\s*<!--\s*terranovate:approve:(.+?)\s*-->`)
+
+// Dashboard maintains a single GitHub issue summarising all pending dependency updates
+type Dashboard struct {
+	client *github.Client
+	owner  string
+	repo   string
+	title  string
+}
+
+// DashboardData holds everything rendered into the dashboard issue
+type DashboardData struct {
+	Updates           []version.UpdateInfo
+	ProviderUpdates   []version.ProviderUpdateInfo
+	HeldBack          []version.UpdateInfo
+	HeldBackProviders []version.ProviderUpdateInfo
+	UnusedProviders   []scanner.UnusedProviderInfo
+	Failures          []version.CheckFailure
+
+	// Open Terranovate pull requests keyed by head branch name
+	OpenPRs map[string]*github.PullRequest
+
+	// Dependency keys that were ticked on the previous dashboard and are still pending
+	Approved map[string]bool
+}
+
+// NewDashboard creates a new dependency dashboard instance
+func NewDashboard(token, owner, repo, title string) (*Dashboard, error) {
+	if token == "" {
+		return nil, fmt.Errorf("github token is required")
+	}
+
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("owner and repo are required")
+	}
+
+	return &Dashboard{
+		client: newClient(token),
+		owner:  owner,
+		repo:   repo,
+		title:  title,
+	}, nil
+}
+
+// ModuleKey returns the dashboard key identifying a module dependency
+func ModuleKey(module scanner.ModuleInfo) string {
+	return fmt.Sprintf("module:%s:%s", module.FilePath, module.Name)
+}
+
+// ProviderKey returns the dashboard key identifying a provider requirement
+func ProviderKey(provider scanner.ProviderInfo) string {
+	return fmt.Sprintf("provider:%s:%s", provider.FilePath, provider.Name)
+}
+
+// Find returns the existing dashboard issue, or nil if there is none yet
+func (d *Dashboard) Find(ctx context.Context) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := d.client.Issues.ListByRepo(ctx, d.owner, d.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues: %w", err)
+		}
+
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+			if strings.Contains(issue.GetBody(), dashboardMarker) {
+				return issue, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Approvals reads the dashboard issue back and returns the dependency keys whose
+// checkbox a maintainer ticked to request a PR on the next run
+func (d *Dashboard) Approvals(ctx context.Context) (map[string]bool, error) {
+	issue, err := d.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if issue == nil {
+		return map[string]bool{}, nil
+	}
+
+	return ParseApprovals(issue.GetBody()), nil
+}
+
+// OpenPRs returns the open Terranovate pull requests keyed by head branch name
+func (d *Dashboard) OpenPRs(ctx context.Context) (map[string]*github.PullRequest, error) {
+	prs := make(map[string]*github.PullRequest)
+
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		page, resp, err := d.client.PullRequests.List(ctx, d.owner, d.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+
+		for _, pr := range page {
+			branch := pr.GetHead().GetRef()
+			if strings.HasPrefix(branch, "terranovate/") {
+				prs[branch] = pr
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Update creates the dashboard issue or replaces the body of the existing one
+func (d *Dashboard) Update(ctx context.Context, data DashboardData) (*github.Issue, error) {
+	body := RenderDashboard(data)
+
+	existing, err := d.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		issue, _, err := d.client.Issues.Create(ctx, d.owner, d.repo, &github.IssueRequest{
+			Title: github.String(d.title),
+			Body:  github.String(body),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dashboard issue: %w", err)
+		}
+
+		log.Info().Int("number", issue.GetNumber()).Msg("dependency dashboard created")
+		return issue, nil
+	}
+
+	issue, _, err := d.client.Issues.Edit(ctx, d.owner, d.repo, existing.GetNumber(), &github.IssueRequest{
+		Title: github.String(d.title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dashboard issue: %w", err)
+	}
+
+	log.Info().Int("number", issue.GetNumber()).Msg("dependency dashboard updated")
+	return issue, nil
+}
+
+// ParseApprovals extracts the dependency keys of ticked checkbox rows from a dashboard body
+func ParseApprovals(body string) map[string]bool {
+	approved := make(map[string]bool)
+
+	for _, match := range approvalPattern.FindAllStringSubmatch(body, -1) {
+		approved[match[1]] = true
+	}
+
+	return approved
+}
+
+// RenderDashboard renders the dashboard issue body
+func RenderDashboard(data DashboardData) string {
+	var body strings.Builder
+
+	body.WriteString(dashboardMarker + "\n")
+	body.WriteString("This issue lists all pending Terraform module and provider updates detected by Terranovate. ")
+	body.WriteString("It is updated automatically on every run.\n\n")
+
+	renderPendingUpdates(&body, data)
+	renderHeldBack(&body, data)
+
+	if len(data.UnusedProviders) > 0 {
+		body.WriteString("## 🧹 Unused Providers\n\n")
+		body.WriteString("These providers are declared in `required_providers` but not used by any resources.\n\n")
+		for _, unused := range data.UnusedProviders {
+			body.WriteString(fmt.Sprintf("- **%s** (`%s`) in `%s:%d` — %s\n",
+				unused.Provider.Name, unused.Provider.Source,
+				unused.Provider.FilePath, unused.Provider.Line, unused.Suggestion))
+		}
+		body.WriteString("\n")
+	}
+
+	if len(data.Failures) > 0 {
+		body.WriteString("## ❌ Failed Checks\n\n")
+		body.WriteString("The versions of these dependencies could not be checked:\n\n")
+		for _, failure := range data.Failures {
+			body.WriteString(fmt.Sprintf("- %s **%s** (`%s`) in `%s:%d`: %s\n",
+				failure.Kind, failure.Name, failure.Source, failure.FilePath, failure.Line, failure.Error))
+		}
+		body.WriteString("\n")
+	}
+
+	body.WriteString("---\n")
+	body.WriteString("🤖 *This dashboard is maintained by [Terranovate](https://github.com/heyjobs/terranovate)*\n")
+
+	return body.String()
+}
+
+// renderPendingUpdates writes the outdated dependencies grouped by update type
+func renderPendingUpdates(body *strings.Builder, data DashboardData) {
+	body.WriteString("## 📦 Pending Updates\n\n")
+
+	if len(data.Updates) == 0 && len(data.ProviderUpdates) == 0 {
+		body.WriteString("✨ All modules and providers are up to date!\n\n")
+		return
+	}
+
+	groups := []struct {
+		updateType version.UpdateType
+		heading    string
+	}{
+		{version.UpdateTypeMajor, "### 🔴 Major"},
+		{version.UpdateTypeMinor, "### 🟡 Minor"},
+		{version.UpdateTypePatch, "### 🟢 Patch"},
+		{version.UpdateTypeUnknown, "### ⚪ Other"},
+	}
+
+	for _, group := range groups {
+		var rows []string
+
+		for _, update := range data.Updates {
+			if normalizeUpdateType(update.UpdateType) != group.updateType {
+				continue
+			}
+			rows = append(rows, fmt.Sprintf("- module **%s** `%s` → `%s` (`%s:%d`) — %s",
+				update.Module.Name, update.CurrentVersion, update.LatestVersion,
+				update.Module.FilePath, update.Module.Line,
+				prReference(data.OpenPRs, ModuleBranchName(update))))
+		}
+
+		for _, update := range data.ProviderUpdates {
+			if normalizeUpdateType(update.UpdateType) != group.updateType {
+				continue
+			}
+			rows = append(rows, fmt.Sprintf("- provider **%s** `%s` → `%s` (`%s:%d`) — %s",
+				update.Provider.Name, update.CurrentVersion, update.LatestVersion,
+				update.Provider.FilePath, update.Provider.Line,
+				prReference(data.OpenPRs, ProviderBranchName(update))))
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		body.WriteString(group.heading + "\n\n")
+		body.WriteString(strings.Join(rows, "\n"))
+		body.WriteString("\n\n")
+	}
+}
+
+// renderHeldBack writes ignored and held-back dependencies, with a checkbox for
+// every update that can be requested on the next run
+func renderHeldBack(body *strings.Builder, data DashboardData) {
+	if len(data.HeldBack) == 0 && len(data.HeldBackProviders) == 0 {
+		return
+	}
+
+	body.WriteString("## ⏸️ Held Back\n\n")
+	body.WriteString("These updates are not proposed automatically. Tick a checkbox to request a PR on the next run.\n\n")
+
+	for _, update := range data.HeldBack {
+		key := ModuleKey(update.Module)
+		if update.LatestVersion == "" {
+			body.WriteString(fmt.Sprintf("- module **%s** `%s` (`%s:%d`) — %s\n",
+				update.Module.Name, update.CurrentVersion,
+				update.Module.FilePath, update.Module.Line, update.HeldBackReason))
+			continue
+		}
+
+		if _, ok := data.OpenPRs[ModuleBranchName(update)]; ok {
+			body.WriteString(fmt.Sprintf("- module **%s** `%s` → `%s` (`%s:%d`) — %s\n",
+				update.Module.Name, update.CurrentVersion, update.LatestVersion,
+				update.Module.FilePath, update.Module.Line,
+				prReference(data.OpenPRs, ModuleBranchName(update))))
+			continue
+		}
+
+		body.WriteString(fmt.Sprintf("- [%s] <!-- terranovate:approve:%s --> module **%s** `%s` → `%s` (`%s:%d`) — %s\n",
+			checkboxState(data.Approved[key]), key, update.Module.Name,
+			update.CurrentVersion, update.LatestVersion,
+			update.Module.FilePath, update.Module.Line, update.HeldBackReason))
+	}
+
+	for _, update := range data.HeldBackProviders {
+		key := ProviderKey(update.Provider)
+		if _, ok := data.OpenPRs[ProviderBranchName(update)]; ok {
+			body.WriteString(fmt.Sprintf("- provider **%s** `%s` → `%s` (`%s:%d`) — %s\n",
+				update.Provider.Name, update.CurrentVersion, update.LatestVersion,
+				update.Provider.FilePath, update.Provider.Line,
+				prReference(data.OpenPRs, ProviderBranchName(update))))
+			continue
+		}
+
+		body.WriteString(fmt.Sprintf("- [%s] <!-- terranovate:approve:%s --> provider **%s** `%s` → `%s` (`%s:%d`) — %s\n",
+			checkboxState(data.Approved[key]), key, update.Provider.Name,
+			update.CurrentVersion, update.LatestVersion,
+			update.Provider.FilePath, update.Provider.Line, update.HeldBackReason))
+	}
+
+	body.WriteString("\n")
+}
+
+// normalizeUpdateType maps empty update types to unknown for grouping
+func normalizeUpdateType(updateType version.UpdateType) version.UpdateType {
+	if updateType == "" {
+		return version.UpdateTypeUnknown
+	}
+	return updateType
+}
+
+// prReference renders a link to the open PR for a branch, if any
+func prReference(openPRs map[string]*github.PullRequest, branch string) string {
+	if pr, ok := openPRs[branch]; ok {
+		return fmt.Sprintf("[#%d](%s)", pr.GetNumber(), pr.GetHTMLURL())
+	}
+	return "no PR yet"
+}
+
+// checkboxState renders the markdown checkbox state
+func checkboxState(checked bool) string {
+	if checked {
+		return "x"
+	}
+	return " "
+}
@@ -0,0 +1,170 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+func TestNewDashboard(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		owner   string
+		repo    string
+		wantErr bool
+	}{
+		{name: "valid configuration", token: "ghp_test", owner: "org", repo: "repo"},
+		{name: "missing token", owner: "org", repo: "repo", wantErr: true},
+		{name: "missing repo", token: "ghp_test", owner: "org", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dashboard, err := NewDashboard(tt.token, tt.owner, tt.repo, "Dashboard")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDashboard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && dashboard.client == nil {
+				t.Error("dashboard.client is nil")
+			}
+		})
+	}
+}
+
+func TestRenderDashboard(t *testing.T) {
+	vpc := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "vpc", FilePath: "network/main.tf", Line: 3},
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+		UpdateType:     version.UpdateTypeMajor,
+	}
+	s3 := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "s3", FilePath: "storage/main.tf", Line: 1},
+		CurrentVersion: "1.0.0",
+		LatestVersion:  "1.0.1",
+		UpdateType:     version.UpdateTypePatch,
+	}
+	eks := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "eks", FilePath: "k8s/main.tf", Line: 7},
+		CurrentVersion: "19.0.0",
+		LatestVersion:  "20.1.0",
+		HeldBackReason: "held back by version_check.minor_only (latest is 20.1.0)",
+	}
+	legacy := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "legacy", FilePath: "main.tf", Line: 20},
+		CurrentVersion: "0.1.0",
+		HeldBackReason: "listed in version_check.ignore_modules",
+	}
+	aws := version.ProviderUpdateInfo{
+		Provider:       scanner.ProviderInfo{Name: "aws", Source: "hashicorp/aws", FilePath: "versions.tf", Line: 4},
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+		HeldBackReason: "held back by version_check.minor_only (latest is 5.0.0)",
+	}
+
+	data := DashboardData{
+		Updates:           []version.UpdateInfo{vpc, s3},
+		HeldBack:          []version.UpdateInfo{eks, legacy},
+		HeldBackProviders: []version.ProviderUpdateInfo{aws},
+		UnusedProviders: []scanner.UnusedProviderInfo{
+			{Provider: scanner.ProviderInfo{Name: "google", Source: "hashicorp/google", FilePath: "versions.tf", Line: 9}, Suggestion: "remove it"},
+		},
+		Failures: []version.CheckFailure{
+			{Kind: "module", Name: "private", Source: "git::https://example.com/x.git", FilePath: "main.tf", Line: 30, Error: "no tags found"},
+		},
+		OpenPRs: map[string]*github.PullRequest{
+			ModuleBranchName(vpc): {Number: github.Int(42), HTMLURL: github.String("https://github.com/org/repo/pull/42")},
+		},
+		Approved: map[string]bool{ProviderKey(aws.Provider): true},
+	}
+
+	body := RenderDashboard(data)
+
+	wantContains := []string{
+		dashboardMarker,
+		"### 🔴 Major",
+		"module **vpc** `4.0.0` → `5.0.0`",
+		"[#42](https://github.com/org/repo/pull/42)",
+		"### 🟢 Patch",
+		"module **s3** `1.0.0` → `1.0.1` (`storage/main.tf:1`) — no PR yet",
+		"- [ ] <!-- terranovate:approve:module:k8s/main.tf:eks --> module **eks**",
+		"- [x] <!-- terranovate:approve:provider:versions.tf:aws --> provider **aws**",
+		"module **legacy** `0.1.0` (`main.tf:20`) — listed in version_check.ignore_modules",
+		"Unused Providers",
+		"**google**",
+		"Failed Checks",
+		"no tags found",
+	}
+	for _, want := range wantContains {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard body does not contain %q\nBody:\n%s", want, body)
+		}
+	}
+
+	if strings.Contains(body, "### 🟡 Minor") {
+		t.Error("dashboard body should not render empty update groups")
+	}
+
+	if strings.Contains(body, "approve:module:main.tf:legacy") {
+		t.Error("ignored modules without a known latest version should not get a checkbox")
+	}
+}
+
+func TestRenderDashboardUpToDate(t *testing.T) {
+	body := RenderDashboard(DashboardData{})
+
+	if !strings.Contains(body, "All modules and providers are up to date") {
+		t.Errorf("dashboard body should report everything up to date\nBody:\n%s", body)
+	}
+}
+
+func TestParseApprovals(t *testing.T) {
+	body := `<!-- terranovate:dashboard -->
+## ⏸️ Held Back
+
+- [x] <!-- terranovate:approve:module:k8s/main.tf:eks --> module **eks**
+- [ ] <!-- terranovate:approve:module:main.tf:vpc --> module **vpc**
+  - [X] <!-- terranovate:approve:provider:versions.tf:aws --> provider **aws**
+- [x] some unrelated task
+`
+
+	got := ParseApprovals(body)
+
+	if len(got) != 2 {
+		t.Fatalf("ParseApprovals() returned %d keys, want 2: %v", len(got), got)
+	}
+	if !got["module:k8s/main.tf:eks"] {
+		t.Error("expected module:k8s/main.tf:eks to be approved")
+	}
+	if !got["provider:versions.tf:aws"] {
+		t.Error("expected provider:versions.tf:aws to be approved")
+	}
+	if got["module:main.tf:vpc"] {
+		t.Error("unticked module:main.tf:vpc should not be approved")
+	}
+}
+
+func TestParseApprovalsRoundTrip(t *testing.T) {
+	for _, filePath := range []string{"k8s/main.tf", "envs/my app/main.tf"} {
+		update := version.UpdateInfo{
+			Module:         scanner.ModuleInfo{Name: "eks", FilePath: filePath, Line: 7},
+			CurrentVersion: "19.0.0",
+			LatestVersion:  "20.0.0",
+			HeldBackReason: "held back",
+		}
+		key := ModuleKey(update.Module)
+
+		body := RenderDashboard(DashboardData{
+			HeldBack: []version.UpdateInfo{update},
+			Approved: map[string]bool{key: true},
+		})
+
+		if !ParseApprovals(body)[key] {
+			t.Errorf("approval for %s should survive a render/parse round trip\nBody:\n%s", key, body)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/edit"
+	"github.com/heyjobs/terranovate/internal/terraform"
+	"github.com/heyjobs/terranovate/internal/version"
+	"github.com/rs/zerolog/log"
+)
+
+// MigrationLabel is applied to PRs carrying AI-proposed edits
+const MigrationLabel = "ai-migration"
+
+// Migrator proposes edits of a module call for a breaking update, such as ai.Analyzer
+type Migrator interface {
+	ProposeMigration(ctx context.Context, req ai.MigrationRequest) (*ai.MigrationPatch, error)
+	Model() string
+}
+
+// Validator validates the root modules that include the edited files, such as
+// terraform.RootPlanner
+type Validator interface {
+	ValidateRoots(ctx context.Context, files []string) error
+}
+
+// SetMigrator enables AI-proposed migrations of breaking module updates. Proposed
+// edits are only committed once validator accepts them.
+func (p *PRCreator) SetMigrator(migrator Migrator, validator Validator) {
+	p.migrator = migrator
+	p.validator = validator
+}
+
+// needsMigration reports whether the schema diff or the release notes of an update
+// show breaking changes our module call may have to follow
+func needsMigration(update version.UpdateInfo) bool {
+	if schemaChanges, ok := update.SchemaChanges.(*terraform.SchemaChanges); ok && terraform.HasBreakingSchemaChanges(schemaChanges) {
+		return true
+	}
+	return update.ReleaseNotes.MentionsBreakingChanges()
+}
+
+// migrate asks the migrator for edits of the module call, already on the latest
+// version in the working tree, and applies them when the HCL parses and validates.
+// It returns nil when no migration was attempted or the model failed to answer.
+func (p *PRCreator) migrate(ctx context.Context, update version.UpdateInfo) *ai.MigrationResult {
+	if p.migrator == nil || p.validator == nil || !needsMigration(update) {
+		return nil
+	}
+
+	path := p.resolvePath(update.Module.FilePath)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		log.Warn().Err(err).Str("file", path).Msg("failed to read module call for AI migration")
+		return nil
+	}
+	call, err := edit.ModuleBlock(src, path, update.Module.Name, update.Module.Line)
+	if err != nil {
+		log.Warn().Err(err).Str("module", update.Module.Name).Msg("failed to locate module call for AI migration")
+		return nil
+	}
+
+	schemaChanges, _ := update.SchemaChanges.(*terraform.SchemaChanges)
+	patch, err := p.migrator.ProposeMigration(ctx, ai.MigrationRequest{
+		ModuleName:     update.Module.Name,
+		Source:         update.Module.Source,
+		CurrentVersion: update.CurrentVersion,
+		LatestVersion:  update.LatestVersion,
+		ModuleCall:     call,
+		SchemaChanges:  describeSchemaChanges(schemaChanges),
+		ReleaseNotes:   update.ReleaseNotes.Text(),
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("module", update.Module.Name).Msg("AI migration failed, skipping")
+		return nil
+	}
+
+	result := &ai.MigrationResult{Model: p.migrator.Model(), Patch: *patch}
+	if patch.Empty() {
+		return result
+	}
+
+	migrated, err := edit.ApplyMigration(src, path, update.Module.Name, update.Module.Line, *patch)
+	if err != nil {
+		result.Rejected = err.Error()
+		log.Warn().Err(err).Str("module", update.Module.Name).Msg("AI migration rejected")
+		return result
+	}
+	if err := os.WriteFile(path, migrated, 0644); err != nil {
+		result.Rejected = fmt.Sprintf("failed to write %s: %v", path, err)
+		return result
+	}
+
+	if err := p.validator.ValidateRoots(ctx, []string{path}); err != nil {
+		if restoreErr := os.WriteFile(path, src, 0644); restoreErr != nil {
+			log.Error().Err(restoreErr).Str("file", path).Msg("failed to revert AI migration")
+		}
+		result.Rejected = fmt.Sprintf("terraform validate failed: %v", err)
+		log.Warn().Err(err).Str("module", update.Module.Name).Msg("AI migration rejected")
+		return result
+	}
+
+	result.Applied = true
+	log.Info().
+		Str("module", update.Module.Name).
+		Int("arguments", len(patch.Arguments)).
+		Int("moved", len(patch.Moved)).
+		Msg("applied AI migration")
+	return result
+}
+
+// describeSchemaChanges lists the breaking API changes of a module for a prompt, one
+// per line
+func describeSchemaChanges(changes *terraform.SchemaChanges) string {
+	var lines []string
+	for _, change := range terraform.BreakingSchemaChanges(changes) {
+		lines = append(lines, "- "+change)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderMigration writes the AI-proposed migration of a module call, marking every
+// edit as proposed by a model
+func renderMigration(body *strings.Builder, result *ai.MigrationResult, moduleName string) {
+	if result == nil {
+		return
+	}
+
+	body.WriteString("### 🤖 AI-Proposed Migration\n\n")
+	switch {
+	case result.Applied:
+		body.WriteString(fmt.Sprintf("> [!WARNING]\n> The edits below were **proposed by an AI model** (`%s`) and committed separately on this branch. "+
+			"They parse and pass `terraform validate`, but no human has reviewed them: check every edit before merging.\n\n", result.Model))
+	case result.Rejected != "":
+		body.WriteString(fmt.Sprintf("An AI model (`%s`) proposed the edits below, but they were **not applied**: %s\n\n", result.Model, result.Rejected))
+	default:
+		body.WriteString(fmt.Sprintf("An AI model (`%s`) found no edits our module call needs.\n\n", result.Model))
+	}
+
+	if result.Patch.Summary != "" {
+		body.WriteString(fmt.Sprintf("%s\n\n", result.Patch.Summary))
+	}
+
+	for _, e := range result.Patch.Arguments {
+		switch e.Op {
+		case ai.OpRename:
+			body.WriteString(fmt.Sprintf("- 🤖 Rename `%s` → `%s`", e.Name, e.NewName))
+		case ai.OpSet:
+			body.WriteString(fmt.Sprintf("- 🤖 Set `%s = %s`", e.Name, e.Value))
+		case ai.OpRemove:
+			body.WriteString(fmt.Sprintf("- 🤖 Remove `%s`", e.Name))
+		}
+		if e.Reason != "" {
+			body.WriteString(" — " + e.Reason)
+		}
+		body.WriteString("\n")
+	}
+	for _, m := range result.Patch.Moved {
+		body.WriteString(fmt.Sprintf("- 🤖 `moved` from `module.%s.%s` to `module.%s.%s`",
+			moduleName, strings.TrimPrefix(m.From, "module."+moduleName+"."),
+			moduleName, strings.TrimPrefix(m.To, "module."+moduleName+".")))
+		if m.Reason != "" {
+			body.WriteString(" — " + m.Reason)
+		}
+		body.WriteString("\n")
+	}
+	if !result.Patch.Empty() {
+		body.WriteString("\n")
+	}
+}
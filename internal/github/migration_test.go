@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/heyjobs/terranovate/internal/terraform"
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+// fakeMigrator proposes a fixed patch
+type fakeMigrator struct {
+	patch *ai.MigrationPatch
+	err   error
+	req   ai.MigrationRequest
+}
+
+func (m *fakeMigrator) ProposeMigration(ctx context.Context, req ai.MigrationRequest) (*ai.MigrationPatch, error) {
+	m.req = req
+	return m.patch, m.err
+}
+
+func (m *fakeMigrator) Model() string {
+	return "test-model"
+}
+
+// fakeValidator fails validation with err
+type fakeValidator struct {
+	err   error
+	files []string
+}
+
+func (v *fakeValidator) ValidateRoots(ctx context.Context, files []string) error {
+	v.files = files
+	return v.err
+}
+
+func TestMigrate(t *testing.T) {
+	src := "module \"vpc\" {\n  source  = \"terraform-aws-modules/vpc/aws\"\n  version = \"5.0.0\"\n\n  enable_classiclink = true\n}\n"
+	patch := &ai.MigrationPatch{
+		Summary:   "enable_classiclink was removed",
+		Arguments: []ai.ArgumentEdit{{Op: ai.OpRemove, Name: "enable_classiclink", Reason: "removed in 5.0.0"}},
+	}
+	breaking := &terraform.SchemaChanges{
+		HasChanges:  true,
+		RemovedVars: []terraform.VariableChange{{Name: "enable_classiclink", Type: "bool"}},
+	}
+
+	tests := []struct {
+		name          string
+		schemaChanges interface{}
+		notes         *version.ReleaseNotes
+		migrator      *fakeMigrator
+		validateErr   error
+		wantResult    bool
+		wantApplied   bool
+		wantRejected  string
+	}{
+		{
+			name:          "applied after validation",
+			schemaChanges: breaking,
+			migrator:      &fakeMigrator{patch: patch},
+			wantResult:    true,
+			wantApplied:   true,
+		},
+		{
+			name:        "breaking release notes",
+			notes:       &version.ReleaseNotes{Versions: []version.VersionNotes{{Version: "5.0.0", Body: "### BREAKING CHANGES\n- Remove enable_classiclink"}}},
+			migrator:    &fakeMigrator{patch: patch},
+			wantResult:  true,
+			wantApplied: true,
+		},
+		{
+			name:          "validation fails",
+			schemaChanges: breaking,
+			migrator:      &fakeMigrator{patch: patch},
+			validateErr:   errors.New("Unsupported argument"),
+			wantResult:    true,
+			wantRejected:  "terraform validate failed: Unsupported argument",
+		},
+		{
+			name:          "invalid patch",
+			schemaChanges: breaking,
+			migrator:      &fakeMigrator{patch: &ai.MigrationPatch{Arguments: []ai.ArgumentEdit{{Op: ai.OpRemove, Name: "azs"}}}},
+			wantResult:    true,
+			wantRejected:  "does not set it",
+		},
+		{
+			name:     "not breaking",
+			notes:    &version.ReleaseNotes{Versions: []version.VersionNotes{{Version: "5.0.1", Body: "- Fix tags"}}},
+			migrator: &fakeMigrator{patch: patch},
+		},
+		{
+			name:          "model fails",
+			schemaChanges: breaking,
+			migrator:      &fakeMigrator{err: errors.New("rate limited")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "main.tf")
+			if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			validator := &fakeValidator{err: tt.validateErr}
+			p := &PRCreator{workingDir: dir}
+			p.SetMigrator(tt.migrator, validator)
+
+			update := version.UpdateInfo{
+				Module:         scanner.ModuleInfo{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", FilePath: path, Line: 1},
+				CurrentVersion: "4.0.0",
+				LatestVersion:  "5.0.0",
+				SchemaChanges:  tt.schemaChanges,
+				ReleaseNotes:   tt.notes,
+			}
+
+			result := p.migrate(context.Background(), update)
+			if (result != nil) != tt.wantResult {
+				t.Fatalf("migrate() = %+v, want a result: %t", result, tt.wantResult)
+			}
+
+			got, _ := os.ReadFile(path)
+			if result == nil {
+				if string(got) != src {
+					t.Errorf("migrate() edited the file without a result")
+				}
+				return
+			}
+
+			if result.Applied != tt.wantApplied {
+				t.Errorf("Applied = %t, want %t", result.Applied, tt.wantApplied)
+			}
+			if !strings.Contains(result.Rejected, tt.wantRejected) {
+				t.Errorf("Rejected = %q, want %q", result.Rejected, tt.wantRejected)
+			}
+			if edited := strings.Contains(string(got), "enable_classiclink"); edited == tt.wantApplied {
+				t.Errorf("file after migrate():\n%s", got)
+			}
+			if !strings.Contains(tt.migrator.req.ModuleCall, `version = "5.0.0"`) {
+				t.Errorf("ModuleCall = %q, want the updated module block", tt.migrator.req.ModuleCall)
+			}
+
+			var body strings.Builder
+			renderMigration(&body, result, "vpc")
+			for _, want := range []string{"### 🤖 AI-Proposed Migration", "`test-model`", "- 🤖 Remove `"} {
+				if !strings.Contains(body.String(), want) {
+					t.Errorf("renderMigration() missing %q:\n%s", want, body.String())
+				}
+			}
+			if tt.wantApplied && !strings.Contains(body.String(), "**proposed by an AI model**") {
+				t.Errorf("renderMigration() does not mark applied edits as AI-proposed:\n%s", body.String())
+			}
+			if !tt.wantApplied && !strings.Contains(body.String(), "**not applied**") {
+				t.Errorf("renderMigration() does not mark rejected edits:\n%s", body.String())
+			}
+		})
+	}
+}
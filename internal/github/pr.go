@@ -7,14 +7,20 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v66/github"
+	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/edit"
 	"github.com/heyjobs/terranovate/internal/terraform"
 	"github.com/heyjobs/terranovate/internal/version"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/oauth2"
 )
 
+// ManagedLabel is applied to every Terranovate PR so open PRs can be counted
+const ManagedLabel = "terranovate"
+
 // PRCreator creates pull requests for module updates
 type PRCreator struct {
 	client     *github.Client
@@ -24,6 +30,21 @@ type PRCreator struct {
 	labels     []string
 	reviewers  []string
 	workingDir string
+	autoMerge  *AutoMergePolicy
+	planner    Planner
+	migrator   Migrator
+	validator  Validator
+
+	// CODEOWNERS rules, loaded on first use
+	codeowners       []CodeownersRule
+	codeownersRoot   string
+	codeownersLoaded bool
+}
+
+// Planner validates the edited branch, returning one plan per affected root module.
+// name identifies the update, e.g. in saved plan artifacts.
+type Planner interface {
+	PlanRoots(ctx context.Context, name string, edits []terraform.FileEdit) []terraform.RootPlan
 }
 
 // NewPRCreator creates a new PR creator instance
@@ -36,13 +57,8 @@ func NewPRCreator(token, owner, repo, baseBranch, workingDir string, labels, rev
 		return nil, fmt.Errorf("owner and repo are required")
 	}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(context.Background(), ts)
-
 	return &PRCreator{
-		client:     github.NewClient(tc),
+		client:     newClient(token),
 		owner:      owner,
 		repo:       repo,
 		baseBranch: baseBranch,
@@ -52,12 +68,118 @@ func NewPRCreator(token, owner, repo, baseBranch, workingDir string, labels, rev
 	}, nil
 }
 
-// CreatePR creates a pull request for a module update
-func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, planResult *terraform.PlanResult) (*github.PullRequest, error) {
-	// Create branch name
-	branchName := fmt.Sprintf("terranovate/%s-%s",
+// SetAutoMergePolicy enables auto-merge for PRs that satisfy the given policy
+func (p *PRCreator) SetAutoMergePolicy(policy *AutoMergePolicy) {
+	p.autoMerge = policy
+}
+
+// SetPlanner enables plan validation of the edited branch before each PR is opened
+func (p *PRCreator) SetPlanner(planner Planner) {
+	p.planner = planner
+}
+
+// planRoots plans the root modules affected by an edited file on a branch, nil without
+// a planner. original is the file content on the base branch, used for the baseline plan.
+func (p *PRCreator) planRoots(ctx context.Context, branch, editedFile string, original []byte) []terraform.RootPlan {
+	if p.planner == nil {
+		return nil
+	}
+	return p.planner.PlanRoots(ctx, branch, []terraform.FileEdit{{Path: p.resolvePath(editedFile), Original: original}})
+}
+
+// autoMergeDecision evaluates the auto-merge policy for a module update, nil when auto-merge is off
+func (p *PRCreator) autoMergeDecision(update version.UpdateInfo, planResult *terraform.PlanResult, rootPlans []terraform.RootPlan) *AutoMergeDecision {
+	if p.autoMerge == nil {
+		return nil
+	}
+	decision := p.autoMerge.Evaluate(update, planResult, terraform.UpgradeDiff(rootPlans))
+	return &decision
+}
+
+// providerAutoMergeDecision evaluates the auto-merge policy for a provider update, nil when auto-merge is off
+func (p *PRCreator) providerAutoMergeDecision(update version.ProviderUpdateInfo, planResult *terraform.PlanResult, rootPlans []terraform.RootPlan) *AutoMergeDecision {
+	if p.autoMerge == nil {
+		return nil
+	}
+	decision := p.autoMerge.EvaluateProvider(update, planResult, terraform.UpgradeDiff(rootPlans))
+	return &decision
+}
+
+// PRStats counts open Terranovate PRs and the Terranovate PRs created since a given time
+func (p *PRCreator) PRStats(ctx context.Context, since time.Time) (open int, createdSince int, err error) {
+	err = p.listManagedPRs(ctx, &github.IssueListByRepoOptions{State: "open"}, func(issue *github.Issue) {
+		open++
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Since filters on update time, which is never before creation time
+	err = p.listManagedPRs(ctx, &github.IssueListByRepoOptions{State: "all", Since: since}, func(issue *github.Issue) {
+		if issue.GetCreatedAt().After(since) {
+			createdSince++
+		}
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return open, createdSince, nil
+}
+
+// listManagedPRs calls fn for every pull request carrying the Terranovate label
+func (p *PRCreator) listManagedPRs(ctx context.Context, opts *github.IssueListByRepoOptions, fn func(*github.Issue)) error {
+	opts.Labels = []string{ManagedLabel}
+	opts.ListOptions = github.ListOptions{PerPage: 100}
+
+	for {
+		issues, resp, err := p.client.Issues.ListByRepo(ctx, p.owner, p.repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list terranovate pull requests: %w", err)
+		}
+
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				fn(issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// newClient creates an authenticated GitHub API client
+func newClient(token string) *github.Client {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	tc := oauth2.NewClient(context.Background(), ts)
+
+	return github.NewClient(tc)
+}
+
+// ModuleBranchName returns the branch name used for a module update PR
+func ModuleBranchName(update version.UpdateInfo) string {
+	return fmt.Sprintf("terranovate/%s-%s",
 		sanitizeBranchName(update.Module.Name),
 		update.LatestVersion)
+}
+
+// ProviderBranchName returns the branch name used for a provider update PR
+func ProviderBranchName(update version.ProviderUpdateInfo) string {
+	return fmt.Sprintf("terranovate/provider-%s-%s",
+		sanitizeBranchName(update.Provider.Name),
+		update.LatestVersion)
+}
+
+// CreatePR creates a pull request for a module update. With a planner set, the root
+// modules affected by the edit are planned on the new branch and planResult is ignored.
+func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, planResult *terraform.PlanResult) (*github.PullRequest, error) {
+	// Create branch name
+	branchName := ModuleBranchName(update)
 
 	log.Info().
 		Str("branch", branchName).
@@ -69,6 +191,9 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 		return nil, fmt.Errorf("failed to create branch: %w", err)
 	}
 
+	// Keep the base branch content for the baseline plan
+	original, _ := os.ReadFile(p.resolvePath(update.Module.FilePath))
+
 	// Update module version in file
 	if err := p.updateModuleVersion(update); err != nil {
 		return nil, fmt.Errorf("failed to update module version: %w", err)
@@ -80,6 +205,22 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 		return nil, fmt.Errorf("failed to commit changes: %w", err)
 	}
 
+	// Commit AI-proposed edits of our module call separately, so they are easy to review or revert
+	update.AIMigration = p.migrate(ctx, update)
+	if update.AIMigration != nil && update.AIMigration.Applied {
+		commitMsg := fmt.Sprintf("Apply AI-proposed migration for %s %s", update.Module.Name, update.LatestVersion)
+		if err := p.commitChanges(commitMsg); err != nil {
+			return nil, fmt.Errorf("failed to commit AI migration: %w", err)
+		}
+	}
+
+	// Validate the edited branch in each affected root module
+	rootPlans := p.planRoots(ctx, branchName, update.Module.FilePath, original)
+	if len(rootPlans) > 0 {
+		planResult = terraform.CombinePlans(rootPlans)
+		terraform.AnnotateUpdate(&update, rootPlans)
+	}
+
 	// Push branch
 	if err := p.pushBranch(branchName); err != nil {
 		return nil, fmt.Errorf("failed to push branch: %w", err)
@@ -90,7 +231,7 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 	if update.HasBreakingChange {
 		title = fmt.Sprintf("⚠️ [BREAKING] Update Terraform module %s to %s", update.Module.Name, update.LatestVersion)
 	}
-	body := p.generatePRBody(update, planResult)
+	body := p.generatePRBody(update, planResult, rootPlans)
 
 	pr, _, err := p.client.PullRequests.Create(ctx, p.owner, p.repo, &github.NewPullRequest{
 		Title:               github.String(title),
@@ -107,6 +248,7 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 	// Prepare labels
 	labels := make([]string, len(p.labels))
 	copy(labels, p.labels)
+	labels = append(labels, ManagedLabel)
 
 	// Add breaking-change label if applicable
 	if update.HasBreakingChange {
@@ -118,6 +260,10 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 		labels = append(labels, string(update.UpdateType)+"-update")
 	}
 
+	if update.AIMigration != nil && update.AIMigration.Applied {
+		labels = append(labels, MigrationLabel)
+	}
+
 	// Add labels
 	if len(labels) > 0 {
 		if _, _, err := p.client.Issues.AddLabelsToIssue(ctx, p.owner, p.repo, pr.GetNumber(), labels); err != nil {
@@ -125,13 +271,13 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 		}
 	}
 
-	// Add reviewers if configured
-	if len(p.reviewers) > 0 {
-		reviewersReq := github.ReviewersRequest{
-			Reviewers: p.reviewers,
-		}
-		if _, _, err := p.client.PullRequests.RequestReviewers(ctx, p.owner, p.repo, pr.GetNumber(), reviewersReq); err != nil {
-			log.Warn().Err(err).Msg("failed to request reviewers")
+	// Request reviews from the owners of the edited file
+	p.requestReviewers(ctx, pr.GetNumber(), update.Module.FilePath)
+
+	// Enable auto-merge if the update qualifies
+	if decision := p.autoMergeDecision(update, planResult, rootPlans); decision != nil && decision.Eligible {
+		if err := p.enableAutoMerge(ctx, pr); err != nil {
+			log.Warn().Err(err).Msg("failed to enable auto-merge")
 		}
 	}
 
@@ -143,6 +289,82 @@ func (p *PRCreator) CreatePR(ctx context.Context, update version.UpdateInfo, pla
 	return pr, nil
 }
 
+// requestReviewers requests reviews from the CODEOWNERS of an edited file,
+// falling back to the configured reviewers when no owner matches
+func (p *PRCreator) requestReviewers(ctx context.Context, number int, editedFile string) {
+	reviewersReq := p.reviewersFor(editedFile)
+	if len(reviewersReq.Reviewers) == 0 && len(reviewersReq.TeamReviewers) == 0 {
+		return
+	}
+
+	if _, _, err := p.client.PullRequests.RequestReviewers(ctx, p.owner, p.repo, number, reviewersReq); err != nil {
+		log.Warn().Err(err).Msg("failed to request reviewers")
+	}
+}
+
+// reviewersFor resolves the review request for an edited file
+func (p *PRCreator) reviewersFor(editedFile string) github.ReviewersRequest {
+	p.loadCodeowners()
+
+	if len(p.codeowners) > 0 {
+		path := p.resolvePath(editedFile)
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(p.codeownersRoot, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+
+		users, teams := SplitOwners(CodeownersFor(p.codeowners, path))
+		if len(users) > 0 || len(teams) > 0 {
+			log.Debug().
+				Str("file", path).
+				Strs("users", users).
+				Strs("teams", teams).
+				Msg("resolved reviewers from CODEOWNERS")
+			return github.ReviewersRequest{Reviewers: users, TeamReviewers: teams}
+		}
+	}
+
+	return github.ReviewersRequest{Reviewers: p.reviewers}
+}
+
+// loadCodeowners reads the CODEOWNERS file of the repository containing the working directory
+func (p *PRCreator) loadCodeowners() {
+	if p.codeownersLoaded {
+		return
+	}
+	p.codeownersLoaded = true
+
+	root := p.workingDir
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = p.workingDir
+	if out, err := cmd.Output(); err == nil {
+		root = strings.TrimSpace(string(out))
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	rules, err := LoadCodeowners(root)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load CODEOWNERS")
+		return
+	}
+
+	p.codeowners = rules
+	p.codeownersRoot = root
+}
+
+// resolvePath makes a scanned file path absolute. The scanner walks the scan path as
+// given, so relative file paths already include it and are relative to the current
+// directory, never to the working directory.
+func (p *PRCreator) resolvePath(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		return abs
+	}
+	return filePath
+}
+
 // createBranch creates and checks out a new git branch
 func (p *PRCreator) createBranch(branchName string) error {
 	// Fetch latest changes
@@ -170,54 +392,8 @@ func (p *PRCreator) createBranch(branchName string) error {
 
 // updateModuleVersion updates the module version in the Terraform file
 func (p *PRCreator) updateModuleVersion(update version.UpdateInfo) error {
-	filePath := update.Module.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(p.workingDir, filePath)
-	}
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	// Replace version in the module block
-	oldContent := string(content)
-	newContent := oldContent
-
-	// Handle different version specification formats
-	if update.CurrentVersion != "" {
-		// Replace version = "old" with version = "new"
-		oldVersion := fmt.Sprintf(`version = "%s"`, update.CurrentVersion)
-		newVersion := fmt.Sprintf(`version = "%s"`, update.LatestVersion)
-		newContent = strings.Replace(newContent, oldVersion, newVersion, 1)
-
-		// Also try single quotes
-		oldVersion = fmt.Sprintf(`version = '%s'`, update.CurrentVersion)
-		newVersion = fmt.Sprintf(`version = '%s'`, update.LatestVersion)
-		newContent = strings.Replace(newContent, oldVersion, newVersion, 1)
-	} else {
-		// Add version attribute if it doesn't exist
-		// Find the module block and add version
-		moduleLine := fmt.Sprintf(`module "%s"`, update.Module.Name)
-		if strings.Contains(newContent, moduleLine) {
-			// Add version after module declaration
-			replacement := fmt.Sprintf("%s {\n  version = \"%s\"\n", moduleLine, update.LatestVersion)
-			newContent = strings.Replace(newContent, moduleLine+" {", replacement, 1)
-		}
-	}
-
-	// For git sources, update the ref parameter
-	if update.Module.SourceType == "git" && update.CurrentVersion != "" {
-		oldRef := fmt.Sprintf("ref=%s", update.CurrentVersion)
-		newRef := fmt.Sprintf("ref=v%s", update.LatestVersion)
-		newContent = strings.Replace(newContent, oldRef, newRef, 1)
-	}
-
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	update.Module.FilePath = p.resolvePath(update.Module.FilePath)
+	return edit.UpdateModule(update)
 }
 
 // commitChanges commits the changes to git
@@ -256,7 +432,7 @@ func (p *PRCreator) runGitCommand(args ...string) error {
 }
 
 // generatePRBody generates the pull request body
-func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraform.PlanResult) string {
+func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraform.PlanResult, rootPlans []terraform.RootPlan) string {
 	var body strings.Builder
 
 	body.WriteString("## Terraform Module Update\n\n")
@@ -299,12 +475,23 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 		body.WriteString(fmt.Sprintf("📋 [View Changelog](%s)\n\n", update.ChangelogURL))
 	}
 
+	renderReleaseNotes(&body, update.ReleaseNotes)
+
 	// Add schema change details if available
 	if update.SchemaChanges != nil {
 		// Type assert to terraform.SchemaChanges
 		if schemaChanges, ok := update.SchemaChanges.(*terraform.SchemaChanges); ok && schemaChanges.HasChanges {
 			body.WriteString("### 📋 API/Schema Changes Detected\n\n")
 
+			if len(schemaChanges.CallSiteIssues) > 0 {
+				body.WriteString(fmt.Sprintf("#### 💥 Breaking Call Sites (%d)\n\n", len(schemaChanges.CallSiteIssues)))
+				body.WriteString("Our configuration breaks against the new version here:\n\n")
+				for _, issue := range schemaChanges.CallSiteIssues {
+					body.WriteString(fmt.Sprintf("- `%s:%d`: %s\n", issue.File, issue.Line, issue.Message))
+				}
+				body.WriteString("\n")
+			}
+
 			if len(schemaChanges.AddedRequiredVars) > 0 {
 				body.WriteString(fmt.Sprintf("#### ⚠️ New Required Variables (%d)\n\n", len(schemaChanges.AddedRequiredVars)))
 				body.WriteString("The following required variables have been added and must be provided:\n\n")
@@ -341,9 +528,34 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 				}
 				body.WriteString("\n")
 			}
+
+			if len(schemaChanges.ChangedDefaults) > 0 {
+				body.WriteString(fmt.Sprintf("#### 🔀 Changed Defaults (%d)\n\n", len(schemaChanges.ChangedDefaults)))
+				body.WriteString("Calls that do not set these variables silently get the new value:\n\n")
+				for _, d := range schemaChanges.ChangedDefaults {
+					body.WriteString(fmt.Sprintf("- `%s`: `%s` → `%s`", d.Name, d.OldDefault, d.NewDefault))
+					if d.AffectsCall {
+						body.WriteString(fmt.Sprintf(" — ⚠️ **affects our call** in `%s:%d`, which relies on the default", d.File, d.Line))
+					}
+					body.WriteString("\n")
+				}
+				body.WriteString("\n")
+			}
+
+			if len(schemaChanges.ChangedAttributes) > 0 {
+				body.WriteString(fmt.Sprintf("#### 🔒 Changed Variable Attributes (%d)\n\n", len(schemaChanges.ChangedAttributes)))
+				for _, a := range schemaChanges.ChangedAttributes {
+					body.WriteString(fmt.Sprintf("- `%s`: %s `%t` → `%t`\n", a.Name, a.Attribute, a.Old, a.New))
+				}
+				body.WriteString("\n")
+			}
 		}
 	}
 
+	renderAIAnalysis(&body, update.AIAnalysis)
+
+	renderMigration(&body, update.AIMigration, update.Module.Name)
+
 	// Add resource change details if available
 	if update.ResourceChanges != nil && update.ResourceChanges.HasChanges {
 		body.WriteString("### 🔍 Resource Changes Detected\n\n")
@@ -352,10 +564,13 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 			body.WriteString(fmt.Sprintf("#### ⚠️ Resources to be REPLACED (%d)\n\n", update.ResourceChanges.TotalReplace))
 			body.WriteString("The following resources will be destroyed and recreated:\n\n")
 			for _, rc := range update.ResourceChanges.ResourcesToReplace {
-				body.WriteString(fmt.Sprintf("- `%s` (%s)\n", rc.Address, rc.ResourceType))
+				body.WriteString(fmt.Sprintf("- `%s` (%s)%s\n", rc.Address, rc.ResourceType, resourceMarkers(rc)))
 				if rc.Reason != "" {
 					body.WriteString(fmt.Sprintf("  - Reason: %s\n", rc.Reason))
 				}
+				for _, trigger := range rc.Triggers {
+					body.WriteString(fmt.Sprintf("  - `%s`: `%s` → `%s` (forces replacement)\n", trigger.Path, trigger.Before, trigger.After))
+				}
 			}
 			body.WriteString("\n")
 		}
@@ -363,7 +578,10 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 		if update.ResourceChanges.TotalDelete > 0 {
 			body.WriteString(fmt.Sprintf("#### 🗑️ Resources to be DELETED (%d)\n\n", update.ResourceChanges.TotalDelete))
 			for _, rc := range update.ResourceChanges.ResourcesToDelete {
-				body.WriteString(fmt.Sprintf("- `%s` (%s)\n", rc.Address, rc.ResourceType))
+				body.WriteString(fmt.Sprintf("- `%s` (%s)%s\n", rc.Address, rc.ResourceType, resourceMarkers(rc)))
+				if rc.ActionReason != "" {
+					body.WriteString(fmt.Sprintf("  - Reason: %s\n", terraform.DescribeActionReason(rc.ActionReason)))
+				}
 			}
 			body.WriteString("\n")
 		}
@@ -371,7 +589,24 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 		if update.ResourceChanges.TotalModify > 0 {
 			body.WriteString(fmt.Sprintf("#### 📝 Resources to be MODIFIED (%d)\n\n", update.ResourceChanges.TotalModify))
 			body.WriteString("Some resource attributes will be updated in-place.\n\n")
+			for _, rc := range update.ResourceChanges.ResourcesToModify {
+				if len(rc.Attributes) > 0 {
+					body.WriteString(fmt.Sprintf("- `%s`%s: `%s`\n", rc.Address, resourceMarkers(rc), strings.Join(rc.Attributes, "`, `")))
+				}
+			}
+			body.WriteString("\n")
+		}
+	}
+
+	// Changes already planned on the base branch are not caused by this update
+	if update.ResourceChanges != nil && len(update.ResourceChanges.Drift) > 0 {
+		body.WriteString("<details>\n")
+		body.WriteString(fmt.Sprintf("<summary>Pre-existing drift (%d resource(s), also planned on the base branch)</summary>\n\n",
+			len(update.ResourceChanges.Drift)))
+		for _, rc := range update.ResourceChanges.Drift {
+			body.WriteString(fmt.Sprintf("- `%s` (%s)\n", rc.Address, rc.Action))
 		}
+		body.WriteString("\n</details>\n\n")
 	}
 
 	// Add specific guidance for breaking changes
@@ -389,37 +624,137 @@ func (p *PRCreator) generatePRBody(update version.UpdateInfo, planResult *terraf
 		body.WriteString("\n")
 	}
 
+	renderPlanResults(&body, planResult, rootPlans)
+
+	renderAutoMergeDecision(&body, p.autoMergeDecision(update, planResult, rootPlans))
+
+	body.WriteString("---\n")
+	body.WriteString("🤖 *This PR was automatically created by [Terranovate](https://github.com/heyjobs/terranovate)*\n")
+
+	return body.String()
+}
+
+// resourceMarkers labels the workspace of a resource and flags moved and imported
+// resources in a resource list
+func resourceMarkers(rc version.ResourceChange) string {
+	var markers string
+	if rc.Workspace != "" {
+		markers += fmt.Sprintf(" — workspace `%s`", rc.Workspace)
+	}
+	if rc.MovedFrom != "" {
+		markers += fmt.Sprintf(" — moved from `%s`", rc.MovedFrom)
+	}
+	if rc.Imported {
+		markers += " — imported"
+	}
+	return markers
+}
+
+// renderPlanResults writes the plan section of a PR body, one subsection per root module
+func renderPlanResults(body *strings.Builder, planResult *terraform.PlanResult, rootPlans []terraform.RootPlan) {
+	if len(rootPlans) == 0 {
+		if planResult != nil {
+			body.WriteString("### Terraform Plan Results\n\n")
+			renderPlanResult(body, planResult)
+		}
+		return
+	}
+
 	if planResult != nil {
 		body.WriteString("### Terraform Plan Results\n\n")
-		if planResult.Success {
-			body.WriteString("✅ Plan succeeded\n\n")
-			body.WriteString(fmt.Sprintf("```\n%s\n```\n\n", planResult.Output))
-
-			if planResult.HasChanges {
-				body.WriteString("⚠️ **This update will make infrastructure changes.**\n\n")
-				body.WriteString("Please review the plan carefully before merging.\n\n")
-			} else {
-				body.WriteString("✨ No infrastructure changes detected.\n\n")
-			}
+		body.WriteString(fmt.Sprintf("Planned %d affected root module(s) on this branch.\n\n", len(rootPlans)))
+	} else {
+		body.WriteString("### Terraform Validation Results\n\n")
+		body.WriteString(fmt.Sprintf("Validated %d affected root module(s) on this branch without a full plan.\n\n", len(rootPlans)))
+	}
+	for _, rootPlan := range rootPlans {
+		body.WriteString(fmt.Sprintf("#### `%s`\n\n", rootPlan.Dir))
+		renderValidationResult(body, rootPlan)
+		if rootPlan.Result != nil {
+			renderPlanResult(body, rootPlan.Result)
+		}
+		if diff := rootPlan.Diff; diff != nil {
+			body.WriteString(fmt.Sprintf("Compared with the base branch, this update adds %d replacement(s), %d deletion(s), %d creation(s) and %d in-place update(s); %d change(s) were already pending.\n\n",
+				len(diff.Replacements), len(diff.Deletions), len(diff.Creations), len(diff.Updates), len(diff.Drift)))
+		}
+	}
+}
+
+// renderValidationResult writes the validation outcome of a root module. A failure that
+// also failed the plan is left to the plan result.
+func renderValidationResult(body *strings.Builder, rootPlan terraform.RootPlan) {
+	validation := rootPlan.Validation
+	if validation == nil {
+		return
+	}
+
+	if validation.Success {
+		body.WriteString(fmt.Sprintf("✅ Validation: %s\n\n", validation.Summary()))
+		return
+	}
+	if rootPlan.Result != nil {
+		return
+	}
+
+	body.WriteString(fmt.Sprintf("❌ Validation: %s\n\n", validation.Summary()))
+	body.WriteString(fmt.Sprintf("```\n%s\n```\n\n", strings.Join(validation.Errors, "\n")))
+	body.WriteString("⚠️ **Please review and fix the errors before merging.**\n\n")
+}
+
+// renderPlanResult writes the outcome of a single plan
+func renderPlanResult(body *strings.Builder, planResult *terraform.PlanResult) {
+	if planResult.Success {
+		body.WriteString("✅ Plan succeeded\n\n")
+		body.WriteString(fmt.Sprintf("```\n%s\n```\n\n", planResult.Output))
+
+		if planResult.HasChanges {
+			body.WriteString("⚠️ **This update will make infrastructure changes.**\n\n")
+			body.WriteString("Please review the plan carefully before merging.\n\n")
+			renderPlanText(body, planResult)
 		} else {
-			body.WriteString("❌ Plan failed\n\n")
-			body.WriteString(fmt.Sprintf("```\n%s\n```\n\n", planResult.ErrorMessage))
-			body.WriteString("⚠️ **Please review and fix the errors before merging.**\n\n")
+			body.WriteString("✨ No infrastructure changes detected.\n\n")
 		}
+	} else {
+		body.WriteString("❌ Plan failed\n\n")
+		body.WriteString(fmt.Sprintf("```\n%s\n```\n\n", planResult.ErrorMessage))
+		body.WriteString("⚠️ **Please review and fix the errors before merging.**\n\n")
 	}
+}
 
-	body.WriteString("---\n")
-	body.WriteString("🤖 *This PR was automatically created by [Terranovate](https://github.com/heyjobs/terranovate)*\n")
+// maxPlanTextLength caps the plan text in a PR body, which GitHub limits to 65536 characters
+const maxPlanTextLength = 20000
 
-	return body.String()
+// renderPlanText writes the human-readable plan in a collapsed section, truncated to
+// maxPlanTextLength
+func renderPlanText(body *strings.Builder, planResult *terraform.PlanResult) {
+	text := strings.TrimSpace(planResult.Text)
+	if text == "" {
+		return
+	}
+
+	truncated := ""
+	if len(text) > maxPlanTextLength {
+		cut := strings.LastIndex(text[:maxPlanTextLength], "\n")
+		if cut <= 0 {
+			cut = maxPlanTextLength
+		}
+		truncated = fmt.Sprintf("\n\n_Truncated, %d more characters._\n", len(text)-cut)
+		if planResult.Artifacts != nil {
+			truncated = fmt.Sprintf("\n\n_Truncated, %d more characters. The full plan is in the plan artifacts._\n", len(text)-cut)
+		}
+		text = text[:cut]
+	}
+
+	body.WriteString("<details>\n<summary>Full plan output</summary>\n\n")
+	body.WriteString(fmt.Sprintf("```\n%s\n```", text))
+	body.WriteString(truncated)
+	body.WriteString("\n</details>\n\n")
 }
 
 // CreateProviderPR creates a pull request for a provider update
 func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.ProviderUpdateInfo) (*github.PullRequest, error) {
 	// Create branch name
-	branchName := fmt.Sprintf("terranovate/provider-%s-%s",
-		sanitizeBranchName(update.Provider.Name),
-		update.LatestVersion)
+	branchName := ProviderBranchName(update)
 
 	log.Info().
 		Str("branch", branchName).
@@ -431,6 +766,9 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 		return nil, fmt.Errorf("failed to create branch: %w", err)
 	}
 
+	// Keep the base branch content for the baseline plan
+	original, _ := os.ReadFile(p.resolvePath(update.Provider.FilePath))
+
 	// Update provider version in file
 	if err := p.updateProviderVersion(update); err != nil {
 		return nil, fmt.Errorf("failed to update provider version: %w", err)
@@ -442,6 +780,10 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 		return nil, fmt.Errorf("failed to commit changes: %w", err)
 	}
 
+	// Validate the edited branch in each affected root module
+	rootPlans := p.planRoots(ctx, branchName, update.Provider.FilePath, original)
+	planResult := terraform.CombinePlans(rootPlans)
+
 	// Push branch
 	if err := p.pushBranch(branchName); err != nil {
 		return nil, fmt.Errorf("failed to push branch: %w", err)
@@ -452,7 +794,7 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 	if update.HasBreakingChange {
 		title = fmt.Sprintf("⚠️ [BREAKING] Update Terraform provider %s to %s", update.Provider.Name, update.LatestVersion)
 	}
-	body := p.generateProviderPRBody(update)
+	body := p.generateProviderPRBody(update, rootPlans)
 
 	pr, _, err := p.client.PullRequests.Create(ctx, p.owner, p.repo, &github.NewPullRequest{
 		Title:               github.String(title),
@@ -469,6 +811,7 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 	// Prepare labels
 	labels := make([]string, len(p.labels))
 	copy(labels, p.labels)
+	labels = append(labels, ManagedLabel)
 	labels = append(labels, "provider")
 
 	// Add breaking-change label if applicable
@@ -488,13 +831,13 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 		}
 	}
 
-	// Add reviewers if configured
-	if len(p.reviewers) > 0 {
-		reviewersReq := github.ReviewersRequest{
-			Reviewers: p.reviewers,
-		}
-		if _, _, err := p.client.PullRequests.RequestReviewers(ctx, p.owner, p.repo, pr.GetNumber(), reviewersReq); err != nil {
-			log.Warn().Err(err).Msg("failed to request reviewers")
+	// Request reviews from the owners of the edited file
+	p.requestReviewers(ctx, pr.GetNumber(), update.Provider.FilePath)
+
+	// Enable auto-merge if the update qualifies
+	if decision := p.providerAutoMergeDecision(update, planResult, rootPlans); decision != nil && decision.Eligible {
+		if err := p.enableAutoMerge(ctx, pr); err != nil {
+			log.Warn().Err(err).Msg("failed to enable auto-merge")
 		}
 	}
 
@@ -508,140 +851,12 @@ func (p *PRCreator) CreateProviderPR(ctx context.Context, update version.Provide
 
 // updateProviderVersion updates the provider version in the Terraform file
 func (p *PRCreator) updateProviderVersion(update version.ProviderUpdateInfo) error {
-	filePath := update.Provider.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(p.workingDir, filePath)
-	}
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-
-	// Find the required_providers block and update the version
-	inTerraformBlock := false
-	inRequiredProvidersBlock := false
-	providerIndent := ""
-	updated := false
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Track terraform block
-		if strings.HasPrefix(trimmed, "terraform") && strings.Contains(trimmed, "{") {
-			inTerraformBlock = true
-			continue
-		}
-
-		// Track required_providers block
-		if inTerraformBlock && strings.HasPrefix(trimmed, "required_providers") && strings.Contains(trimmed, "{") {
-			inRequiredProvidersBlock = true
-			continue
-		}
-
-		// Check if we're at the provider line
-		if inRequiredProvidersBlock && strings.Contains(line, update.Provider.Name) {
-			// Detect indentation
-			for _, c := range line {
-				if c == ' ' || c == '\t' {
-					providerIndent += string(c)
-				} else {
-					break
-				}
-			}
-
-			// Look for version specification in this line or next lines
-			if strings.Contains(line, "version") {
-				// Version is on the same line
-				lines[i] = p.replaceProviderVersionInLine(line, update.CurrentVersion, update.LatestVersion)
-				updated = true
-				break
-			} else {
-				// Look ahead for version in subsequent lines
-				for j := i + 1; j < len(lines) && j < i+10; j++ {
-					if strings.Contains(lines[j], "version") {
-						lines[j] = p.replaceProviderVersionInLine(lines[j], update.CurrentVersion, update.LatestVersion)
-						updated = true
-						break
-					}
-					// Stop if we've exited the provider block
-					if strings.TrimSpace(lines[j]) == "}" {
-						break
-					}
-				}
-				if updated {
-					break
-				}
-			}
-		}
-
-		// Track block exits
-		if trimmed == "}" {
-			if inRequiredProvidersBlock {
-				inRequiredProvidersBlock = false
-			} else if inTerraformBlock {
-				inTerraformBlock = false
-			}
-		}
-	}
-
-	if !updated {
-		return fmt.Errorf("could not find provider version to update in file %s", filePath)
-	}
-
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
-}
-
-// replaceProviderVersionInLine replaces the version in a provider line
-func (p *PRCreator) replaceProviderVersionInLine(line, currentVersion, latestVersion string) string {
-	// Extract current version from line (handles different formats)
-	// Examples:
-	//   version = "~> 5.0"
-	//   version = ">= 5.0.0"
-	//   version = "5.0.0"
-
-	// Find the version value in quotes
-	versionStart := strings.Index(line, "\"")
-	if versionStart == -1 {
-		return line
-	}
-
-	versionEnd := strings.Index(line[versionStart+1:], "\"")
-	if versionEnd == -1 {
-		return line
-	}
-
-	oldValue := line[versionStart+1 : versionStart+1+versionEnd]
-
-	// Determine the constraint operator to preserve
-	constraint := ""
-	if strings.HasPrefix(oldValue, "~>") {
-		constraint = "~> "
-	} else if strings.HasPrefix(oldValue, ">=") {
-		constraint = ">= "
-	} else if strings.HasPrefix(oldValue, "<=") {
-		constraint = "<= "
-	} else if strings.HasPrefix(oldValue, "=") {
-		constraint = "= "
-	} else if strings.HasPrefix(oldValue, ">") {
-		constraint = "> "
-	} else if strings.HasPrefix(oldValue, "<") {
-		constraint = "< "
-	}
-
-	newValue := constraint + latestVersion
-	return strings.Replace(line, "\""+oldValue+"\"", "\""+newValue+"\"", 1)
+	update.Provider.FilePath = p.resolvePath(update.Provider.FilePath)
+	return edit.UpdateProvider(update)
 }
 
 // generateProviderPRBody generates the PR body for a provider update
-func (p *PRCreator) generateProviderPRBody(update version.ProviderUpdateInfo) string {
+func (p *PRCreator) generateProviderPRBody(update version.ProviderUpdateInfo, rootPlans []terraform.RootPlan) string {
 	var body strings.Builder
 
 	// Add breaking change warning banner if applicable
@@ -681,6 +896,14 @@ func (p *PRCreator) generateProviderPRBody(update version.ProviderUpdateInfo) st
 		body.WriteString(fmt.Sprintf("📖 [View provider documentation](%s)\n\n", update.ChangelogURL))
 	}
 
+	renderReleaseNotes(&body, update.ReleaseNotes)
+
+	if schemaChanges, ok := update.SchemaChanges.(*terraform.ProviderSchemaChanges); ok && schemaChanges.HasChanges {
+		renderProviderSchemaChanges(&body, schemaChanges)
+	}
+
+	renderAIAnalysis(&body, update.AIAnalysis)
+
 	// Add review checklist
 	body.WriteString("### Review Checklist\n\n")
 
@@ -701,12 +924,134 @@ func (p *PRCreator) generateProviderPRBody(update version.ProviderUpdateInfo) st
 	}
 
 	body.WriteString("\n")
+
+	planResult := terraform.CombinePlans(rootPlans)
+	renderPlanResults(&body, planResult, rootPlans)
+
+	renderAutoMergeDecision(&body, p.providerAutoMergeDecision(update, planResult, rootPlans))
+
 	body.WriteString("---\n")
 	body.WriteString("🤖 *This PR was automatically created by [Terranovate](https://github.com/heyjobs/terranovate)*\n")
 
 	return body.String()
 }
 
+// renderReleaseNotes writes the release notes of the versions an update moves across
+// as a collapsed section
+func renderReleaseNotes(body *strings.Builder, notes *version.ReleaseNotes) {
+	if notes == nil || len(notes.Versions) == 0 {
+		return
+	}
+
+	versions := "1 version"
+	if len(notes.Versions) != 1 {
+		versions = fmt.Sprintf("%d versions", len(notes.Versions))
+	}
+	body.WriteString("<details>\n")
+	body.WriteString(fmt.Sprintf("<summary>📝 Release notes (%s, from %s %s)</summary>\n\n", versions, notes.Repository, notes.Source))
+
+	for _, v := range notes.Versions {
+		if v.URL != "" {
+			body.WriteString(fmt.Sprintf("#### [%s](%s)\n\n", v.Version, v.URL))
+		} else {
+			body.WriteString(fmt.Sprintf("#### %s\n\n", v.Version))
+		}
+		body.WriteString(strings.TrimSpace(v.Body))
+		body.WriteString("\n\n")
+	}
+	if notes.Truncated {
+		body.WriteString("_Release notes truncated to fit the PR body._\n\n")
+	}
+	body.WriteString("</details>\n\n")
+}
+
+// renderAIAnalysis writes the AI analysis of an update with the release note lines and
+// schema changes each detail cites
+func renderAIAnalysis(body *strings.Builder, analysis *ai.AIAnalysis) {
+	if analysis == nil {
+		return
+	}
+
+	verdict := "no breaking changes found"
+	if analysis.HasBreakingChanges {
+		verdict = "breaking changes found"
+	}
+	body.WriteString("### 🤖 AI Analysis\n\n")
+	body.WriteString(fmt.Sprintf("**%s** (%s confidence, rated by how well the cited evidence covers the announced breaking changes)\n\n",
+		verdict, analysis.Confidence))
+	body.WriteString(analysis.Summary + "\n\n")
+
+	for _, detail := range analysis.Details {
+		body.WriteString(fmt.Sprintf("- %s\n", detail))
+	}
+	if len(analysis.Details) > 0 {
+		body.WriteString("\n")
+	}
+}
+
+// renderProviderSchemaChanges writes the schema changes of the resource and data source
+// types the repository uses
+func renderProviderSchemaChanges(body *strings.Builder, changes *terraform.ProviderSchemaChanges) {
+	body.WriteString("### 📋 Provider Schema Changes\n\n")
+	body.WriteString("Changes to the schemas of the resource and data source types used in this repository:\n\n")
+
+	if removed := len(changes.RemovedResources) + len(changes.RemovedDataSources); removed > 0 {
+		body.WriteString(fmt.Sprintf("#### ❌ Removed Types (%d)\n\n", removed))
+		for _, t := range changes.RemovedResources {
+			body.WriteString(fmt.Sprintf("- resource `%s`\n", t))
+		}
+		for _, t := range changes.RemovedDataSources {
+			body.WriteString(fmt.Sprintf("- data source `%s`\n", t))
+		}
+		body.WriteString("\n")
+	}
+
+	if len(changes.RemovedAttributes) > 0 {
+		body.WriteString(fmt.Sprintf("#### ❌ Removed Attributes (%d)\n\n", len(changes.RemovedAttributes)))
+		for _, c := range changes.RemovedAttributes {
+			line := fmt.Sprintf("- `%s`", c)
+			if c.RenamedTo != "" {
+				line += fmt.Sprintf(" (probably renamed to `%s`)", c.RenamedTo)
+			}
+			body.WriteString(line + attributeUsages(c.Usages, "set by", "not set here") + "\n")
+		}
+		body.WriteString("\n")
+	}
+
+	if len(changes.NewRequired) > 0 {
+		body.WriteString(fmt.Sprintf("#### ⚠️ Newly Required (%d)\n\n", len(changes.NewRequired)))
+		for _, c := range changes.NewRequired {
+			body.WriteString(fmt.Sprintf("- `%s`%s\n", c, attributeUsages(c.Usages, "not set by", "already set everywhere")))
+		}
+		body.WriteString("\n")
+	}
+
+	if len(changes.DeprecatedAttributes) > 0 {
+		body.WriteString(fmt.Sprintf("#### 🕰️ Newly Deprecated (%d)\n\n", len(changes.DeprecatedAttributes)))
+		for _, c := range changes.DeprecatedAttributes {
+			body.WriteString(fmt.Sprintf("- `%s`%s\n", c, attributeUsages(c.Usages, "set by", "not set here")))
+		}
+		body.WriteString("\n")
+	}
+
+	// The schema JSON does not say which attributes force replacement
+	body.WriteString("_Attributes that now force replacement are not part of the provider schema; check the plan results for replacements._\n\n")
+}
+
+// attributeUsages renders the resource blocks a schema change affects as
+// " — ⚠️ set by `aws_s3_bucket.logs` in `main.tf:12`, ...", or marks the change as
+// informational, e.g. " (informational: not set here)", when it affects none
+func attributeUsages(usages []terraform.AttributeUsage, verb, unaffected string) string {
+	if len(usages) == 0 {
+		return fmt.Sprintf(" (informational: %s)", unaffected)
+	}
+	var parts []string
+	for _, u := range usages {
+		parts = append(parts, fmt.Sprintf("`%s` in `%s:%d`", u.Address, u.File, u.Line))
+	}
+	return fmt.Sprintf(" — ⚠️ %s %s", verb, strings.Join(parts, ", "))
+}
+
 // sanitizeBranchName sanitizes a string to be used as a git branch name
 func sanitizeBranchName(name string) string {
 	// Replace invalid characters with hyphens
@@ -1,9 +1,12 @@
 package github
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/heyjobs/terranovate/internal/ai"
 	"github.com/heyjobs/terranovate/internal/scanner"
 	"github.com/heyjobs/terranovate/internal/terraform"
 	"github.com/heyjobs/terranovate/internal/version"
@@ -286,11 +289,36 @@ func TestGeneratePRBody(t *testing.T) {
 				"cidr_block change forces replacement",
 			},
 		},
+		{
+			name: "with release notes",
+			update: version.UpdateInfo{
+				Module: scanner.ModuleInfo{
+					Name: "vpc",
+				},
+				CurrentVersion: "4.0.0",
+				LatestVersion:  "5.1.0",
+				ReleaseNotes: &version.ReleaseNotes{
+					Repository: "terraform-aws-modules/terraform-aws-vpc",
+					Source:     "releases",
+					Versions: []version.VersionNotes{
+						{Version: "5.1.0", URL: "https://github.com/terraform-aws-modules/terraform-aws-vpc/releases/tag/v5.1.0", Body: "- Add ipv6 support"},
+						{Version: "5.0.0", Body: "- Remove enable_classiclink\n"},
+					},
+					Truncated: true,
+				},
+			},
+			wantContains: []string{
+				"<summary>📝 Release notes (2 versions, from terraform-aws-modules/terraform-aws-vpc releases)</summary>",
+				"#### [5.1.0](https://github.com/terraform-aws-modules/terraform-aws-vpc/releases/tag/v5.1.0)\n\n- Add ipv6 support",
+				"#### 5.0.0\n\n- Remove enable_classiclink\n\n",
+				"_Release notes truncated to fit the PR body._",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body := creator.generatePRBody(tt.update, tt.planResult)
+			body := creator.generatePRBody(tt.update, tt.planResult, nil)
 
 			for _, want := range tt.wantContains {
 				if !strings.Contains(body, want) {
@@ -370,11 +398,71 @@ func TestGenerateProviderPRBody(t *testing.T) {
 				"🟡 Minor",
 			},
 		},
+		{
+			name: "provider update with schema changes",
+			update: version.ProviderUpdateInfo{
+				Provider: scanner.ProviderInfo{
+					Name:   "aws",
+					Source: "hashicorp/aws",
+				},
+				CurrentVersion: "4.67.0",
+				LatestVersion:  "5.0.0",
+				SchemaChanges: &terraform.ProviderSchemaChanges{
+					HasChanges:         true,
+					RemovedResources:   []string{"aws_s3_bucket_object"},
+					RemovedDataSources: []string{"aws_subnet_ids"},
+					RemovedAttributes: []terraform.ProviderAttributeChange{
+						{Type: "aws_s3_bucket", Path: "acl", RenamedTo: "bucket_acl", Usages: []terraform.AttributeUsage{
+							{Address: "aws_s3_bucket.logs", File: "storage.tf", Line: 12},
+							{Address: "aws_s3_bucket.assets", File: "storage.tf", Line: 30},
+						}},
+						{Type: "aws_ami", Path: "filter.values", IsDataSource: true},
+					},
+					NewRequired: []terraform.ProviderAttributeChange{{Type: "aws_instance", Path: "ami", Usages: []terraform.AttributeUsage{
+						{Address: "aws_instance.web", File: "compute.tf", Line: 3},
+					}}},
+					DeprecatedAttributes: []terraform.ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "region"}},
+				},
+			},
+			wantContains: []string{
+				"### 📋 Provider Schema Changes",
+				"#### ❌ Removed Types (2)",
+				"- resource `aws_s3_bucket_object`",
+				"- data source `aws_subnet_ids`",
+				"#### ❌ Removed Attributes (2)",
+				"- `aws_s3_bucket.acl` (probably renamed to `bucket_acl`) — ⚠️ set by `aws_s3_bucket.logs` in `storage.tf:12`, `aws_s3_bucket.assets` in `storage.tf:30`\n",
+				"- `data.aws_ami.filter.values` (informational: not set here)\n",
+				"#### ⚠️ Newly Required (1)",
+				"- `aws_instance.ami` — ⚠️ not set by `aws_instance.web` in `compute.tf:3`",
+				"#### 🕰️ Newly Deprecated (1)",
+				"- `aws_s3_bucket.region`",
+			},
+		},
+		{
+			name: "provider update with release notes",
+			update: version.ProviderUpdateInfo{
+				Provider: scanner.ProviderInfo{
+					Name:   "aws",
+					Source: "hashicorp/aws",
+				},
+				CurrentVersion: "5.30.0",
+				LatestVersion:  "5.31.0",
+				ReleaseNotes: &version.ReleaseNotes{
+					Repository: "hashicorp/terraform-provider-aws",
+					Source:     "CHANGELOG.md",
+					Versions:   []version.VersionNotes{{Version: "5.31.0", Body: "BUG FIXES:\n* resource/aws_s3_bucket: Fix acl"}},
+				},
+			},
+			wantContains: []string{
+				"<summary>📝 Release notes (1 version, from hashicorp/terraform-provider-aws CHANGELOG.md)</summary>",
+				"#### 5.31.0\n\nBUG FIXES:",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body := creator.generateProviderPRBody(tt.update)
+			body := creator.generateProviderPRBody(tt.update, nil)
 
 			for _, want := range tt.wantContains {
 				if !strings.Contains(body, want) {
@@ -385,80 +473,164 @@ func TestGenerateProviderPRBody(t *testing.T) {
 	}
 }
 
-func TestReplaceProviderVersionInLine(t *testing.T) {
-	creator, _ := NewPRCreator("ghp_test", "testorg", "testrepo", "main", "/project", nil, nil)
+func TestGeneratePRBodyRootPlans(t *testing.T) {
+	creator := &PRCreator{}
+	update := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "network", Source: "./modules/network", FilePath: "modules/network/main.tf", Line: 1},
+		CurrentVersion: "1.0.0",
+		LatestVersion:  "1.1.0",
+	}
+	rootPlans := []terraform.RootPlan{
+		{Dir: "envs/prod", Result: &terraform.PlanResult{Success: true, Output: "No changes. Infrastructure is up-to-date."}},
+		{Dir: "envs/staging", Result: &terraform.PlanResult{ErrorMessage: "Error: missing variable"}},
+	}
 
-	tests := []struct {
-		name           string
-		line           string
-		currentVersion string
-		latestVersion  string
-		want           string
-	}{
-		{
-			name:           "simple version",
-			line:           `      version = "4.0.0"`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = "5.0.0"`,
-		},
-		{
-			name:           "pessimistic constraint",
-			line:           `      version = "~> 4.0"`,
-			currentVersion: "4.0",
-			latestVersion:  "5.0",
-			want:           `      version = "~> 5.0"`,
-		},
-		{
-			name:           "greater than or equal",
-			line:           `      version = ">= 4.0.0"`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = ">= 5.0.0"`,
-		},
-		{
-			name:           "exact version with equals",
-			line:           `      version = "= 4.0.0"`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = "= 5.0.0"`,
-		},
-		{
-			name:           "less than or equal",
-			line:           `      version = "<= 4.0.0"`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = "<= 5.0.0"`,
-		},
-		{
-			name:           "greater than",
-			line:           `      version = "> 3.0.0"`,
-			currentVersion: "3.0.0",
-			latestVersion:  "4.0.0",
-			want:           `      version = "> 4.0.0"`,
-		},
-		{
-			name:           "less than",
-			line:           `      version = "< 4.0.0"`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = "< 5.0.0"`,
+	body := creator.generatePRBody(update, terraform.CombinePlans(rootPlans), rootPlans)
+
+	for _, want := range []string{
+		"Planned 2 affected root module(s)",
+		"#### `envs/prod`",
+		"#### `envs/staging`",
+		"Error: missing variable",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("generatePRBody() does not contain %q\nBody:\n%s", want, body)
+		}
+	}
+}
+
+func TestGeneratePRBodyValidationOnly(t *testing.T) {
+	creator := &PRCreator{}
+	update := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", FilePath: "envs/prod/main.tf", Line: 1},
+		CurrentVersion: "5.0.0",
+		LatestVersion:  "6.0.0",
+	}
+	rootPlans := []terraform.RootPlan{
+		{Dir: "envs/prod", Validation: &terraform.ValidationResult{Level: terraform.ValidationValidate, Success: true}},
+		{Dir: "envs/staging", Validation: &terraform.ValidationResult{
+			Level:  terraform.ValidationValidate,
+			Errors: []string{`main.tf:3: Unsupported argument: An argument named "enable_classiclink" is not expected here.`},
+		}},
+	}
+
+	planResult := terraform.CombinePlans(rootPlans)
+	if planResult != nil {
+		t.Fatalf("CombinePlans() = %+v, want nil without plans", planResult)
+	}
+
+	body := creator.generatePRBody(update, planResult, rootPlans)
+
+	for _, want := range []string{
+		"### Terraform Validation Results",
+		"Validated 2 affected root module(s)",
+		"✅ Validation: validate succeeded",
+		"❌ Validation: validate failed",
+		`An argument named "enable_classiclink" is not expected here.`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("generatePRBody() does not contain %q\nBody:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Terraform Plan Results") {
+		t.Errorf("generatePRBody() reports plan results without a plan\nBody:\n%s", body)
+	}
+}
+
+func TestGeneratePRBodyAIAnalysis(t *testing.T) {
+	creator := &PRCreator{}
+	update := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", FilePath: "main.tf", Line: 1},
+		CurrentVersion: "5.0.0",
+		LatestVersion:  "6.0.0",
+		AIAnalysis: &ai.AIAnalysis{
+			HasBreakingChanges: true,
+			Summary:            "The name variable was removed.",
+			Confidence:         "high",
+			Details: []ai.Detail{{
+				Text:      "Remove the name argument",
+				Citations: []ai.Evidence{{ID: "S1", Source: "schema diff", Text: "Removed variable name (string)"}},
+			}},
 		},
-		{
-			name:           "no quotes",
-			line:           `      version = hello`,
-			currentVersion: "4.0.0",
-			latestVersion:  "5.0.0",
-			want:           `      version = hello`,
+	}
+
+	body := creator.generatePRBody(update, nil, nil)
+
+	for _, want := range []string{
+		"### 🤖 AI Analysis",
+		"**breaking changes found** (high confidence",
+		`- Remove the name argument [schema diff: "Removed variable name (string)"]`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("generatePRBody() does not contain %q\nBody:\n%s", want, body)
+		}
+	}
+}
+
+func TestRenderPlanText(t *testing.T) {
+	var body strings.Builder
+	renderPlanText(&body, &terraform.PlanResult{Text: "  # aws_vpc.this will be updated in-place\n"})
+	if !strings.Contains(body.String(), "<summary>Full plan output</summary>") ||
+		!strings.Contains(body.String(), "# aws_vpc.this will be updated in-place") {
+		t.Errorf("renderPlanText() = %q", body.String())
+	}
+
+	long := strings.Repeat("  ~ tags = {}\n", maxPlanTextLength/10)
+	body.Reset()
+	renderPlanText(&body, &terraform.PlanResult{Text: long, Artifacts: &terraform.PlanArtifacts{TextFile: "plan.txt"}})
+	if body.Len() > maxPlanTextLength+500 {
+		t.Errorf("renderPlanText() wrote %d characters, want at most about %d", body.Len(), maxPlanTextLength)
+	}
+	if !strings.Contains(body.String(), "The full plan is in the plan artifacts.") {
+		t.Errorf("renderPlanText() does not mention truncation:\n%s", body.String()[body.Len()-300:])
+	}
+
+	body.Reset()
+	renderPlanText(&body, &terraform.PlanResult{})
+	if body.Len() != 0 {
+		t.Errorf("renderPlanText() wrote %q for an empty plan", body.String())
+	}
+}
+
+func TestGeneratePRBodyDrift(t *testing.T) {
+	creator := &PRCreator{}
+	update := version.UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "vpc", Source: "terraform-aws-modules/vpc/aws"},
+		CurrentVersion: "5.0.0",
+		LatestVersion:  "5.1.0",
+		ResourceChanges: &version.ResourceChangesSummary{
+			HasChanges:        true,
+			ResourcesToModify: []version.ResourceChange{{Address: "module.vpc.aws_vpc.this", Attributes: []string{"enable_dns_hostnames"}}},
+			TotalModify:       1,
+			Drift:             []version.ResourceChange{{Address: "aws_s3_bucket.logs", Action: "update"}},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := creator.replaceProviderVersionInLine(tt.line, tt.currentVersion, tt.latestVersion)
-			if got != tt.want {
-				t.Errorf("replaceProviderVersionInLine() = %q, want %q", got, tt.want)
-			}
-		})
+	body := creator.generatePRBody(update, nil, nil)
+
+	for _, want := range []string{
+		"- `module.vpc.aws_vpc.this`: `enable_dns_hostnames`",
+		"<summary>Pre-existing drift (1 resource(s), also planned on the base branch)</summary>",
+		"- `aws_s3_bucket.logs` (update)",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("generatePRBody() does not contain %q\nBody:\n%s", want, body)
+		}
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator := &PRCreator{workingDir: "infra"}
+
+	// Scanned paths include the scan path, so the working directory is not prepended
+	if got, want := creator.resolvePath("infra/main.tf"), filepath.Join(cwd, "infra", "main.tf"); got != want {
+		t.Errorf("resolvePath(infra/main.tf) = %q, want %q", got, want)
+	}
+	if got := creator.resolvePath("/repo/main.tf"); got != "/repo/main.tf" {
+		t.Errorf("resolvePath(/repo/main.tf) = %q, want it unchanged", got)
 	}
 }
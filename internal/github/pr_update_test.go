@@ -266,91 +266,6 @@ terraform {
 	}
 }
 
-func TestReplaceProviderVersionInLineEdgeCases(t *testing.T) {
-	creator, _ := NewPRCreator("test-token", "testorg", "testrepo", "main", ".", nil, nil)
-
-	tests := []struct {
-		name    string
-		line    string
-		current string
-		latest  string
-		want    string
-	}{
-		{
-			name:    "simple version no constraint",
-			line:    `      version = "4.0.0"`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `      version = "5.0.0"`,
-		},
-		{
-			name:    "version with ~> constraint",
-			line:    `      version = "~> 4.0"`,
-			current: "4.0",
-			latest:  "5.0",
-			want:    `      version = "~> 5.0"`,
-		},
-		{
-			name:    "version with >= constraint",
-			line:    `      version = ">= 4.0.0"`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `      version = ">= 5.0.0"`,
-		},
-		{
-			name:    "version with = constraint",
-			line:    `      version = "= 4.0.0"`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `      version = "= 5.0.0"`,
-		},
-		{
-			name:    "version with <= constraint",
-			line:    `      version = "<= 4.0.0"`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `      version = "<= 5.0.0"`,
-		},
-		{
-			name:    "version with > constraint",
-			line:    `      version = "> 3.0.0"`,
-			current: "3.0.0",
-			latest:  "4.0.0",
-			want:    `      version = "> 4.0.0"`,
-		},
-		{
-			name:    "version with < constraint",
-			line:    `      version = "< 5.0.0"`,
-			current: "5.0.0",
-			latest:  "6.0.0",
-			want:    `      version = "< 6.0.0"`,
-		},
-		{
-			name:    "no quotes in line",
-			line:    `      version = noquotes`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `      version = noquotes`,
-		},
-		{
-			name:    "compact spacing",
-			line:    `version="4.0.0"`,
-			current: "4.0.0",
-			latest:  "5.0.0",
-			want:    `version="5.0.0"`,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := creator.replaceProviderVersionInLine(tt.line, tt.current, tt.latest)
-			if got != tt.want {
-				t.Errorf("replaceProviderVersionInLine() = %q, want %q", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestGeneratePRBodyWithSchemaChanges(t *testing.T) {
 	creator, _ := NewPRCreator("test-token", "testorg", "testrepo", "main", ".", nil, nil)
 
@@ -379,10 +294,21 @@ func TestGeneratePRBodyWithSchemaChanges(t *testing.T) {
 			RemovedOutputs: []terraform.OutputChange{
 				{Name: "deprecated_output"},
 			},
+			ChangedDefaults: []terraform.DefaultChange{
+				{Name: "enable_nat_gateway", OldDefault: "true", NewDefault: "false", AffectsCall: true, File: "main.tf", Line: 10},
+				{Name: "single_nat_gateway", OldDefault: "false", NewDefault: "true"},
+			},
+			ChangedAttributes: []terraform.VariableAttributeChange{
+				{Name: "tags", Attribute: "nullable", Old: true, New: false},
+			},
+			CallSiteIssues: []terraform.CallSiteIssue{
+				{Kind: terraform.IssueRemovedArgument, Name: "old_var", File: "main.tf", Line: 14,
+					Message: `argument "old_var" is no longer accepted by the module`},
+			},
 		},
 	}
 
-	body := creator.generatePRBody(update, nil)
+	body := creator.generatePRBody(update, nil, nil)
 
 	expectedStrings := []string{
 		"API/Schema Changes Detected",
@@ -395,6 +321,12 @@ func TestGeneratePRBodyWithSchemaChanges(t *testing.T) {
 		"number → string",
 		"Removed Outputs",
 		"deprecated_output",
+		"Changed Defaults (2)",
+		"- `enable_nat_gateway`: `true` → `false` — ⚠️ **affects our call** in `main.tf:10`, which relies on the default",
+		"- `single_nat_gateway`: `false` → `true`\n",
+		"- `tags`: nullable `true` → `false`",
+		"Breaking Call Sites (1)",
+		"- `main.tf:14`: argument \"old_var\" is no longer accepted by the module",
 	}
 
 	for _, expected := range expectedStrings {
@@ -422,7 +354,7 @@ func TestGenerateProviderPRBodyWithBreakingChange(t *testing.T) {
 		ChangelogURL:          "https://registry.terraform.io/providers/hashicorp/aws/5.0.0",
 	}
 
-	body := creator.generateProviderPRBody(update)
+	body := creator.generateProviderPRBody(update, nil)
 
 	expectedStrings := []string{
 		"Breaking Change Warning",
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/rs/zerolog/log"
 )
 
@@ -28,6 +30,9 @@ type ModuleInfo struct {
 	// Line number in the file
 	Line int
 
+	// Last line of the module block
+	EndLine int
+
 	// SourceType indicates if it's a registry or git source
 	SourceType SourceType
 }
@@ -48,6 +53,9 @@ type ProviderInfo struct {
 
 	// Line number in the file
 	Line int
+
+	// Last line of the provider requirement
+	EndLine int
 }
 
 // ResourceInfo represents a Terraform resource or data source found during scanning
@@ -66,6 +74,37 @@ type ResourceInfo struct {
 
 	// IsDataSource indicates if this is a data source (true) or resource (false)
 	IsDataSource bool
+
+	// Attributes and nested blocks set in the block, excluding meta-arguments
+	Attributes []AttributeInfo
+}
+
+// AttributeInfo is an attribute or nested block set in a resource or data block
+type AttributeInfo struct {
+	// Path of the attribute, with nested blocks joined by dots (e.g., "versioning.enabled")
+	Path string
+
+	// Line number in the file
+	Line int
+}
+
+// Address returns the address of the resource, e.g. aws_s3_bucket.logs or data.aws_ami.ubuntu
+func (r ResourceInfo) Address() string {
+	address := r.Type + "." + r.Name
+	if r.IsDataSource {
+		address = "data." + address
+	}
+	return address
+}
+
+// SetsAttribute returns the attribute or nested block at path set in the resource
+func (r ResourceInfo) SetsAttribute(path string) (AttributeInfo, bool) {
+	for _, attr := range r.Attributes {
+		if attr.Path == path {
+			return attr, true
+		}
+	}
+	return AttributeInfo{}, false
 }
 
 // SourceType indicates the type of module source
@@ -91,6 +130,7 @@ type Scanner struct {
 	exclude   []string
 	include   []string
 	recursive bool
+	openTofu  bool
 }
 
 // New creates a new Scanner instance
@@ -103,6 +143,13 @@ func New(basePath string, exclude []string, include []string, recursive bool) *S
 	}
 }
 
+// EnableOpenTofu makes the scanner read OpenTofu .tofu files wherever the include
+// patterns match the .tf file of the same name. Like OpenTofu, it then ignores a .tf
+// file that has a .tofu counterpart.
+func (s *Scanner) EnableOpenTofu() {
+	s.openTofu = true
+}
+
 // Scan scans the configured path for Terraform modules
 func (s *Scanner) Scan() ([]ModuleInfo, error) {
 	var modules []ModuleInfo
@@ -271,6 +318,7 @@ func (s *Scanner) parseFile(path string) ([]ModuleInfo, error) {
 			Name:     block.Labels[0],
 			FilePath: path,
 			Line:     block.DefRange.Start.Line,
+			EndLine:  blockEndLine(block),
 		}
 
 		// Extract source and version attributes
@@ -312,6 +360,14 @@ func (s *Scanner) parseFile(path string) ([]ModuleInfo, error) {
 	return modules, nil
 }
 
+// blockEndLine returns the line of a block's closing brace
+func blockEndLine(block *hcl.Block) int {
+	if body, ok := block.Body.(*hclsyntax.Body); ok {
+		return body.SrcRange.End.Line
+	}
+	return block.DefRange.End.Line
+}
+
 // DetermineSourceType determines the type of module source
 func (s *Scanner) DetermineSourceType(source string) SourceType {
 	// Check for git sources
@@ -356,8 +412,21 @@ func (s *Scanner) shouldExclude(path string) bool {
 
 // shouldInclude checks if a file should be included
 func (s *Scanner) shouldInclude(path string) bool {
+	name := filepath.Base(path)
+	if s.openTofu {
+		switch filepath.Ext(path) {
+		case ".tofu":
+			name = strings.TrimSuffix(name, ".tofu") + ".tf"
+		case ".tf":
+			// OpenTofu loads the .tofu file instead
+			if _, err := os.Stat(strings.TrimSuffix(path, ".tf") + ".tofu"); err == nil {
+				return false
+			}
+		}
+	}
+
 	for _, pattern := range s.include {
-		matched, err := filepath.Match(pattern, filepath.Base(path))
+		matched, err := filepath.Match(pattern, name)
 		if err == nil && matched {
 			return true
 		}
@@ -425,6 +494,7 @@ func (s *Scanner) parseProviders(path string) ([]ProviderInfo, error) {
 					Name:     providerName,
 					FilePath: path,
 					Line:     providerAttr.Range.Start.Line,
+					EndLine:  providerAttr.Range.End.Line,
 				}
 
 				// Provider can be specified as a string (just source) or object with source and version
@@ -512,6 +582,9 @@ func (s *Scanner) parseResources(path string) ([]ResourceInfo, error) {
 			Line:         block.DefRange.Start.Line,
 			IsDataSource: block.Type == "data",
 		}
+		if body, ok := block.Body.(*hclsyntax.Body); ok {
+			resourceInfo.Attributes = blockAttributes(body, "", true)
+		}
 
 		resources = append(resources, resourceInfo)
 		log.Debug().
@@ -523,3 +596,60 @@ func (s *Scanner) parseResources(path string) ([]ResourceInfo, error) {
 
 	return resources, nil
 }
+
+// resourceMetaArguments are the arguments and blocks of resource and data blocks that
+// belong to Terraform rather than to the provider's schema
+var resourceMetaArguments = map[string]bool{
+	"count":       true,
+	"for_each":    true,
+	"provider":    true,
+	"depends_on":  true,
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+}
+
+// blockAttributes returns the attributes and nested blocks set in body, in file order.
+// The content of a dynamic block is recorded under the name of the block it generates.
+func blockAttributes(body *hclsyntax.Body, prefix string, topLevel bool) []AttributeInfo {
+	var attrs []AttributeInfo
+
+	for name, attr := range body.Attributes {
+		if topLevel && resourceMetaArguments[name] {
+			continue
+		}
+		attrs = append(attrs, AttributeInfo{Path: prefix + name, Line: attr.SrcRange.Start.Line})
+	}
+
+	for _, block := range body.Blocks {
+		name := block.Type
+		content := block.Body
+		if name == "dynamic" {
+			if len(block.Labels) != 1 {
+				continue
+			}
+			name = block.Labels[0]
+			content = nil
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "content" {
+					content = nested.Body
+				}
+			}
+		} else if topLevel && resourceMetaArguments[name] {
+			continue
+		}
+
+		attrs = append(attrs, AttributeInfo{Path: prefix + name, Line: block.TypeRange.Start.Line})
+		if content != nil {
+			attrs = append(attrs, blockAttributes(content, prefix+name+".", false)...)
+		}
+	}
+
+	sort.SliceStable(attrs, func(i, j int) bool {
+		if attrs[i].Line != attrs[j].Line {
+			return attrs[i].Line < attrs[j].Line
+		}
+		return attrs[i].Path < attrs[j].Path
+	})
+	return attrs
+}
@@ -3,6 +3,7 @@ package scanner
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -185,6 +186,61 @@ func TestShouldInclude(t *testing.T) {
 	}
 }
 
+func TestScan_OpenTofu(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"main.tf": `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}`,
+		"main.tofu": `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}`,
+		"eks.tofu": `module "eks" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "20.0.0"
+}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	t.Run("terraform ignores tofu files", func(t *testing.T) {
+		modules, err := New(tmpDir, nil, []string{"*.tf"}, false).Scan()
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if len(modules) != 1 || modules[0].Version != "5.0.0" {
+			t.Errorf("Scan() = %+v, want only vpc 5.0.0 from main.tf", modules)
+		}
+	})
+
+	t.Run("opentofu prefers tofu files", func(t *testing.T) {
+		s := New(tmpDir, nil, []string{"*.tf"}, false)
+		s.EnableOpenTofu()
+
+		modules, err := s.Scan()
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+
+		versions := map[string]string{}
+		for _, module := range modules {
+			versions[module.Name] = module.Version
+			if filepath.Ext(module.FilePath) != ".tofu" {
+				t.Errorf("module %s read from %s, want a .tofu file", module.Name, module.FilePath)
+			}
+		}
+		if versions["vpc"] != "5.1.0" || versions["eks"] != "20.0.0" {
+			t.Errorf("Scan() versions = %v, want vpc 5.1.0 and eks 20.0.0", versions)
+		}
+	})
+}
+
 func TestScan(t *testing.T) {
 	// Create temporary directory structure
 	tmpDir := t.TempDir()
@@ -257,6 +313,9 @@ module "s3" {
 						if m.SourceType != SourceTypeRegistry {
 							t.Errorf("vpc source type = %s, want %s", m.SourceType, SourceTypeRegistry)
 						}
+						if m.Line != 2 || m.EndLine != 5 {
+							t.Errorf("vpc lines = %d-%d, want 2-5", m.Line, m.EndLine)
+						}
 					}
 					if m.Name == "s3" {
 						foundSub = true
@@ -393,6 +452,79 @@ terraform {
 	}
 }
 
+func TestScanResourcesAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mainTf := `resource "aws_s3_bucket" "logs" {
+  count  = 1
+  bucket = "logs"
+  acl    = "private"
+
+  versioning {
+    enabled = true
+  }
+
+  dynamic "lifecycle_rule" {
+    for_each = var.rules
+    content {
+      id = lifecycle_rule.value
+    }
+  }
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  owners = ["099720109477"]
+}
+`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.tf"), []byte(mainTf), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	scanner := New(tmpDir, nil, []string{"*.tf"}, true)
+	resources, err := scanner.ScanResources()
+	if err != nil {
+		t.Fatalf("ScanResources() error = %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("ScanResources() found %d resources, want 2", len(resources))
+	}
+
+	bucket := resources[0]
+	wantBucket := []AttributeInfo{
+		{Path: "bucket", Line: 3},
+		{Path: "acl", Line: 4},
+		{Path: "versioning", Line: 6},
+		{Path: "versioning.enabled", Line: 7},
+		{Path: "lifecycle_rule", Line: 10},
+		{Path: "lifecycle_rule.id", Line: 13},
+	}
+	if !reflect.DeepEqual(bucket.Attributes, wantBucket) {
+		t.Errorf("aws_s3_bucket.logs Attributes = %+v, want %+v", bucket.Attributes, wantBucket)
+	}
+	if attr, ok := bucket.SetsAttribute("acl"); !ok || attr.Line != 4 {
+		t.Errorf("SetsAttribute(acl) = %+v, %t, want line 4", attr, ok)
+	}
+	if _, ok := bucket.SetsAttribute("count"); ok {
+		t.Error("SetsAttribute(count) = true, want meta-arguments excluded")
+	}
+	if got := bucket.Address(); got != "aws_s3_bucket.logs" {
+		t.Errorf("Address() = %q, want aws_s3_bucket.logs", got)
+	}
+
+	ami := resources[1]
+	if want := []AttributeInfo{{Path: "owners", Line: 23}}; !reflect.DeepEqual(ami.Attributes, want) {
+		t.Errorf("data.aws_ami.ubuntu Attributes = %+v, want %+v", ami.Attributes, want)
+	}
+	if got := ami.Address(); got != "data.aws_ami.ubuntu" {
+		t.Errorf("Address() = %q, want data.aws_ami.ubuntu", got)
+	}
+}
+
 func TestScan_WithExcludes(t *testing.T) {
 	tmpDir := t.TempDir()
 
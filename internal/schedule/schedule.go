@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the timezone database so schedules work in minimal container images
+	_ "time/tzdata"
+)
+
+// Schedule is a set of cron-like time windows in a timezone
+type Schedule struct {
+	location *time.Location
+	windows  []window
+	exprs    []string
+}
+
+// window is a parsed five-field cron expression (minute hour day-of-month month day-of-week)
+type window struct {
+	minute     fieldSet
+	hour       fieldSet
+	dayOfMonth fieldSet
+	month      fieldSet
+	dayOfWeek  fieldSet
+	domStar    bool
+	dowStar    bool
+}
+
+// fieldSet holds the allowed values of a cron field
+type fieldSet map[int]bool
+
+// New parses cron-like windows in the given timezone. An empty window list allows any time.
+func New(windows []string, timezone string) (*Schedule, error) {
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule timezone %q: %w", timezone, err)
+		}
+		location = loc
+	}
+
+	s := &Schedule{location: location, exprs: windows}
+	for _, expr := range windows {
+		w, err := parseWindow(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+
+	return s, nil
+}
+
+// Allows reports whether t falls inside any window of the schedule
+func (s *Schedule) Allows(t time.Time) bool {
+	if s == nil || len(s.windows) == 0 {
+		return true
+	}
+
+	t = t.In(s.location)
+	for _, w := range s.windows {
+		if w.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the schedule for output
+func (s *Schedule) String() string {
+	if s == nil || len(s.windows) == 0 {
+		return "at any time"
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(s.exprs, " or "), s.location)
+}
+
+// matches reports whether t matches the window, using cron's day-of-month/day-of-week OR semantics
+func (w window) matches(t time.Time) bool {
+	if !w.minute[t.Minute()] || !w.hour[t.Hour()] || !w.month[int(t.Month())] {
+		return false
+	}
+
+	dom := w.dayOfMonth[t.Day()]
+	dow := w.dayOfWeek[int(t.Weekday())]
+	switch {
+	case w.domStar && w.dowStar:
+		return true
+	case w.domStar:
+		return dow
+	case w.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseWindow parses a five-field cron expression
+func parseWindow(expr string) (window, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return window{}, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	var w window
+	var err error
+	if w.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return window{}, fmt.Errorf("invalid schedule %q: minute: %w", expr, err)
+	}
+	if w.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return window{}, fmt.Errorf("invalid schedule %q: hour: %w", expr, err)
+	}
+	if w.dayOfMonth, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return window{}, fmt.Errorf("invalid schedule %q: day-of-month: %w", expr, err)
+	}
+	if w.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return window{}, fmt.Errorf("invalid schedule %q: month: %w", expr, err)
+	}
+	if w.dayOfWeek, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return window{}, fmt.Errorf("invalid schedule %q: day-of-week: %w", expr, err)
+	}
+
+	// 7 is an alias for Sunday
+	if w.dayOfWeek[7] {
+		w.dayOfWeek[0] = true
+	}
+
+	w.domStar = fields[2] == "*"
+	w.dowStar = fields[4] == "*"
+
+	return w, nil
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseField parses a cron field made of comma-separated values, ranges and steps
+func parseField(field string, min, max int, names map[string]int) (fieldSet, error) {
+	set := make(fieldSet)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx != -1 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = s
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], names); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = parseValue(bounds[1], names); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 starting at 5
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// parseValue parses a numeric or named cron value
+func parseValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleAllows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		windows  []string
+		timezone string
+		at       time.Time
+		want     bool
+	}{
+		{
+			name: "no windows allows any time",
+			at:   time.Date(2025, 1, 5, 3, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name:     "weekday morning inside window",
+			windows:  []string{"* 6-11 * * mon-fri"},
+			timezone: "Europe/Berlin",
+			at:       time.Date(2025, 1, 6, 9, 30, 0, 0, berlin), // Monday
+			want:     true,
+		},
+		{
+			name:     "weekday afternoon outside window",
+			windows:  []string{"* 6-11 * * 1-5"},
+			timezone: "Europe/Berlin",
+			at:       time.Date(2025, 1, 6, 14, 0, 0, 0, berlin),
+			want:     false,
+		},
+		{
+			name:     "weekend outside window",
+			windows:  []string{"* 6-11 * * 1-5"},
+			timezone: "Europe/Berlin",
+			at:       time.Date(2025, 1, 4, 9, 0, 0, 0, berlin), // Saturday
+			want:     false,
+		},
+		{
+			name:     "timezone conversion",
+			windows:  []string{"* 6-11 * * 1-5"},
+			timezone: "Europe/Berlin",
+			at:       time.Date(2025, 1, 6, 5, 30, 0, 0, time.UTC), // 06:30 in Berlin
+			want:     true,
+		},
+		{
+			name:    "any of several windows",
+			windows: []string{"* 6-8 * * 1-5", "0-29 20 * * 0,7"},
+			at:      time.Date(2025, 1, 5, 20, 15, 0, 0, time.UTC), // Sunday
+			want:    true,
+		},
+		{
+			name:    "step values",
+			windows: []string{"*/15 * * * *"},
+			at:      time.Date(2025, 1, 5, 20, 16, 0, 0, time.UTC),
+			want:    false,
+		},
+		{
+			name:    "day of month or day of week",
+			windows: []string{"* * 1 * mon"},
+			at:      time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), // Wednesday the 1st
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.windows, tt.timezone)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.Allows(tt.at); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		windows  []string
+		timezone string
+	}{
+		{name: "too few fields", windows: []string{"* 6-11 *"}},
+		{name: "hour out of range", windows: []string{"* 6-24 * * *"}},
+		{name: "unknown day name", windows: []string{"* * * * funday"}},
+		{name: "invalid timezone", windows: []string{"* * * * *"}, timezone: "Mars/Olympus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.windows, tt.timezone); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
@@ -52,12 +52,7 @@ func AnalyzeResourceChanges(planResult *PlanResult) *version.ResourceChangesSumm
 		// Build reason string
 		reason := buildChangeReason(change)
 
-		resourceChange := version.ResourceChange{
-			Address:      change.Address,
-			ResourceType: change.ResourceType,
-			Action:       strings.Join(change.Action, ", "),
-			Reason:       reason,
-		}
+		resourceChange := summaryChange(change, reason)
 
 		// Categorize the change
 		if isReplace {
@@ -78,6 +73,9 @@ func AnalyzeResourceChanges(planResult *PlanResult) *version.ResourceChangesSumm
 // buildChangeReason creates a human-readable reason for the resource change
 func buildChangeReason(change ResourceChange) string {
 	if len(change.ReplaceTriggers) == 0 {
+		if change.ActionReason != "" {
+			return "Terraform plans this because " + DescribeActionReason(change.ActionReason)
+		}
 		return "Module update requires resource replacement"
 	}
 
@@ -89,6 +87,24 @@ func buildChangeReason(change ResourceChange) string {
 		strings.Join(change.ReplaceTriggers, ", "))
 }
 
+// describeResourceChange renders a resource with its workspace, action reason and moved/imported markers
+func describeResourceChange(rc version.ResourceChange) string {
+	text := "- " + rc.Address
+	if rc.Workspace != "" {
+		text += " [workspace " + rc.Workspace + "]"
+	}
+	if rc.ActionReason != "" {
+		text += " (" + DescribeActionReason(rc.ActionReason) + ")"
+	}
+	if rc.MovedFrom != "" {
+		text += " [moved from " + rc.MovedFrom + "]"
+	}
+	if rc.Imported {
+		text += " [imported]"
+	}
+	return text
+}
+
 // HasCriticalChanges checks if the resource changes include critical operations
 func HasCriticalChanges(summary *version.ResourceChangesSummary) bool {
 	if summary == nil {
@@ -123,5 +139,112 @@ func FormatResourceChanges(summary *version.ResourceChangesSummary) string {
 		return "Minor changes only"
 	}
 
+	for _, rc := range summary.ResourcesToReplace {
+		parts = append(parts, "   "+describeResourceChange(rc))
+		for _, trigger := range rc.Triggers {
+			parts = append(parts, fmt.Sprintf("      %s: %s → %s (forces replacement)", trigger.Path, trigger.Before, trigger.After))
+		}
+	}
+	for _, rc := range summary.ResourcesToDelete {
+		parts = append(parts, "   "+describeResourceChange(rc))
+	}
+
 	return strings.Join(parts, "\n")
 }
+
+// AnalyzePlanDiff summarizes only the resource changes an update introduces, keeping
+// changes already planned on the base branch as drift
+func AnalyzePlanDiff(diff *PlanDiff) *version.ResourceChangesSummary {
+	summary := &version.ResourceChangesSummary{
+		HasChanges:         diff.HasChanges(),
+		ResourcesToReplace: []version.ResourceChange{},
+		ResourcesToDelete:  []version.ResourceChange{},
+		ResourcesToModify:  []version.ResourceChange{},
+	}
+
+	for _, change := range diff.Replacements {
+		summary.ResourcesToReplace = append(summary.ResourcesToReplace, summaryChange(change, buildChangeReason(change)))
+	}
+	for _, change := range diff.Deletions {
+		summary.ResourcesToDelete = append(summary.ResourcesToDelete, summaryChange(change, buildChangeReason(change)))
+	}
+	for _, change := range diff.Updates {
+		summary.ResourcesToModify = append(summary.ResourcesToModify, summaryChange(change, ""))
+	}
+	for _, change := range diff.Drift {
+		summary.Drift = append(summary.Drift, summaryChange(change, ""))
+	}
+
+	summary.TotalReplace = len(summary.ResourcesToReplace)
+	summary.TotalDelete = len(summary.ResourcesToDelete)
+	summary.TotalModify = len(summary.ResourcesToModify)
+
+	return summary
+}
+
+// summaryChange converts a plan resource change for the update summary
+func summaryChange(change ResourceChange, reason string) version.ResourceChange {
+	rc := version.ResourceChange{
+		Address:      change.Address,
+		ResourceType: change.ResourceType,
+		Action:       strings.Join(change.Action, ", "),
+		Reason:       reason,
+		Attributes:   change.ChangedAttributes,
+		ActionReason: change.ActionReason,
+		MovedFrom:    change.PreviousAddress,
+		Imported:     change.Importing,
+		Workspace:    change.Workspace,
+	}
+
+	for _, trigger := range change.ReplacePaths {
+		rc.Triggers = append(rc.Triggers, version.AttributeChange{
+			Path:   trigger.Path,
+			Before: trigger.Before,
+			After:  trigger.After,
+		})
+	}
+
+	return rc
+}
+
+// AnnotateUpdate records the resource changes of the root plans on a module update and
+// flags the update as breaking when it replaces or deletes resources. Roots planned
+// against a baseline only contribute the changes the update introduces.
+func AnnotateUpdate(update *version.UpdateInfo, rootPlans []RootPlan) {
+	diff := UpgradeDiff(rootPlans)
+	if diff == nil {
+		return
+	}
+
+	update.ResourceChanges = AnalyzePlanDiff(diff)
+
+	if HasCriticalChanges(update.ResourceChanges) {
+		update.HasBreakingChange = true
+		if update.BreakingChangeDetails == "" {
+			update.BreakingChangeDetails = "This update will cause resource replacements or deletions. Please review carefully."
+		} else {
+			update.BreakingChangeDetails += " Additionally, this update will cause resource replacements or deletions."
+		}
+	}
+}
+
+// UpgradeDiff combines the changes the upgrade introduces in the successfully planned
+// roots, nil when no root planned successfully. A root planned without a baseline
+// counts all of its changes as the upgrade's.
+func UpgradeDiff(rootPlans []RootPlan) *PlanDiff {
+	var diffs []*PlanDiff
+	for _, rootPlan := range rootPlans {
+		if rootPlan.Result == nil || !rootPlan.Result.Success {
+			continue
+		}
+		if rootPlan.Diff != nil {
+			diffs = append(diffs, rootPlan.Diff)
+		} else {
+			diffs = append(diffs, DiffPlans(nil, rootPlan.Result))
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	return CombineDiffs(diffs...)
+}
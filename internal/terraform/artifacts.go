@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ArtifactEntry lists the saved plans of one root module for one update
+type ArtifactEntry struct {
+	Name      string         `json:"name"`
+	Root      string         `json:"root"`
+	Workspace string         `json:"workspace,omitempty"`
+	Plan      *PlanArtifacts `json:"plan,omitempty"`
+	Baseline  *PlanArtifacts `json:"baseline,omitempty"`
+}
+
+// ManifestFile is the name of the artifact manifest in the artifacts directory
+const ManifestFile = "manifest.json"
+
+// artifactDir returns where the plans of a root are kept for an update, "" when
+// artifacts are not kept
+func (p *RootPlanner) artifactDir(name, root string) string {
+	if p.artifactsDir == "" {
+		return ""
+	}
+	if rel, err := filepath.Rel(p.finder.basePath, root); err == nil {
+		root = rel
+	}
+	return filepath.Join(p.artifactsDir, artifactName(name), artifactName(root))
+}
+
+// recordArtifacts adds the saved plans to the manifest and rewrites it, so that the
+// manifest is complete after every update
+func (p *RootPlanner) recordArtifacts(name string, plans []RootPlan) {
+	p.manifestMu.Lock()
+	defer p.manifestMu.Unlock()
+
+	for _, plan := range plans {
+		for _, workspace := range planWorkspaces(plan) {
+			entry := ArtifactEntry{Name: name, Root: plan.Dir, Workspace: workspace}
+			entry.Plan = workspaceArtifacts(plan.Result, workspace)
+			entry.Baseline = workspaceArtifacts(plan.Baseline, workspace)
+			if entry.Plan != nil || entry.Baseline != nil {
+				p.manifest = append(p.manifest, entry)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(p.manifest, "", "  ")
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to encode plan artifact manifest")
+		return
+	}
+	path := filepath.Join(p.artifactsDir, ManifestFile)
+	if err := os.MkdirAll(p.artifactsDir, 0755); err != nil {
+		log.Warn().Err(err).Str("dir", p.artifactsDir).Msg("failed to create artifacts directory")
+		return
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		log.Warn().Err(err).Str("file", path).Msg("failed to write plan artifact manifest")
+	}
+}
+
+// planWorkspaces returns the workspaces a root was planned in, "" for a root planned
+// in its current workspace only
+func planWorkspaces(plan RootPlan) []string {
+	for _, result := range []*PlanResult{plan.Result, plan.Baseline} {
+		if result != nil && len(result.Workspaces) > 0 {
+			workspaces := make([]string, len(result.Workspaces))
+			for i, ws := range result.Workspaces {
+				workspaces[i] = ws.Workspace
+			}
+			return workspaces
+		}
+	}
+	return []string{""}
+}
+
+// workspaceArtifacts returns the saved plan of one workspace in a result
+func workspaceArtifacts(result *PlanResult, workspace string) *PlanArtifacts {
+	if result == nil {
+		return nil
+	}
+	if workspace == "" {
+		return result.Artifacts
+	}
+	for _, ws := range result.Workspaces {
+		if ws.Workspace == workspace && ws.Result != nil {
+			return ws.Result.Artifacts
+		}
+	}
+	return nil
+}
+
+// artifactName turns a branch name or root path into a single directory name,
+// e.g. terranovate/vpc-5.1.0 -> terranovate-vpc-5.1.0
+func artifactName(name string) string {
+	name = filepath.ToSlash(filepath.Clean(name))
+	if name == "." || name == "" {
+		return "root"
+	}
+	name = strings.Trim(name, "/")
+	return strings.NewReplacer("/", "-", "..", "_", ":", "-").Replace(name)
+}
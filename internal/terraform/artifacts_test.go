@@ -0,0 +1,98 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArtifactName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"terranovate/vpc-5.1.0", "terranovate-vpc-5.1.0"},
+		{"envs/prod", "envs-prod"},
+		{".", "root"},
+		{"", "root"},
+		{"../shared", "_-shared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := artifactName(tt.name); got != tt.want {
+				t.Errorf("artifactName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordArtifacts(t *testing.T) {
+	base := t.TempDir()
+	artifacts := filepath.Join(t.TempDir(), "plans")
+
+	planner := NewRootPlanner(base, nil, "", nil)
+	planner.SetArtifactsDir(artifacts)
+
+	dir := planner.artifactDir("terranovate/vpc-5.1.0", filepath.Join(base, "envs", "prod"))
+	if want := filepath.Join(artifacts, "terranovate-vpc-5.1.0", "envs-prod"); dir != want {
+		t.Errorf("artifactDir() = %q, want %q", dir, want)
+	}
+
+	saved := &PlanArtifacts{
+		PlanFile: filepath.Join(dir, "plan.tfplan"),
+		JSONFile: filepath.Join(dir, "plan.json"),
+		TextFile: filepath.Join(dir, "plan.txt"),
+	}
+	planner.recordArtifacts("terranovate/vpc-5.1.0", []RootPlan{
+		{Dir: "envs/prod", Result: &PlanResult{Success: true, Artifacts: saved}},
+		{Dir: "envs/dev", Result: &PlanResult{ErrorMessage: "init failed"}},
+	})
+
+	data, err := os.ReadFile(filepath.Join(artifacts, ManifestFile))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest []ArtifactEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if len(manifest) != 1 || manifest[0].Root != "envs/prod" || manifest[0].Plan.TextFile != saved.TextFile {
+		t.Errorf("manifest = %+v, want only the saved envs/prod plan", manifest)
+	}
+}
+
+func TestRecordArtifactsWorkspaces(t *testing.T) {
+	artifacts := t.TempDir()
+
+	planner := NewRootPlanner(t.TempDir(), nil, "", nil)
+	planner.SetArtifactsDir(artifacts)
+
+	prod := &PlanArtifacts{TextFile: "prod/plan.txt"}
+	baseline := &PlanArtifacts{TextFile: "baseline/prod/plan.txt"}
+	planner.recordArtifacts("terranovate/vpc-5.1.0", []RootPlan{{
+		Dir: "envs/app",
+		Result: CombineWorkspaces([]WorkspaceResult{
+			{Workspace: "staging", Result: &PlanResult{ErrorMessage: "plan failed"}},
+			{Workspace: "prod", Result: &PlanResult{Success: true, Artifacts: prod}},
+		}),
+		Baseline: CombineWorkspaces([]WorkspaceResult{
+			{Workspace: "staging", Result: &PlanResult{ErrorMessage: "plan failed"}},
+			{Workspace: "prod", Result: &PlanResult{Success: true, Artifacts: baseline}},
+		}),
+	}})
+
+	data, err := os.ReadFile(filepath.Join(artifacts, ManifestFile))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest []ArtifactEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if len(manifest) != 1 || manifest[0].Workspace != "prod" ||
+		manifest[0].Plan.TextFile != prod.TextFile || manifest[0].Baseline.TextFile != baseline.TextFile {
+		t.Errorf("manifest = %+v, want the prod workspace plans", manifest)
+	}
+}
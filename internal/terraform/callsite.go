@@ -0,0 +1,301 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// CallSiteIssueKind is the way a module call breaks against the new version
+type CallSiteIssueKind string
+
+const (
+	// IssueRemovedArgument is an argument the call passes that the module no longer accepts
+	IssueRemovedArgument CallSiteIssueKind = "removed_argument"
+
+	// IssueMissingRequired is a required variable the call does not set
+	IssueMissingRequired CallSiteIssueKind = "missing_required"
+
+	// IssueTypeMismatch is a literal argument that no longer converts to the variable's type
+	IssueTypeMismatch CallSiteIssueKind = "type_mismatch"
+
+	// IssueRemovedOutput is a module.<name>.<output> reference to a removed output
+	IssueRemovedOutput CallSiteIssueKind = "removed_output"
+)
+
+// moduleMetaArguments are module block arguments that are not module variables
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// CallSiteIssue is a breakage of our own configuration, located at the line that breaks
+type CallSiteIssue struct {
+	Kind    CallSiteIssueKind
+	Name    string // argument, variable or output
+	File    string
+	Line    int
+	Message string
+}
+
+// String renders the issue as "file:line: message"
+func (i CallSiteIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+// CheckCallSite compares the module block of a module call, and the references to its
+// outputs in the configuration around it, with the schemas of the current and the new
+// version. It reports arguments the new version removed, required variables the call
+// does not set, literal arguments that do not satisfy a changed type, and references
+// to removed outputs.
+func CheckCallSite(module scanner.ModuleInfo, current, latest *ModuleSchema) ([]CallSiteIssue, error) {
+	if latest == nil || module.FilePath == "" {
+		return nil, nil
+	}
+
+	block, err := loadModuleBlock(module)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := checkArguments(module.FilePath, block, current, latest)
+
+	if current != nil {
+		refs, err := removedOutputReferences(filepath.Dir(module.FilePath), module.Name, current, latest)
+		if err != nil {
+			return issues, err
+		}
+		issues = append(issues, refs...)
+	}
+
+	return issues, nil
+}
+
+// MarkReliedDefaults flags the changed defaults our module call relies on, because
+// it does not set the variable. Only those change the behavior of the call.
+func MarkReliedDefaults(module scanner.ModuleInfo, defaults []DefaultChange) error {
+	if len(defaults) == 0 || module.FilePath == "" {
+		return nil
+	}
+
+	block, err := loadModuleBlock(module)
+	if err != nil {
+		return err
+	}
+
+	for i := range defaults {
+		if _, set := block.Body.Attributes[defaults[i].Name]; !set {
+			defaults[i].AffectsCall = true
+			defaults[i].File = module.FilePath
+			defaults[i].Line = block.TypeRange.Start.Line
+		}
+	}
+	return nil
+}
+
+// loadModuleBlock parses the file of a module call and returns its module block
+func loadModuleBlock(module scanner.ModuleInfo) (*hclsyntax.Block, error) {
+	src, err := os.ReadFile(module.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", module.FilePath, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, module.FilePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", module.FilePath, diags.Error())
+	}
+
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == "module" && len(block.Labels) == 1 && block.Labels[0] == module.Name {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("module %q not found in %s", module.Name, module.FilePath)
+}
+
+// checkArguments compares the arguments of a module block with the new variables
+func checkArguments(path string, block *hclsyntax.Block, current, latest *ModuleSchema) []CallSiteIssue {
+	var issues []CallSiteIssue
+
+	names := make([]string, 0, len(block.Body.Attributes))
+	for name := range block.Body.Attributes {
+		if !moduleMetaArguments[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		attr := block.Body.Attributes[name]
+		variable, exists := latest.Variables[name]
+		if !exists {
+			issues = append(issues, CallSiteIssue{
+				Kind:    IssueRemovedArgument,
+				Name:    name,
+				File:    path,
+				Line:    attr.SrcRange.Start.Line,
+				Message: fmt.Sprintf("argument %q is no longer accepted by the module", name),
+			})
+			continue
+		}
+
+		// Only literal values of variables whose type changed are checked
+		if current == nil {
+			continue
+		}
+		if old, ok := current.Variables[name]; !ok || old.Type == variable.Type {
+			continue
+		}
+		if reason := typeMismatch(attr.Expr, variable.Type); reason != "" {
+			issues = append(issues, CallSiteIssue{
+				Kind:    IssueTypeMismatch,
+				Name:    name,
+				File:    path,
+				Line:    attr.SrcRange.Start.Line,
+				Message: fmt.Sprintf("argument %q does not satisfy the new type %s: %s", name, variable.Type, reason),
+			})
+		}
+	}
+
+	var missing []string
+	for name, variable := range latest.Variables {
+		if _, set := block.Body.Attributes[name]; variable.Required && !set {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		issues = append(issues, CallSiteIssue{
+			Kind:    IssueMissingRequired,
+			Name:    name,
+			File:    path,
+			Line:    block.TypeRange.Start.Line,
+			Message: fmt.Sprintf("required variable %q is not set", name),
+		})
+	}
+
+	return issues
+}
+
+// typeMismatch explains why a literal value does not convert to a type constraint,
+// or returns "" when it does, or when the value or the type cannot be evaluated
+func typeMismatch(expr hclsyntax.Expression, typeText string) string {
+	if typeText == "" {
+		return ""
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		return "" // not a literal
+	}
+
+	typeExpr, diags := hclsyntax.ParseExpression([]byte(typeText), "type", hcl.InitialPos)
+	if diags.HasErrors() {
+		return ""
+	}
+	ty, _, diags := typeexpr.TypeConstraintWithDefaults(typeExpr)
+	if diags.HasErrors() {
+		return ""
+	}
+
+	if _, err := convert.Convert(val, ty); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// removedOutputReferences finds references to removed outputs of a module call in
+// the configuration files of dir
+func removedOutputReferences(dir, moduleName string, current, latest *ModuleSchema) ([]CallSiteIssue, error) {
+	removed := map[string]bool{}
+	for name := range current.Outputs {
+		if _, exists := latest.Outputs[name]; !exists {
+			removed[name] = true
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var issues []CallSiteIssue
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(entry.Name()); ext != ".tf" && ext != ".tofu" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return issues, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+
+		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+			if !ok {
+				return nil
+			}
+			output := moduleOutput(expr.Traversal, moduleName)
+			if output != "" && removed[output] {
+				issues = append(issues, CallSiteIssue{
+					Kind:    IssueRemovedOutput,
+					Name:    output,
+					File:    path,
+					Line:    expr.SrcRange.Start.Line,
+					Message: fmt.Sprintf("module.%s.%s refers to an output the module no longer has", moduleName, output),
+				})
+			}
+			return nil
+		})
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool {
+		if issues[a].File != issues[b].File {
+			return issues[a].File < issues[b].File
+		}
+		return issues[a].Line < issues[b].Line
+	})
+	return issues, nil
+}
+
+// moduleOutput returns the output a module.<name>.<output> traversal refers to, skipping
+// the instance key of modules with count or for_each, or "" for other traversals
+func moduleOutput(traversal hcl.Traversal, moduleName string) string {
+	if len(traversal) < 3 || traversal.RootName() != "module" {
+		return ""
+	}
+	if attr, ok := traversal[1].(hcl.TraverseAttr); !ok || attr.Name != moduleName {
+		return ""
+	}
+	for _, step := range traversal[2:] {
+		switch s := step.(type) {
+		case hcl.TraverseAttr:
+			return s.Name
+		case hcl.TraverseIndex:
+			continue
+		default:
+			return ""
+		}
+	}
+	return ""
+}
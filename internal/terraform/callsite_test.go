@@ -0,0 +1,145 @@
+package terraform
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/heyjobs/terranovate/internal/scanner"
+)
+
+func TestCheckCallSite(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.tf")
+	writeTF(t, main, `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "4.0.0"
+
+  name            = "main"
+  azs             = "eu-west-1a"
+  private_subnets = var.private_subnets
+  legacy_flag     = true
+  tags            = { Team = "platform" }
+}
+`)
+	writeTF(t, filepath.Join(dir, "outputs.tf"), `output "nat_ips" {
+  value = module.vpc.nat_public_ips
+}
+
+output "vpc_id" {
+  value = module.vpc.vpc_id
+}
+
+output "other" {
+  value = module.other.nat_public_ips
+}
+`)
+
+	current := &ModuleSchema{
+		Variables: map[string]Variable{
+			"name":            {Type: "string"},
+			"azs":             {Type: "string"},
+			"private_subnets": {Type: "string"},
+			"legacy_flag":     {Type: "bool"},
+			"tags":            {Type: "map(string)"},
+		},
+		Outputs: map[string]Output{"vpc_id": {}, "nat_public_ips": {}},
+	}
+	latest := &ModuleSchema{
+		Variables: map[string]Variable{
+			"name":            {Type: "string"},
+			"azs":             {Type: "list(string)"},
+			"private_subnets": {Type: "list(string)"},
+			"tags":            {Type: "map(number)"},
+			"cidr":            {Type: "string", Required: true},
+		},
+		Outputs: map[string]Output{"vpc_id": {}},
+	}
+
+	issues, err := CheckCallSite(scanner.ModuleInfo{Name: "vpc", FilePath: main}, current, latest)
+	if err != nil {
+		t.Fatalf("CheckCallSite() error = %v", err)
+	}
+
+	type located struct {
+		Kind CallSiteIssueKind
+		Name string
+		File string
+		Line int
+	}
+	var got []located
+	for _, issue := range issues {
+		got = append(got, located{issue.Kind, issue.Name, filepath.Base(issue.File), issue.Line})
+	}
+
+	// azs = "eu-west-1a" cannot become a list, a reference cannot be checked, and
+	// a map of strings that are not numbers breaks map(number)
+	want := []located{
+		{IssueTypeMismatch, "azs", "main.tf", 6},
+		{IssueRemovedArgument, "legacy_flag", "main.tf", 8},
+		{IssueTypeMismatch, "tags", "main.tf", 9},
+		{IssueMissingRequired, "cidr", "main.tf", 1},
+		{IssueRemovedOutput, "nat_public_ips", "outputs.tf", 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckCallSite() = %+v\nwant %+v", got, want)
+	}
+
+	if want := main + `:1: required variable "cidr" is not set`; issues[3].String() != want {
+		t.Errorf("String() = %q, want %q", issues[3].String(), want)
+	}
+}
+
+func TestModuleOutput(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"module.vpc.vpc_id", "vpc_id"},
+		{`module.vpc["a"].vpc_id`, "vpc_id"},
+		{"module.vpc[0].vpc_id", "vpc_id"},
+		{"module.other.vpc_id", ""},
+		{"var.vpc", ""},
+		{"module.vpc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			traversal, diags := hclsyntax.ParseTraversalAbs([]byte(tt.expr), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("invalid traversal %s: %s", tt.expr, diags.Error())
+			}
+			if got := moduleOutput(traversal, "vpc"); got != tt.want {
+				t.Errorf("moduleOutput(%s) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkReliedDefaults(t *testing.T) {
+	main := filepath.Join(t.TempDir(), "main.tf")
+	writeTF(t, main, `
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+
+  single_nat_gateway = true
+}
+`)
+
+	defaults := []DefaultChange{
+		{Name: "enable_nat_gateway", OldDefault: "true", NewDefault: "false"},
+		{Name: "single_nat_gateway", OldDefault: "false", NewDefault: "true"},
+	}
+	if err := MarkReliedDefaults(scanner.ModuleInfo{Name: "vpc", FilePath: main}, defaults); err != nil {
+		t.Fatalf("MarkReliedDefaults() error = %v", err)
+	}
+
+	if !defaults[0].AffectsCall || defaults[0].File != main || defaults[0].Line != 2 {
+		t.Errorf("enable_nat_gateway = %+v, want flagged at %s:2", defaults[0], main)
+	}
+	if defaults[1].AffectsCall {
+		t.Errorf("single_nat_gateway = %+v, want not flagged since the call sets it", defaults[1])
+	}
+}
@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Engine is the infrastructure-as-code tool that runs plans: Terraform or OpenTofu
+type Engine string
+
+const (
+	// EngineTerraform runs HashiCorp Terraform
+	EngineTerraform Engine = "terraform"
+
+	// EngineOpenTofu runs OpenTofu
+	EngineOpenTofu Engine = "tofu"
+)
+
+// ParseEngine parses an engine setting. "auto" and "" return an empty engine, to be detected.
+func ParseEngine(setting string) (Engine, error) {
+	switch strings.ToLower(setting) {
+	case "", "auto":
+		return "", nil
+	case "terraform":
+		return EngineTerraform, nil
+	case "tofu", "opentofu":
+		return EngineOpenTofu, nil
+	default:
+		return "", fmt.Errorf("invalid engine %q: must be terraform, tofu or auto", setting)
+	}
+}
+
+// DetectEngine resolves an engine setting for a directory. With "auto" it looks for a
+// .opentofu-version or .terraform-version file in the directory and its parents, then
+// for .tofu files, then for a terraform or tofu binary in PATH, defaulting to Terraform.
+func DetectEngine(setting, dir string) (Engine, error) {
+	engine, err := ParseEngine(setting)
+	if err != nil || engine != "" {
+		return engine, err
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		for current := abs; ; current = filepath.Dir(current) {
+			if fileExists(filepath.Join(current, ".opentofu-version")) {
+				return EngineOpenTofu, nil
+			}
+			if fileExists(filepath.Join(current, ".terraform-version")) {
+				return EngineTerraform, nil
+			}
+			if filepath.Dir(current) == current {
+				break
+			}
+		}
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tofu")); len(matches) > 0 {
+		return EngineOpenTofu, nil
+	}
+
+	if _, err := exec.LookPath("terraform"); err == nil {
+		return EngineTerraform, nil
+	}
+	if _, err := exec.LookPath("tofu"); err == nil {
+		return EngineOpenTofu, nil
+	}
+
+	return EngineTerraform, nil
+}
+
+// Binary returns the name of the engine's executable
+func (e Engine) Binary() string {
+	if e == EngineOpenTofu {
+		return "tofu"
+	}
+	return "terraform"
+}
+
+// RegistryHost returns the default module and provider registry of the engine
+func (e Engine) RegistryHost() string {
+	if e == EngineOpenTofu {
+		return "registry.opentofu.org"
+	}
+	return "registry.terraform.io"
+}
+
+// String returns the display name of the engine
+func (e Engine) String() string {
+	if e == EngineOpenTofu {
+		return "OpenTofu"
+	}
+	return "Terraform"
+}
+
+// fileExists reports whether path exists and is a regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,110 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEngine(t *testing.T) {
+	tests := []struct {
+		setting string
+		want    Engine
+		wantErr bool
+	}{
+		{setting: "", want: ""},
+		{setting: "auto", want: ""},
+		{setting: "terraform", want: EngineTerraform},
+		{setting: "tofu", want: EngineOpenTofu},
+		{setting: "OpenTofu", want: EngineOpenTofu},
+		{setting: "pulumi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			got, err := ParseEngine(tt.setting)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEngine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEngine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEngine(t *testing.T) {
+	t.Run("explicit setting", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTF(t, filepath.Join(dir, ".opentofu-version"), "1.8.0\n")
+
+		got, err := DetectEngine("terraform", dir)
+		if err != nil {
+			t.Fatalf("DetectEngine() error = %v", err)
+		}
+		if got != EngineTerraform {
+			t.Errorf("DetectEngine() = %q, want %q", got, EngineTerraform)
+		}
+	})
+
+	t.Run("opentofu version file in parent", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTF(t, filepath.Join(dir, ".opentofu-version"), "1.8.0\n")
+		root := filepath.Join(dir, "envs", "prod")
+		if err := os.MkdirAll(root, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := DetectEngine("auto", root)
+		if err != nil {
+			t.Fatalf("DetectEngine() error = %v", err)
+		}
+		if got != EngineOpenTofu {
+			t.Errorf("DetectEngine() = %q, want %q", got, EngineOpenTofu)
+		}
+	})
+
+	t.Run("terraform version file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTF(t, filepath.Join(dir, ".terraform-version"), "1.9.0\n")
+
+		got, err := DetectEngine("", dir)
+		if err != nil {
+			t.Fatalf("DetectEngine() error = %v", err)
+		}
+		if got != EngineTerraform {
+			t.Errorf("DetectEngine() = %q, want %q", got, EngineTerraform)
+		}
+	})
+
+	t.Run("tofu files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTF(t, filepath.Join(dir, "main.tofu"), "")
+
+		got, err := DetectEngine("", dir)
+		if err != nil {
+			t.Fatalf("DetectEngine() error = %v", err)
+		}
+		if got != EngineOpenTofu {
+			t.Errorf("DetectEngine() = %q, want %q", got, EngineOpenTofu)
+		}
+	})
+
+	t.Run("invalid setting", func(t *testing.T) {
+		if _, err := DetectEngine("terragrunt", t.TempDir()); err == nil {
+			t.Error("DetectEngine() expected error for invalid setting")
+		}
+	})
+}
+
+func TestEngineDefaults(t *testing.T) {
+	if got := EngineOpenTofu.Binary(); got != "tofu" {
+		t.Errorf("Binary() = %q, want tofu", got)
+	}
+	if got := EngineOpenTofu.RegistryHost(); got != "registry.opentofu.org" {
+		t.Errorf("RegistryHost() = %q, want registry.opentofu.org", got)
+	}
+	if got := EngineTerraform.RegistryHost(); got != "registry.terraform.io" {
+		t.Errorf("RegistryHost() = %q, want registry.terraform.io", got)
+	}
+}
@@ -0,0 +1,350 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/rs/zerolog/log"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ParseModuleSchema reads the variable and output blocks of the module in dir. As in
+// OpenTofu, a .tofu file replaces the .tf file with the same name.
+func ParseModuleSchema(dir string) (*ModuleSchema, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module directory: %w", err)
+	}
+
+	schema := &ModuleSchema{
+		Variables: make(map[string]Variable),
+		Outputs:   make(map[string]Output),
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".tofu":
+		case ".tf":
+			if fileExists(filepath.Join(dir, strings.TrimSuffix(name, ".tf")+".tofu")) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if err := parseSchemaFile(filepath.Join(dir, name), schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+// parseSchemaFile adds the variable and output blocks of one file to schema
+func parseSchemaFile(path string, schema *ModuleSchema) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	for _, block := range body.Blocks {
+		if len(block.Labels) != 1 {
+			continue
+		}
+		name := block.Labels[0]
+
+		switch block.Type {
+		case "variable":
+			schema.Variables[name] = parseVariable(block.Body, src)
+		case "output":
+			output := Output{}
+			if attr, ok := block.Body.Attributes["description"]; ok {
+				output.Description = stringValue(attr.Expr)
+			}
+			if attr, ok := block.Body.Attributes["sensitive"]; ok {
+				output.Sensitive = boolValue(attr.Expr, false)
+			}
+			schema.Outputs[name] = output
+		}
+	}
+
+	return nil
+}
+
+// parseVariable converts a variable block. A variable is required when it has no default.
+func parseVariable(body *hclsyntax.Body, src []byte) Variable {
+	variable := Variable{Required: true, Nullable: true}
+
+	if attr, ok := body.Attributes["type"]; ok {
+		variable.Type = expressionText(attr.Expr, src)
+	}
+	if attr, ok := body.Attributes["description"]; ok {
+		variable.Description = stringValue(attr.Expr)
+	}
+	if attr, ok := body.Attributes["default"]; ok {
+		variable.Required = false
+		variable.Default = defaultValue(attr.Expr, src)
+	}
+	if attr, ok := body.Attributes["nullable"]; ok {
+		variable.Nullable = boolValue(attr.Expr, true)
+	}
+	if attr, ok := body.Attributes["sensitive"]; ok {
+		variable.Sensitive = boolValue(attr.Expr, false)
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "validation" {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["condition"]; ok {
+			variable.Validations = append(variable.Validations, expressionText(attr.Expr, src))
+		}
+	}
+
+	return variable
+}
+
+// expressionText returns the source of an expression with whitespace collapsed,
+// e.g. "list(object({ name = string }))"
+func expressionText(expr hclsyntax.Expression, src []byte) string {
+	text := string(expr.Range().SliceBytes(src))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// defaultValue evaluates a default as the JSON value the registry would report,
+// falling back to the expression source when it cannot be evaluated
+func defaultValue(expr hclsyntax.Expression, src []byte) interface{} {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		return expressionText(expr, src)
+	}
+	if val.IsNull() {
+		return nil
+	}
+
+	data, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return expressionText(expr, src)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return expressionText(expr, src)
+	}
+	return out
+}
+
+// stringValue evaluates a string literal, returning "" for anything else
+func stringValue(expr hclsyntax.Expression) string {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.String {
+		return ""
+	}
+	return val.AsString()
+}
+
+// boolValue evaluates a bool literal, returning fallback for anything else
+func boolValue(expr hclsyntax.Expression, fallback bool) bool {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.Bool {
+		return fallback
+	}
+	return val.True()
+}
+
+// parseGitModuleSource splits a git module source into the URL to fetch, the
+// subdirectory of the module and the ref query parameter. Sources without a scheme,
+// such as github.com/owner/repo, are fetched over HTTPS.
+func parseGitModuleSource(source string) (string, string, string, error) {
+	source = strings.TrimPrefix(source, "git::")
+
+	var ref string
+	if base, query, ok := strings.Cut(source, "?"); ok {
+		source = base
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid git source query %q: %w", query, err)
+		}
+		ref = values.Get("ref")
+	}
+
+	// The module subdirectory follows a double slash after the scheme
+	start := 0
+	if i := strings.Index(source, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	var subdir string
+	if i := strings.Index(source[start:], "//"); i >= 0 {
+		subdir = strings.Trim(source[start+i+2:], "/")
+		source = source[:start+i]
+	}
+
+	if start == 0 && !strings.HasPrefix(source, "git@") {
+		source = "https://" + source
+	}
+	if source == "" || source == "https://" {
+		return "", "", "", fmt.Errorf("invalid git module source")
+	}
+
+	return source, subdir, ref, nil
+}
+
+// fetchGitSchema checks out a git module source at ref and parses its schema
+func (sc *SchemaComparator) fetchGitSchema(ctx context.Context, source, ref string) (*ModuleSchema, error) {
+	repoURL, subdir, sourceRef, err := parseGitModuleSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = sourceRef
+	}
+	if ref == "" {
+		return nil, fmt.Errorf("git source %s has no ref to compare", source)
+	}
+
+	dir, err := sc.checkoutGitRef(ctx, repoURL, ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseModuleSchema(filepath.Join(dir, filepath.FromSlash(subdir)))
+}
+
+// checkoutGitRef returns a directory holding repoURL at ref, shallow-fetching it into
+// the cache unless it is already there. Versions are tried with and without a "v"
+// prefix, since tags are compared without it.
+func (sc *SchemaComparator) checkoutGitRef(ctx context.Context, repoURL, ref string) (string, error) {
+	cacheDir, err := sc.gitCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(repoURL))
+	repoDir := filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+
+	candidates := []string{ref}
+	if strings.HasPrefix(ref, "v") {
+		candidates = append(candidates, strings.TrimPrefix(ref, "v"))
+	} else {
+		candidates = append(candidates, "v"+ref)
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		dir := filepath.Join(repoDir, url.PathEscape(candidate))
+		if _, err := os.Stat(dir); err == nil {
+			log.Debug().Str("repository", repoURL).Str("ref", candidate).Msg("using cached module checkout")
+			return dir, nil
+		}
+
+		if lastErr = shallowFetch(ctx, repoURL, candidate, dir); lastErr == nil {
+			return dir, nil
+		}
+	}
+
+	return "", lastErr
+}
+
+// shallowFetch fetches a single ref of a repository into dir, without history.
+// Fetching by ref rather than cloning a branch also works for tags and commit SHAs.
+func shallowFetch(ctx context.Context, repoURL, ref, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create module cache: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-")
+	if err != nil {
+		return fmt.Errorf("failed to create module cache: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", repoURL, ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	} {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	os.RemoveAll(filepath.Join(tmp, ".git"))
+	if err := os.Rename(tmp, dir); err != nil && !fileExistsDir(dir) {
+		return fmt.Errorf("failed to cache module checkout: %w", err)
+	}
+
+	log.Debug().Str("repository", repoURL).Str("ref", ref).Msg("fetched module source")
+	return nil
+}
+
+// gitCacheDir returns the directory git module checkouts are cached in
+func (sc *SchemaComparator) gitCacheDir() (string, error) {
+	dir := sc.cacheDir
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		dir = filepath.Join(userCache, "terranovate")
+	}
+	return filepath.Join(dir, "git-modules"), nil
+}
+
+// registryDownloadSource asks the registry where a module version is downloaded
+// from, which for most public modules is a git repository
+func (sc *SchemaComparator) registryDownloadSource(ctx context.Context, source, version string) (string, error) {
+	host, parts, _, err := sc.registryModulePath(source)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("https://%s/v1/modules/%s/%s/%s/%s/download",
+		host, parts[0], parts[1], parts[2], version)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := sc.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	location := resp.Header.Get("X-Terraform-Get")
+	if location == "" {
+		return "", fmt.Errorf("registry returned no download location (status %d)", resp.StatusCode)
+	}
+	return location, nil
+}
+
+// fileExistsDir reports whether path exists and is a directory
+func fileExistsDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,342 @@
+package terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/scanner"
+)
+
+const moduleVariables = `
+variable "name" {
+  type        = string
+  description = "Name of the VPC"
+}
+
+variable "azs" {
+  type    = list(string)
+  default = ["eu-west-1a", "eu-west-1b"]
+}
+
+variable "enable_nat_gateway" {
+  type     = bool
+  default  = true
+  nullable = false
+}
+
+variable "tags" {
+  type      = map(
+    string
+  )
+  default   = null
+  sensitive = true
+
+  validation {
+    condition     = var.tags == null || length(var.tags) < 50
+    error_message = "Too many tags."
+  }
+}
+
+output "vpc_id" {
+  description = "ID of the VPC"
+  value       = "vpc-123"
+}
+`
+
+func TestParseModuleSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeTF(t, filepath.Join(dir, "variables.tf"), moduleVariables)
+	writeTF(t, filepath.Join(dir, "outputs.tf"), `output "old" { value = 1 }`)
+	writeTF(t, filepath.Join(dir, "outputs.tofu"), `output "secret" {
+  value     = 1
+  sensitive = true
+}`)
+
+	schema, err := ParseModuleSchema(dir)
+	if err != nil {
+		t.Fatalf("ParseModuleSchema() error = %v", err)
+	}
+
+	want := map[string]Variable{
+		"name":               {Type: "string", Description: "Name of the VPC", Required: true, Nullable: true},
+		"azs":                {Type: "list(string)", Default: []interface{}{"eu-west-1a", "eu-west-1b"}, Nullable: true},
+		"enable_nat_gateway": {Type: "bool", Default: true, Nullable: false},
+		"tags": {
+			Type:        "map( string )",
+			Nullable:    true,
+			Sensitive:   true,
+			Validations: []string{"var.tags == null || length(var.tags) < 50"},
+		},
+	}
+	if !reflect.DeepEqual(schema.Variables, want) {
+		t.Errorf("Variables = %#v\nwant %#v", schema.Variables, want)
+	}
+
+	wantOutputs := map[string]Output{
+		"vpc_id": {Description: "ID of the VPC"},
+		"secret": {Sensitive: true},
+	}
+	if !reflect.DeepEqual(schema.Outputs, wantOutputs) {
+		t.Errorf("Outputs = %#v, want %#v (outputs.tofu replaces outputs.tf)", schema.Outputs, wantOutputs)
+	}
+}
+
+func TestParseGitModuleSource(t *testing.T) {
+	tests := []struct {
+		source     string
+		wantURL    string
+		wantSubdir string
+		wantRef    string
+	}{
+		{"git::https://github.com/acme/modules.git//vpc?ref=v1.2.0", "https://github.com/acme/modules.git", "vpc", "v1.2.0"},
+		{"git::ssh://git@github.com/acme/vpc.git?ref=main", "ssh://git@github.com/acme/vpc.git", "", "main"},
+		{"git@github.com:acme/modules.git//modules/vpc?ref=1.0.0", "git@github.com:acme/modules.git", "modules/vpc", "1.0.0"},
+		{"github.com/acme/terraform-vpc", "https://github.com/acme/terraform-vpc", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			url, subdir, ref, err := parseGitModuleSource(tt.source)
+			if err != nil {
+				t.Fatalf("parseGitModuleSource() error = %v", err)
+			}
+			if url != tt.wantURL || subdir != tt.wantSubdir || ref != tt.wantRef {
+				t.Errorf("parseGitModuleSource() = %q, %q, %q, want %q, %q, %q",
+					url, subdir, ref, tt.wantURL, tt.wantSubdir, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestCompareSchemasGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	git("init", "-q")
+	writeTF(t, filepath.Join(repo, "vpc", "main.tf"), `
+variable "cidr" { type = string }
+variable "legacy" { default = "x" }
+output "id" { value = 1 }
+`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1.0.0")
+
+	writeTF(t, filepath.Join(repo, "vpc", "main.tf"), `
+variable "cidr" { type = list(string) }
+variable "region" { type = string }
+`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "v2")
+	git("tag", "v2.0.0")
+
+	comparator := NewSchemaComparator()
+	comparator.SetCacheDir(t.TempDir())
+
+	module := scanner.ModuleInfo{
+		Source:     "git::file://" + repo + "//vpc?ref=v1.0.0",
+		SourceType: scanner.SourceTypeGit,
+	}
+	// The latest version is reported without the tag's "v" prefix
+	changes, err := comparator.CompareSchemas(context.Background(), module, "v1.0.0", "2.0.0")
+	if err != nil || changes == nil {
+		t.Fatalf("CompareSchemas() = %v, %v", changes, err)
+	}
+
+	if len(changes.AddedRequiredVars) != 1 || changes.AddedRequiredVars[0].Name != "region" {
+		t.Errorf("AddedRequiredVars = %+v, want region", changes.AddedRequiredVars)
+	}
+	if len(changes.RemovedVars) != 1 || changes.RemovedVars[0].Name != "legacy" {
+		t.Errorf("RemovedVars = %+v, want legacy", changes.RemovedVars)
+	}
+	if len(changes.ChangedVarTypes) != 1 || changes.ChangedVarTypes[0].Type != "string → list(string)" {
+		t.Errorf("ChangedVarTypes = %+v, want cidr string → list(string)", changes.ChangedVarTypes)
+	}
+	if len(changes.RemovedOutputs) != 1 || changes.RemovedOutputs[0].Name != "id" {
+		t.Errorf("RemovedOutputs = %+v, want id", changes.RemovedOutputs)
+	}
+}
+
+// registryStub serves module versions of acme/vpc/aws from a TLS test server: the
+// JSON of GET /v1/modules/acme/vpc/aws/<version> and the X-Terraform-Get header of
+// its /download
+func registryStub(t *testing.T, modules map[string]string, download string) *SchemaComparator {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := strings.TrimPrefix(r.URL.Path, "/v1/modules/acme/vpc/aws/")
+		if v, ok := strings.CutSuffix(version, "/download"); ok {
+			if download != "" {
+				w.Header().Set("X-Terraform-Get", strings.ReplaceAll(download, "{version}", v))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, ok := modules[version]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	comparator := NewSchemaComparator()
+	comparator.httpClient = server.Client()
+	comparator.SetRegistryHost(server.Listener.Addr().String())
+	comparator.SetCacheDir(t.TempDir())
+	return comparator
+}
+
+func TestCompareSchemasRegistrySubmodule(t *testing.T) {
+	dir := t.TempDir()
+	callFile := filepath.Join(dir, "main.tf")
+	writeTF(t, callFile, `module "sg" {
+  source  = "acme/vpc/aws//modules/security-group"
+  version = "1.0.0"
+  name    = "web"
+}
+`)
+	module := scanner.ModuleInfo{
+		Name:       "sg",
+		Source:     "acme/vpc/aws//modules/security-group",
+		SourceType: scanner.SourceTypeRegistry,
+		FilePath:   callFile,
+		Line:       1,
+	}
+
+	// The root module has no name variable, the submodule does
+	release := func(submoduleOutput string) string {
+		return `{
+  "root": {"inputs": [{"name": "cidr", "type": "string", "required": true}], "outputs": [{"name": "vpc_id"}]},
+  "submodules": [{
+    "path": "modules/security-group",
+    "inputs": [{"name": "name", "type": "string", "required": true}],
+    "outputs": [{"name": "` + submoduleOutput + `"}]
+  }]
+}`
+	}
+	comparator := registryStub(t, map[string]string{
+		"1.0.0": release("id"),
+		"2.0.0": release("security_group_id"),
+	}, "")
+
+	changes, err := comparator.CompareSchemas(context.Background(), module, "1.0.0", "2.0.0")
+	if err != nil || changes == nil {
+		t.Fatalf("CompareSchemas() = %v, %v", changes, err)
+	}
+	if len(changes.CallSiteIssues) != 0 || len(changes.RemovedVars) != 0 || len(changes.AddedRequiredVars) != 0 {
+		t.Errorf("CompareSchemas() checked the root module: %+v", changes)
+	}
+	if len(changes.RemovedOutputs) != 1 || changes.RemovedOutputs[0].Name != "id" {
+		t.Errorf("RemovedOutputs = %+v, want id of the submodule", changes.RemovedOutputs)
+	}
+
+	// A registry without documented inputs is read from the subdirectory of its git source
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeTF(t, filepath.Join(repo, "main.tf"), `variable "cidr" { type = string }`)
+	writeTF(t, filepath.Join(repo, "modules", "security-group", "main.tf"), `variable "name" { type = string }`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1.0.0")
+	writeTF(t, filepath.Join(repo, "modules", "security-group", "main.tf"), `
+variable "name" { type = string }
+variable "vpc_id" { type = string }
+`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "v2")
+	git("tag", "v2.0.0")
+
+	comparator = registryStub(t, map[string]string{
+		"1.0.0": `{"root": {}}`,
+		"2.0.0": `{"root": {}}`,
+	}, "git::file://"+repo+"?ref=v{version}")
+
+	changes, err = comparator.CompareSchemas(context.Background(), module, "1.0.0", "2.0.0")
+	if err != nil || changes == nil {
+		t.Fatalf("CompareSchemas() = %v, %v", changes, err)
+	}
+	if len(changes.AddedRequiredVars) != 1 || changes.AddedRequiredVars[0].Name != "vpc_id" {
+		t.Errorf("AddedRequiredVars = %+v, want vpc_id of the submodule", changes.AddedRequiredVars)
+	}
+	if len(changes.CallSiteIssues) != 1 || changes.CallSiteIssues[0].Kind != IssueMissingRequired {
+		t.Errorf("CallSiteIssues = %+v, want only the missing vpc_id", changes.CallSiteIssues)
+	}
+}
+
+func TestCompareSchemasEmptySchema(t *testing.T) {
+	dir := t.TempDir()
+	callFile := filepath.Join(dir, "main.tf")
+	writeTF(t, callFile, `module "vpc" {
+  source  = "acme/vpc/aws"
+  version = "1.0.0"
+  cidr    = "10.0.0.0/16"
+}
+`)
+	module := scanner.ModuleInfo{
+		Name:       "vpc",
+		Source:     "acme/vpc/aws",
+		SourceType: scanner.SourceTypeRegistry,
+		FilePath:   callFile,
+		Line:       1,
+	}
+
+	// The latest release documents no inputs and is not downloaded from git
+	comparator := registryStub(t, map[string]string{
+		"1.0.0": `{"root": {"inputs": [{"name": "cidr", "type": "string", "required": true}]}}`,
+		"2.0.0": `{"root": {"inputs": [], "outputs": []}}`,
+	}, "https://example.com/vpc-{version}.zip")
+
+	changes, err := comparator.CompareSchemas(context.Background(), module, "1.0.0", "2.0.0")
+	if err != nil {
+		t.Fatalf("CompareSchemas() error = %v", err)
+	}
+	if changes != nil {
+		t.Errorf("CompareSchemas() = %+v, want nil for an unknown schema", changes)
+	}
+}
+
+func TestGitSourceWithSubdir(t *testing.T) {
+	tests := []struct {
+		source string
+		subdir string
+		want   string
+	}{
+		{"git::https://github.com/acme/vpc.git?ref=v1.0.0", "modules/sg", "git::https://github.com/acme/vpc.git//modules/sg?ref=v1.0.0"},
+		{"git::https://github.com/acme/modules.git//vpc?ref=v1.0.0", "modules/sg", "git::https://github.com/acme/modules.git//vpc/modules/sg?ref=v1.0.0"},
+		{"git@github.com:acme/vpc.git", "modules/sg", "git@github.com:acme/vpc.git//modules/sg"},
+		{"git::https://github.com/acme/vpc.git?ref=v1.0.0", "", "git::https://github.com/acme/vpc.git?ref=v1.0.0"},
+	}
+
+	for _, tt := range tests {
+		if got := gitSourceWithSubdir(tt.source, tt.subdir); got != tt.want {
+			t.Errorf("gitSourceWithSubdir(%q, %q) = %q, want %q", tt.source, tt.subdir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package terraform
+
+import (
+	"reflect"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// PlanDiff separates the changes an upgrade introduces from changes that are
+// already planned on the base branch
+type PlanDiff struct {
+	Replacements []ResourceChange // resources the upgrade replaces
+	Deletions    []ResourceChange // resources the upgrade deletes
+	Creations    []ResourceChange // resources the upgrade creates
+	Updates      []ResourceChange // in-place updates, limited to the attributes the upgrade changes
+	Drift        []ResourceChange // changes planned on the base branch as well
+}
+
+// HasChanges reports whether the upgrade introduces any resource change
+func (d *PlanDiff) HasChanges() bool {
+	return d != nil && len(d.Replacements)+len(d.Deletions)+len(d.Creations)+len(d.Updates) > 0
+}
+
+// DiffPlans compares the plan of the base branch with the plan after the upgrade,
+// matching resources by workspace and address. A change also planned on the base branch with the
+// same actions is drift; an update gains only the attributes the base plan did not change.
+func DiffPlans(baseline, upgraded *PlanResult) *PlanDiff {
+	diff := &PlanDiff{}
+	if upgraded == nil {
+		return diff
+	}
+
+	before := map[string]ResourceChange{}
+	if baseline != nil {
+		for _, change := range baseline.DetailedChanges {
+			before[change.Workspace+"\x00"+change.Address] = change
+		}
+	}
+
+	for _, change := range upgraded.DetailedChanges {
+		kind := changeKind(change)
+		if kind == "" {
+			continue
+		}
+
+		base, existed := before[change.Workspace+"\x00"+change.Address]
+		if existed && changeKind(base) == kind {
+			if kind != "update" {
+				diff.Drift = append(diff.Drift, change)
+				continue
+			}
+
+			// Keep only attributes the base branch does not change already
+			added := subtract(change.ChangedAttributes, base.ChangedAttributes)
+			if len(added) == 0 {
+				diff.Drift = append(diff.Drift, change)
+				continue
+			}
+			diff.Drift = append(diff.Drift, base)
+			change.ChangedAttributes = added
+		}
+
+		switch kind {
+		case "replace":
+			diff.Replacements = append(diff.Replacements, change)
+		case "delete":
+			diff.Deletions = append(diff.Deletions, change)
+		case "create":
+			diff.Creations = append(diff.Creations, change)
+		case "update":
+			diff.Updates = append(diff.Updates, change)
+		}
+	}
+
+	return diff
+}
+
+// CombineDiffs merges the plan diffs of several root modules
+func CombineDiffs(diffs ...*PlanDiff) *PlanDiff {
+	combined := &PlanDiff{}
+	for _, d := range diffs {
+		if d == nil {
+			continue
+		}
+		combined.Replacements = append(combined.Replacements, d.Replacements...)
+		combined.Deletions = append(combined.Deletions, d.Deletions...)
+		combined.Creations = append(combined.Creations, d.Creations...)
+		combined.Updates = append(combined.Updates, d.Updates...)
+		combined.Drift = append(combined.Drift, d.Drift...)
+	}
+	return combined
+}
+
+// changeKind classifies a resource change as replace, delete, create or update,
+// or "" for no-op and read
+func changeKind(change ResourceChange) string {
+	hasCreate, hasDelete, hasUpdate := false, false, false
+	for _, action := range change.Action {
+		switch action {
+		case "create":
+			hasCreate = true
+		case "delete":
+			hasDelete = true
+		case "update":
+			hasUpdate = true
+		}
+	}
+
+	switch {
+	case hasCreate && hasDelete:
+		return "replace"
+	case hasDelete:
+		return "delete"
+	case hasCreate:
+		return "create"
+	case hasUpdate:
+		return "update"
+	default:
+		return ""
+	}
+}
+
+// subtract returns the values of a that are not in b
+func subtract(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, v := range b {
+		exclude[v] = true
+	}
+
+	var out []string
+	for _, v := range a {
+		if !exclude[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// changedAttributes returns the sorted top-level attributes whose value differs
+// between before and after, counting values only known after apply as changed
+func changedAttributes(change *tfjson.Change) []string {
+	before, _ := change.Before.(map[string]interface{})
+	after, _ := change.After.(map[string]interface{})
+	unknown, _ := change.AfterUnknown.(map[string]interface{})
+
+	seen := map[string]bool{}
+	for key := range before {
+		seen[key] = true
+	}
+	for key := range after {
+		seen[key] = true
+	}
+
+	var attrs []string
+	for key := range seen {
+		if isUnknown(unknown[key]) || !reflect.DeepEqual(before[key], after[key]) {
+			attrs = append(attrs, key)
+		}
+	}
+
+	sort.Strings(attrs)
+	return attrs
+}
+
+// isUnknown reports whether an after_unknown value marks anything as unknown
+func isUnknown(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case map[string]interface{}:
+		for _, nested := range val {
+			if isUnknown(nested) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range val {
+			if isUnknown(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestDiffPlans(t *testing.T) {
+	baseline := &PlanResult{DetailedChanges: []ResourceChange{
+		{Address: "aws_s3_bucket.logs", Action: []string{"update"}, ChangedAttributes: []string{"tags"}},
+		{Address: "aws_iam_role.old", Action: []string{"delete"}},
+		{Address: "aws_vpc.this", Action: []string{"update"}, ChangedAttributes: []string{"tags"}},
+		{Address: "aws_subnet.a", Action: []string{"no-op"}},
+	}}
+	upgraded := &PlanResult{DetailedChanges: []ResourceChange{
+		{Address: "aws_s3_bucket.logs", Action: []string{"update"}, ChangedAttributes: []string{"tags"}},
+		{Address: "aws_iam_role.old", Action: []string{"delete"}},
+		{Address: "aws_vpc.this", Action: []string{"update"}, ChangedAttributes: []string{"enable_dns_hostnames", "tags"}},
+		{Address: "aws_subnet.a", Action: []string{"delete", "create"}},
+		{Address: "aws_route_table.private", Action: []string{"delete"}},
+		{Address: "aws_flow_log.this", Action: []string{"create"}},
+		{Address: "aws_eip.nat", Action: []string{"no-op"}},
+	}}
+
+	diff := DiffPlans(baseline, upgraded)
+
+	if got := addresses(diff.Replacements); !reflect.DeepEqual(got, []string{"aws_subnet.a"}) {
+		t.Errorf("Replacements = %v", got)
+	}
+	if got := addresses(diff.Deletions); !reflect.DeepEqual(got, []string{"aws_route_table.private"}) {
+		t.Errorf("Deletions = %v", got)
+	}
+	if got := addresses(diff.Creations); !reflect.DeepEqual(got, []string{"aws_flow_log.this"}) {
+		t.Errorf("Creations = %v", got)
+	}
+	if len(diff.Updates) != 1 || !reflect.DeepEqual(diff.Updates[0].ChangedAttributes, []string{"enable_dns_hostnames"}) {
+		t.Errorf("Updates = %+v, want aws_vpc.this changing enable_dns_hostnames", diff.Updates)
+	}
+	if got := addresses(diff.Drift); !reflect.DeepEqual(got, []string{"aws_s3_bucket.logs", "aws_iam_role.old", "aws_vpc.this"}) {
+		t.Errorf("Drift = %v", got)
+	}
+
+	summary := AnalyzePlanDiff(diff)
+	if summary.TotalReplace != 1 || summary.TotalDelete != 1 || summary.TotalModify != 1 || len(summary.Drift) != 3 {
+		t.Errorf("AnalyzePlanDiff() = %+v", summary)
+	}
+	if !HasCriticalChanges(summary) {
+		t.Error("HasCriticalChanges() = false, want true")
+	}
+}
+
+func TestDiffPlansWithoutBaseline(t *testing.T) {
+	diff := DiffPlans(nil, &PlanResult{DetailedChanges: []ResourceChange{
+		{Address: "aws_vpc.this", Action: []string{"update"}},
+	}})
+	if len(diff.Updates) != 1 || len(diff.Drift) != 0 {
+		t.Errorf("DiffPlans(nil, ...) = %+v, want every change attributed to the update", diff)
+	}
+}
+
+func TestChangedAttributes(t *testing.T) {
+	got := changedAttributes(&tfjson.Change{
+		Before:       map[string]interface{}{"cidr_block": "10.0.0.0/16", "tags": map[string]interface{}{"a": "1"}, "id": "vpc-1"},
+		After:        map[string]interface{}{"cidr_block": "10.0.0.0/16", "tags": map[string]interface{}{"a": "2"}},
+		AfterUnknown: map[string]interface{}{"id": true},
+	})
+	if want := []string{"id", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changedAttributes() = %v, want %v", got, want)
+	}
+}
+
+func addresses(changes []ResourceChange) []string {
+	var out []string
+	for _, change := range changes {
+		out = append(out, change.Address)
+	}
+	return out
+}
@@ -0,0 +1,446 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/rs/zerolog/log"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ProviderSchemaChanges are the changes between two provider versions in the schemas
+// of the resource and data source types the configuration uses
+type ProviderSchemaChanges struct {
+	HasChanges           bool
+	RemovedResources     []string                  // resource types that no longer exist
+	RemovedDataSources   []string                  // data source types that no longer exist
+	RemovedAttributes    []ProviderAttributeChange // attributes and blocks that were removed or renamed
+	NewRequired          []ProviderAttributeChange // attributes and blocks that became required
+	DeprecatedAttributes []ProviderAttributeChange // attributes that became deprecated
+}
+
+// ProviderAttributeChange is a change to one attribute or nested block of a resource
+// or data source type
+type ProviderAttributeChange struct {
+	Type         string // resource or data source type, e.g. aws_s3_bucket
+	IsDataSource bool
+	Path         string           // attribute path, with nested blocks joined by dots
+	RenamedTo    string           // attribute that probably replaces a removed one, "" if unknown
+	Usages       []AttributeUsage // resource blocks the change affects
+}
+
+// AttributeUsage is a resource block affected by a change: one that sets a removed or
+// deprecated attribute, or one that does not set a newly required attribute
+type AttributeUsage struct {
+	Address string // e.g. aws_s3_bucket.logs
+	File    string
+	Line    int
+}
+
+// String renders the change as "type.path" with a data. prefix for data sources
+func (c ProviderAttributeChange) String() string {
+	name := c.Type + "." + c.Path
+	if c.IsDataSource {
+		name = "data." + name
+	}
+	return name
+}
+
+// ProviderSchemaComparator fetches the schemas of provider versions by running
+// terraform providers schema -json in a throwaway configuration
+type ProviderSchemaComparator struct {
+	binaryPath   string
+	env          map[string]string
+	cache        *Cache
+	registryHost string
+}
+
+// NewProviderSchemaComparator creates a comparator running the given binary
+func NewProviderSchemaComparator(binaryPath string, env map[string]string) *ProviderSchemaComparator {
+	return &ProviderSchemaComparator{
+		binaryPath:   binaryPath,
+		env:          env,
+		registryHost: EngineTerraform.RegistryHost(),
+	}
+}
+
+// SetRegistryHost sets the registry that provider sources without a hostname resolve
+// to, e.g. registry.opentofu.org for OpenTofu, when matching lock file entries
+func (c *ProviderSchemaComparator) SetRegistryHost(host string) {
+	if host != "" {
+		c.registryHost = host
+	}
+}
+
+// SetCache shares the provider plugin cache with the comparator's inits
+func (c *ProviderSchemaComparator) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// CompareProvider diffs the schemas of the provider at the version in use, read from
+// the lock file next to the provider requirement when there is one, and at the target
+// version, limited to the resource and data source types in resources
+func (c *ProviderSchemaComparator) CompareProvider(ctx context.Context, provider scanner.ProviderInfo, currentVersion, latestVersion string, resources []scanner.ResourceInfo) (*ProviderSchemaChanges, error) {
+	current := c.currentConstraint(provider, currentVersion)
+
+	currentSchema, err := c.FetchSchema(ctx, provider.Name, provider.Source, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema of current version: %w", err)
+	}
+	latestSchema, err := c.FetchSchema(ctx, provider.Name, provider.Source, "= "+latestVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema of %s: %w", latestVersion, err)
+	}
+
+	return DiffProviderSchemas(currentSchema, latestSchema, resources), nil
+}
+
+// currentConstraint pins the version in use: the locked version, or currentVersion
+// without a lock file. The provider's own constraint (e.g. ~> 5.0) is never used, as
+// init would install the newest matching release, often the target version itself.
+func (c *ProviderSchemaComparator) currentConstraint(provider scanner.ProviderInfo, currentVersion string) string {
+	if locked := lockedProviderVersion(filepath.Dir(provider.FilePath), provider.Source, c.registryHost); locked != "" {
+		return "= " + locked
+	}
+	return "= " + strings.TrimPrefix(currentVersion, "v")
+}
+
+// FetchSchema installs a provider matching the version constraint in a temporary
+// directory and returns its schema
+func (c *ProviderSchemaComparator) FetchSchema(ctx context.Context, name, source, constraint string) (*tfjson.ProviderSchema, error) {
+	dir, err := os.MkdirTemp("", "terranovate-provider-schema-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider schema sandbox: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := fmt.Sprintf("terraform {\n  required_providers {\n    %s = {\n      source  = %q\n      version = %q\n    }\n  }\n}\n",
+		name, source, constraint)
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write provider schema configuration: %w", err)
+	}
+
+	env := c.env
+	if c.cache != nil {
+		env = c.cache.Env(env)
+	}
+	runner, err := New(dir, c.binaryPath, env)
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.InitWithoutBackend(ctx, false); err != nil {
+		return nil, err
+	}
+
+	tf, err := runner.newTerraform()
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := tf.ProvidersSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("terraform providers schema failed: %w", err)
+	}
+
+	// The only provider is keyed by its full address, e.g. registry.terraform.io/hashicorp/aws
+	for address, schema := range schemas.Schemas {
+		log.Debug().Str("provider", address).Str("version", constraint).Msg("fetched provider schema")
+		return schema, nil
+	}
+	return nil, fmt.Errorf("no schema returned for provider %s", source)
+}
+
+// lockedProviderVersion returns the version of a provider pinned in the
+// .terraform.lock.hcl of dir, or "" when there is none. Lock files record full
+// provider addresses, so a source without a hostname is looked up on defaultHost:
+// with OpenTofu, hashicorp/aws is registry.opentofu.org/hashicorp/aws.
+func lockedProviderVersion(dir, source, defaultHost string) string {
+	path := filepath.Join(dir, ".terraform.lock.hcl")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return ""
+	}
+
+	address := strings.ToLower(providerAddress(source, defaultHost))
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+		if strings.ToLower(block.Labels[0]) != address {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["version"]; ok {
+			return stringValue(attr.Expr)
+		}
+	}
+	return ""
+}
+
+// providerAddress returns the full address of a provider source, adding defaultHost
+// when the source names no hostname
+func providerAddress(source, defaultHost string) string {
+	if parts := strings.Split(source, "/"); len(parts) == 3 {
+		return source
+	}
+	return defaultHost + "/" + source
+}
+
+// DiffProviderSchemas compares two provider schemas for the resource and data source
+// types used in resources: removed types, removed or renamed attributes and nested
+// blocks, attributes and blocks that became required, and newly deprecated attributes,
+// each with the resource blocks it affects
+func DiffProviderSchemas(current, latest *tfjson.ProviderSchema, resources []scanner.ResourceInfo) *ProviderSchemaChanges {
+	changes := &ProviderSchemaChanges{}
+	if current == nil || latest == nil {
+		return changes
+	}
+
+	seen := map[string]bool{}
+	for _, resource := range resources {
+		key := fmt.Sprintf("%t/%s", resource.IsDataSource, resource.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		currentSchemas, latestSchemas := current.ResourceSchemas, latest.ResourceSchemas
+		if resource.IsDataSource {
+			currentSchemas, latestSchemas = current.DataSourceSchemas, latest.DataSourceSchemas
+		}
+
+		before, ok := currentSchemas[resource.Type]
+		if !ok || before.Block == nil {
+			continue // not a type of this provider
+		}
+		after, ok := latestSchemas[resource.Type]
+		if !ok || after.Block == nil {
+			if resource.IsDataSource {
+				changes.RemovedDataSources = append(changes.RemovedDataSources, resource.Type)
+			} else {
+				changes.RemovedResources = append(changes.RemovedResources, resource.Type)
+			}
+			continue
+		}
+
+		diffBlocks(changes, ProviderAttributeChange{Type: resource.Type, IsDataSource: resource.IsDataSource}, "", before.Block, after.Block)
+	}
+
+	locateUsages(changes, resources)
+
+	sort.Strings(changes.RemovedResources)
+	sort.Strings(changes.RemovedDataSources)
+	for _, list := range [][]ProviderAttributeChange{changes.RemovedAttributes, changes.NewRequired, changes.DeprecatedAttributes} {
+		sort.Slice(list, func(i, j int) bool { return list[i].String() < list[j].String() })
+	}
+
+	changes.HasChanges = len(changes.RemovedResources)+len(changes.RemovedDataSources)+
+		len(changes.RemovedAttributes)+len(changes.NewRequired)+len(changes.DeprecatedAttributes) > 0
+	return changes
+}
+
+// diffBlocks compares the attributes and nested blocks of a schema block
+func diffBlocks(changes *ProviderSchemaChanges, base ProviderAttributeChange, prefix string, before, after *tfjson.SchemaBlock) {
+	change := func(name string) ProviderAttributeChange {
+		c := base
+		c.Path = prefix + name
+		return c
+	}
+
+	var removed []string
+	for name := range before.Attributes {
+		if _, ok := after.Attributes[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	renames := renamedAttributes(removed, before, after)
+	for _, name := range removed {
+		c := change(name)
+		if renamed, ok := renames[name]; ok {
+			c.RenamedTo = prefix + renamed
+		}
+		changes.RemovedAttributes = append(changes.RemovedAttributes, c)
+	}
+
+	for name, attr := range after.Attributes {
+		old, existed := before.Attributes[name]
+		if attr.Required && (!existed || !old.Required) {
+			changes.NewRequired = append(changes.NewRequired, change(name))
+		}
+		if existed && attr.Deprecated && !old.Deprecated {
+			changes.DeprecatedAttributes = append(changes.DeprecatedAttributes, change(name))
+		}
+	}
+
+	for name, block := range before.NestedBlocks {
+		newBlock, ok := after.NestedBlocks[name]
+		if !ok {
+			changes.RemovedAttributes = append(changes.RemovedAttributes, change(name))
+			continue
+		}
+		if block.Block != nil && newBlock.Block != nil {
+			diffBlocks(changes, base, prefix+name+".", block.Block, newBlock.Block)
+		}
+	}
+	for name, block := range after.NestedBlocks {
+		old, existed := before.NestedBlocks[name]
+		if block.MinItems > 0 && (!existed || old.MinItems == 0) {
+			changes.NewRequired = append(changes.NewRequired, change(name))
+		}
+	}
+}
+
+// locateUsages finds the resource blocks each attribute change affects, from the
+// attributes the scanner found set in them
+func locateUsages(changes *ProviderSchemaChanges, resources []scanner.ResourceInfo) {
+	affected := func(c ProviderAttributeChange) []scanner.ResourceInfo {
+		var matches []scanner.ResourceInfo
+		for _, r := range resources {
+			if r.Type == c.Type && r.IsDataSource == c.IsDataSource {
+				matches = append(matches, r)
+			}
+		}
+		return matches
+	}
+
+	for _, list := range [][]ProviderAttributeChange{changes.RemovedAttributes, changes.DeprecatedAttributes} {
+		for i := range list {
+			for _, r := range affected(list[i]) {
+				if attr, ok := r.SetsAttribute(list[i].Path); ok {
+					list[i].Usages = append(list[i].Usages, AttributeUsage{Address: r.Address(), File: r.FilePath, Line: attr.Line})
+				}
+			}
+		}
+	}
+
+	// A newly required nested attribute only matters where its block is set
+	for i, c := range changes.NewRequired {
+		parent, _, nested := cutLast(c.Path, ".")
+		for _, r := range affected(c) {
+			if _, ok := r.SetsAttribute(c.Path); ok {
+				continue
+			}
+			line := r.Line
+			if nested {
+				attr, ok := r.SetsAttribute(parent)
+				if !ok {
+					continue
+				}
+				line = attr.Line
+			}
+			changes.NewRequired[i].Usages = append(changes.NewRequired[i].Usages, AttributeUsage{Address: r.Address(), File: r.FilePath, Line: line})
+		}
+	}
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
+
+// renamedAttributes pairs removed attributes with added attributes of the same type,
+// when exactly one added attribute has that type
+func renamedAttributes(removed []string, before, after *tfjson.SchemaBlock) map[string]string {
+	var added []string
+	for name := range after.Attributes {
+		if _, ok := before.Attributes[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+
+	renames := map[string]string{}
+	claimed := map[string]bool{}
+	for _, name := range removed {
+		old := before.Attributes[name]
+		var candidates []string
+		for _, newName := range added {
+			newType := after.Attributes[newName].AttributeType
+			if !claimed[newName] && old.AttributeType != cty.NilType && newType != cty.NilType && old.AttributeType.Equals(newType) {
+				candidates = append(candidates, newName)
+			}
+		}
+		if len(candidates) == 1 {
+			renames[name] = candidates[0]
+			claimed[candidates[0]] = true
+		}
+	}
+	return renames
+}
+
+// HasBreakingProviderSchemaChanges reports whether the configuration uses a type or
+// attribute the new version removed, or misses one it now requires. Attribute
+// changes no resource block is affected by are informational.
+func HasBreakingProviderSchemaChanges(changes *ProviderSchemaChanges) bool {
+	if changes == nil {
+		return false
+	}
+	return len(changes.RemovedResources)+len(changes.RemovedDataSources)+
+		affectingChanges(changes.RemovedAttributes)+affectingChanges(changes.NewRequired) > 0
+}
+
+// BreakingProviderSchemaChanges describes the breaking changes
+// HasBreakingProviderSchemaChanges counts, one per entry, e.g. for an AI prompt
+func BreakingProviderSchemaChanges(changes *ProviderSchemaChanges) []string {
+	if changes == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, t := range changes.RemovedResources {
+		lines = append(lines, fmt.Sprintf("Removed resource type %s", t))
+	}
+	for _, t := range changes.RemovedDataSources {
+		lines = append(lines, fmt.Sprintf("Removed data source %s", t))
+	}
+	for _, c := range changes.RemovedAttributes {
+		if len(c.Usages) == 0 {
+			continue
+		}
+		line := fmt.Sprintf("Removed attribute %s, set by %s", c, usageAddresses(c.Usages))
+		if c.RenamedTo != "" {
+			line += fmt.Sprintf(" (probably renamed to %s)", c.RenamedTo)
+		}
+		lines = append(lines, line)
+	}
+	for _, c := range changes.NewRequired {
+		if len(c.Usages) > 0 {
+			lines = append(lines, fmt.Sprintf("Newly required attribute %s, not set by %s", c, usageAddresses(c.Usages)))
+		}
+	}
+	return lines
+}
+
+// usageAddresses joins the addresses of the resource blocks a change affects
+func usageAddresses(usages []AttributeUsage) string {
+	addresses := make([]string, len(usages))
+	for i, u := range usages {
+		addresses[i] = u.Address
+	}
+	return strings.Join(addresses, ", ")
+}
+
+// affectingChanges counts the attribute changes that affect a resource block
+func affectingChanges(changes []ProviderAttributeChange) int {
+	n := 0
+	for _, c := range changes {
+		if len(c.Usages) > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,257 @@
+package terraform
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiffProviderSchemas(t *testing.T) {
+	block := func(attrs map[string]*tfjson.SchemaAttribute, nested map[string]*tfjson.SchemaBlockType) *tfjson.Schema {
+		return &tfjson.Schema{Block: &tfjson.SchemaBlock{Attributes: attrs, NestedBlocks: nested}}
+	}
+
+	current := &tfjson.ProviderSchema{
+		ResourceSchemas: map[string]*tfjson.Schema{
+			"aws_s3_bucket": block(map[string]*tfjson.SchemaAttribute{
+				"bucket": {AttributeType: cty.String, Optional: true},
+				"acl":    {AttributeType: cty.String, Optional: true},
+				"region": {AttributeType: cty.String, Optional: true},
+			}, map[string]*tfjson.SchemaBlockType{
+				"versioning": {Block: &tfjson.SchemaBlock{Attributes: map[string]*tfjson.SchemaAttribute{
+					"enabled":    {AttributeType: cty.Bool, Optional: true},
+					"mfa_delete": {AttributeType: cty.Bool, Optional: true},
+				}}},
+				"logging": {Block: &tfjson.SchemaBlock{}},
+			}),
+			"aws_instance": block(map[string]*tfjson.SchemaAttribute{
+				"ami": {AttributeType: cty.String, Required: true},
+			}, nil),
+			"aws_unused": block(map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Optional: true},
+			}, nil),
+			"aws_legacy": block(nil, nil),
+		},
+		DataSourceSchemas: map[string]*tfjson.Schema{
+			"aws_ami": block(map[string]*tfjson.SchemaAttribute{
+				"owners": {AttributeType: cty.List(cty.String), Optional: true},
+			}, nil),
+			"aws_old": block(nil, nil),
+		},
+	}
+	latest := &tfjson.ProviderSchema{
+		ResourceSchemas: map[string]*tfjson.Schema{
+			"aws_s3_bucket": block(map[string]*tfjson.SchemaAttribute{
+				"bucket":      {AttributeType: cty.String, Required: true},
+				"bucket_acl":  {AttributeType: cty.String, Optional: true},
+				"region":      {AttributeType: cty.String, Optional: true, Deprecated: true},
+				"force_apply": {AttributeType: cty.Bool, Optional: true},
+			}, map[string]*tfjson.SchemaBlockType{
+				"versioning": {Block: &tfjson.SchemaBlock{Attributes: map[string]*tfjson.SchemaAttribute{
+					"enabled": {AttributeType: cty.Bool, Required: true},
+				}}},
+				"lifecycle_rule": {Block: &tfjson.SchemaBlock{}, MinItems: 1},
+			}),
+			"aws_instance": block(map[string]*tfjson.SchemaAttribute{
+				"ami": {AttributeType: cty.String, Required: true},
+			}, nil),
+		},
+		DataSourceSchemas: map[string]*tfjson.Schema{
+			"aws_ami": block(map[string]*tfjson.SchemaAttribute{
+				"owners": {AttributeType: cty.List(cty.String), Optional: true},
+			}, nil),
+		},
+	}
+
+	resources := []scanner.ResourceInfo{
+		{Type: "aws_s3_bucket", Name: "logs", FilePath: "main.tf", Line: 1, Attributes: []scanner.AttributeInfo{
+			{Path: "bucket", Line: 2},
+			{Path: "acl", Line: 3},
+			{Path: "region", Line: 4},
+			{Path: "versioning", Line: 5},
+		}},
+		{Type: "aws_s3_bucket", Name: "assets", FilePath: "main.tf", Line: 10},
+		{Type: "aws_instance", Name: "web"},
+		{Type: "aws_legacy", Name: "old"},
+		{Type: "aws_old", Name: "lookup", IsDataSource: true},
+		{Type: "aws_ami", Name: "ubuntu", IsDataSource: true},
+		{Type: "google_storage_bucket", Name: "other"},
+	}
+
+	changes := DiffProviderSchemas(current, latest, resources)
+
+	if !changes.HasChanges {
+		t.Fatal("DiffProviderSchemas() HasChanges = false, want true")
+	}
+	if want := []string{"aws_legacy"}; !reflect.DeepEqual(changes.RemovedResources, want) {
+		t.Errorf("RemovedResources = %v, want %v", changes.RemovedResources, want)
+	}
+	if want := []string{"aws_old"}; !reflect.DeepEqual(changes.RemovedDataSources, want) {
+		t.Errorf("RemovedDataSources = %v, want %v", changes.RemovedDataSources, want)
+	}
+
+	wantRemoved := []ProviderAttributeChange{
+		{Type: "aws_s3_bucket", Path: "acl", RenamedTo: "bucket_acl", Usages: []AttributeUsage{
+			{Address: "aws_s3_bucket.logs", File: "main.tf", Line: 3},
+		}},
+		{Type: "aws_s3_bucket", Path: "logging"},
+		{Type: "aws_s3_bucket", Path: "versioning.mfa_delete"},
+	}
+	if !reflect.DeepEqual(changes.RemovedAttributes, wantRemoved) {
+		t.Errorf("RemovedAttributes = %+v, want %+v", changes.RemovedAttributes, wantRemoved)
+	}
+
+	wantRequired := []ProviderAttributeChange{
+		{Type: "aws_s3_bucket", Path: "bucket", Usages: []AttributeUsage{
+			{Address: "aws_s3_bucket.assets", File: "main.tf", Line: 10},
+		}},
+		{Type: "aws_s3_bucket", Path: "lifecycle_rule", Usages: []AttributeUsage{
+			{Address: "aws_s3_bucket.logs", File: "main.tf", Line: 1},
+			{Address: "aws_s3_bucket.assets", File: "main.tf", Line: 10},
+		}},
+		{Type: "aws_s3_bucket", Path: "versioning.enabled", Usages: []AttributeUsage{
+			{Address: "aws_s3_bucket.logs", File: "main.tf", Line: 5},
+		}},
+	}
+	if !reflect.DeepEqual(changes.NewRequired, wantRequired) {
+		t.Errorf("NewRequired = %+v, want %+v", changes.NewRequired, wantRequired)
+	}
+
+	wantDeprecated := []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "region", Usages: []AttributeUsage{
+		{Address: "aws_s3_bucket.logs", File: "main.tf", Line: 4},
+	}}}
+	if !reflect.DeepEqual(changes.DeprecatedAttributes, wantDeprecated) {
+		t.Errorf("DeprecatedAttributes = %+v, want %+v", changes.DeprecatedAttributes, wantDeprecated)
+	}
+
+	if !HasBreakingProviderSchemaChanges(changes) {
+		t.Error("HasBreakingProviderSchemaChanges() = false, want true")
+	}
+
+	unchanged := DiffProviderSchemas(current, current, resources)
+	if unchanged.HasChanges || HasBreakingProviderSchemaChanges(unchanged) {
+		t.Errorf("DiffProviderSchemas() of identical schemas = %+v, want no changes", unchanged)
+	}
+}
+
+func TestProviderAttributeChangeString(t *testing.T) {
+	tests := []struct {
+		change ProviderAttributeChange
+		want   string
+	}{
+		{ProviderAttributeChange{Type: "aws_s3_bucket", Path: "acl"}, "aws_s3_bucket.acl"},
+		{ProviderAttributeChange{Type: "aws_ami", Path: "filter.name", IsDataSource: true}, "data.aws_ami.filter.name"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestHasBreakingProviderSchemaChanges(t *testing.T) {
+	used := []AttributeUsage{{Address: "aws_s3_bucket.logs", File: "main.tf", Line: 3}}
+
+	tests := []struct {
+		name    string
+		changes *ProviderSchemaChanges
+		want    bool
+	}{
+		{name: "nil", changes: nil, want: false},
+		{name: "removed type", changes: &ProviderSchemaChanges{RemovedResources: []string{"aws_legacy"}}, want: true},
+		{name: "removed attribute we set", changes: &ProviderSchemaChanges{RemovedAttributes: []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "acl", Usages: used}}}, want: true},
+		{name: "required attribute we miss", changes: &ProviderSchemaChanges{NewRequired: []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "bucket", Usages: used}}}, want: true},
+		{name: "removed attribute we never set", changes: &ProviderSchemaChanges{RemovedAttributes: []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "logging"}}}, want: false},
+		{name: "required attribute we always set", changes: &ProviderSchemaChanges{NewRequired: []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "bucket"}}}, want: false},
+		{name: "deprecated attribute we set", changes: &ProviderSchemaChanges{DeprecatedAttributes: []ProviderAttributeChange{{Type: "aws_s3_bucket", Path: "region", Usages: used}}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasBreakingProviderSchemaChanges(tt.changes); got != tt.want {
+				t.Errorf("HasBreakingProviderSchemaChanges() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockedProviderVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTF(t, filepath.Join(dir, ".terraform.lock.hcl"), `provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:abc=",
+  ]
+}
+
+provider "registry.opentofu.org/hashicorp/random" {
+  version = "3.6.0"
+}
+`)
+
+	tests := []struct {
+		source string
+		host   string
+		want   string
+	}{
+		{"hashicorp/aws", "registry.terraform.io", "5.31.0"},
+		{"HashiCorp/AWS", "registry.terraform.io", "5.31.0"},
+		{"registry.terraform.io/hashicorp/aws", "registry.opentofu.org", "5.31.0"},
+		{"hashicorp/aws", "registry.opentofu.org", ""},
+		{"hashicorp/random", "registry.opentofu.org", "3.6.0"},
+		{"registry.opentofu.org/hashicorp/random", "registry.terraform.io", "3.6.0"},
+		{"hashicorp/random", "registry.terraform.io", ""},
+		{"hashicorp/google", "registry.terraform.io", ""},
+	}
+
+	for _, tt := range tests {
+		if got := lockedProviderVersion(dir, tt.source, tt.host); got != tt.want {
+			t.Errorf("lockedProviderVersion(%q, %q) = %q, want %q", tt.source, tt.host, got, tt.want)
+		}
+	}
+
+	if got := lockedProviderVersion(t.TempDir(), "hashicorp/aws", "registry.terraform.io"); got != "" {
+		t.Errorf("lockedProviderVersion() without lock file = %q, want empty", got)
+	}
+}
+
+func TestProviderSchemaCurrentConstraint(t *testing.T) {
+	locked := t.TempDir()
+	writeTF(t, filepath.Join(locked, ".terraform.lock.hcl"), `provider "registry.terraform.io/hashicorp/aws" {
+  version = "5.31.0"
+}
+`)
+	unlocked := t.TempDir()
+
+	comparator := NewProviderSchemaComparator("", nil)
+	tests := []struct {
+		name    string
+		dir     string
+		current string
+		want    string
+	}{
+		{"locked version", locked, "5.0", "= 5.31.0"},
+		{"no lock file pins the current version, not the constraint", unlocked, "5.0.0", "= 5.0.0"},
+		{"no lock file with v prefix", unlocked, "v5.0.0", "= 5.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := scanner.ProviderInfo{
+				Name:     "aws",
+				Source:   "hashicorp/aws",
+				Version:  "~> 5.0",
+				FilePath: filepath.Join(tt.dir, "versions.tf"),
+			}
+			if got := comparator.currentConstraint(provider, tt.current); got != tt.want {
+				t.Errorf("currentConstraint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
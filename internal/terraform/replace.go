@@ -0,0 +1,155 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	sensitiveValue = "(sensitive value)"
+	unknownValue   = "(known after apply)"
+	nullValue      = "null"
+)
+
+// actionReasonText describes the action_reason values terraform reports in plan JSON
+var actionReasonText = map[string]string{
+	"replace_because_tainted":           "the resource is tainted",
+	"replace_because_cannot_update":     "an attribute cannot be updated in-place",
+	"replace_by_request":                "replacement was requested with -replace",
+	"replace_by_triggers":               "a replace_triggered_by reference changed",
+	"delete_because_no_resource_config": "the resource was removed from the configuration",
+	"delete_because_no_module":          "its module was removed from the configuration",
+	"delete_because_wrong_repetition":   "count or for_each changed to the other repetition mode",
+	"delete_because_count_index":        "its count index is out of range",
+	"delete_because_each_key":           "its for_each key no longer exists",
+	"delete_because_no_moved_target":    "the target of a moved block does not exist",
+	"read_because_config_unknown":       "the configuration is known only after apply",
+	"read_because_dependency_pending":   "a dependency has pending changes",
+	"read_because_check_nested":         "it is used by a check block",
+}
+
+// DescribeActionReason returns a human-readable description of a plan action_reason
+func DescribeActionReason(reason string) string {
+	if text, ok := actionReasonText[reason]; ok {
+		return text
+	}
+	return strings.ReplaceAll(reason, "_", " ")
+}
+
+// newResourceChange converts a plan resource change, including its replace paths
+func newResourceChange(rc *tfjson.ResourceChange, actionReason string) ResourceChange {
+	change := ResourceChange{
+		Address:           rc.Address,
+		ResourceType:      rc.Type,
+		Action:            make([]string, 0, len(rc.Change.Actions)),
+		ChangedAttributes: changedAttributes(rc.Change),
+		ActionReason:      actionReason,
+		Importing:         rc.Change.Importing != nil,
+	}
+
+	for _, action := range rc.Change.Actions {
+		change.Action = append(change.Action, string(action))
+	}
+
+	if rc.PreviousAddress != "" && rc.PreviousAddress != rc.Address {
+		change.PreviousAddress = rc.PreviousAddress
+	}
+
+	for _, replacePath := range rc.Change.ReplacePaths {
+		steps, ok := replacePath.([]interface{})
+		if !ok {
+			continue
+		}
+
+		path := formatPath(steps)
+		change.ReplaceTriggers = append(change.ReplaceTriggers, path)
+		change.ReplacePaths = append(change.ReplacePaths, AttributeChange{
+			Path:   path,
+			Before: renderValue(lookupPath(rc.Change.Before, steps), isMarked(rc.Change.BeforeSensitive, steps), false),
+			After: renderValue(lookupPath(rc.Change.After, steps), isMarked(rc.Change.AfterSensitive, steps),
+				isMarked(rc.Change.AfterUnknown, steps)),
+		})
+	}
+
+	return change
+}
+
+// formatPath renders replace path steps as an attribute path, e.g. ingress[0].cidr_blocks
+func formatPath(steps []interface{}) string {
+	var path strings.Builder
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			if path.Len() > 0 {
+				path.WriteString(".")
+			}
+			path.WriteString(s)
+		case float64:
+			path.WriteString(fmt.Sprintf("[%d]", int(s)))
+		default:
+			path.WriteString(fmt.Sprintf("[%v]", s))
+		}
+	}
+	return path.String()
+}
+
+// lookupPath follows path steps through a decoded JSON value
+func lookupPath(value interface{}, steps []interface{}) interface{} {
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = obj[s]
+		case float64:
+			list, ok := value.([]interface{})
+			if !ok || int(s) < 0 || int(s) >= len(list) {
+				return nil
+			}
+			value = list[int(s)]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// isMarked reports whether a path is marked in a sensitive or after_unknown structure,
+// where true on an enclosing value marks everything below it. A value with any marked
+// part counts as marked, so partly sensitive values are redacted as a whole.
+func isMarked(marks interface{}, steps []interface{}) bool {
+	for _, step := range steps {
+		if b, ok := marks.(bool); ok {
+			return b
+		}
+		marks = lookupPath(marks, []interface{}{step})
+	}
+	return isUnknown(marks)
+}
+
+// renderValue renders an attribute value for display, redacting sensitive values
+func renderValue(value interface{}, sensitive, unknown bool) string {
+	switch {
+	case sensitive:
+		return sensitiveValue
+	case unknown:
+		return unknownValue
+	case value == nil:
+		return nullValue
+	}
+
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,119 @@
+package terraform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heyjobs/terranovate/internal/version"
+)
+
+const samplePlanJSON = `{
+  "format_version": "1.2",
+  "terraform_version": "1.9.0",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"ami": "ami-111", "user_data": "secret", "ebs_block_device": [{"volume_size": 20}]},
+        "after": {"ami": "ami-222", "user_data": "other", "ebs_block_device": [{"volume_size": 40}]},
+        "after_unknown": {"id": true},
+        "before_sensitive": {"user_data": true},
+        "after_sensitive": {"user_data": true},
+        "replace_paths": [["ami"], ["user_data"], ["ebs_block_device", 0, "volume_size"]]
+      },
+      "action_reason": "replace_because_cannot_update"
+    },
+    {
+      "address": "aws_s3_bucket.new",
+      "previous_address": "aws_s3_bucket.old",
+      "type": "aws_s3_bucket",
+      "name": "new",
+      "change": {"actions": ["no-op"], "before": {}, "after": {}}
+    },
+    {
+      "address": "aws_iam_role.legacy",
+      "type": "aws_iam_role",
+      "name": "legacy",
+      "change": {"actions": ["delete"], "before": {"name": "legacy"}, "after": null},
+      "action_reason": "delete_because_no_resource_config"
+    },
+    {
+      "address": "aws_vpc.main",
+      "type": "aws_vpc",
+      "name": "main",
+      "change": {"actions": ["no-op"], "before": {}, "after": {}, "importing": {"id": "vpc-123"}}
+    }
+  ]
+}`
+
+func TestParsePlan(t *testing.T) {
+	r := &Runner{}
+	result, err := r.parsePlan([]byte(samplePlanJSON), true)
+	if err != nil {
+		t.Fatalf("parsePlan() error = %v", err)
+	}
+
+	if result.ResourcesAdd != 1 || result.ResourcesDestroy != 2 {
+		t.Errorf("counts = add %d, destroy %d, want 1 and 2", result.ResourcesAdd, result.ResourcesDestroy)
+	}
+
+	web := result.DetailedChanges[0]
+	if web.ActionReason != "replace_because_cannot_update" {
+		t.Errorf("ActionReason = %q", web.ActionReason)
+	}
+	wantPaths := []AttributeChange{
+		{Path: "ami", Before: `"ami-111"`, After: `"ami-222"`},
+		{Path: "user_data", Before: "(sensitive value)", After: "(sensitive value)"},
+		{Path: "ebs_block_device[0].volume_size", Before: "20", After: "40"},
+	}
+	if !reflect.DeepEqual(web.ReplacePaths, wantPaths) {
+		t.Errorf("ReplacePaths = %+v, want %+v", web.ReplacePaths, wantPaths)
+	}
+	if !reflect.DeepEqual(web.ReplaceTriggers, []string{"ami", "user_data", "ebs_block_device[0].volume_size"}) {
+		t.Errorf("ReplaceTriggers = %v", web.ReplaceTriggers)
+	}
+
+	if moved := result.DetailedChanges[1]; moved.PreviousAddress != "aws_s3_bucket.old" {
+		t.Errorf("PreviousAddress = %q, want aws_s3_bucket.old", moved.PreviousAddress)
+	}
+	if imported := result.DetailedChanges[3]; !imported.Importing {
+		t.Error("Importing = false, want true")
+	}
+
+	summary := AnalyzeResourceChanges(result)
+	if summary.TotalReplace != 1 || summary.TotalDelete != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if got := summary.ResourcesToDelete[0].Reason; got != "Terraform plans this because the resource was removed from the configuration" {
+		t.Errorf("delete reason = %q", got)
+	}
+
+	formatted := FormatResourceChanges(summary)
+	for _, want := range []string{
+		"- aws_instance.web (an attribute cannot be updated in-place)",
+		`ami: "ami-111" → "ami-222" (forces replacement)`,
+		"user_data: (sensitive value) → (sensitive value)",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("FormatResourceChanges() does not contain %q\nGot: %s", want, formatted)
+		}
+	}
+	if strings.Contains(formatted, "secret") {
+		t.Errorf("FormatResourceChanges() leaks a sensitive value:\n%s", formatted)
+	}
+}
+
+func TestDescribeResourceChangeMarkers(t *testing.T) {
+	got := describeResourceChange(version.ResourceChange{
+		Address:   "aws_s3_bucket.new",
+		MovedFrom: "aws_s3_bucket.old",
+		Imported:  true,
+	})
+	if want := "- aws_s3_bucket.new [moved from aws_s3_bucket.old] [imported]"; got != want {
+		t.Errorf("describeResourceChange() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,628 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/rs/zerolog/log"
+)
+
+// RootPlan is the plan result of one root module
+type RootPlan struct {
+	Dir        string
+	Validation *ValidationResult // Checks run before, or instead of, the plan
+	Result     *PlanResult       // Plan with the edited files, nil when not planned
+	Baseline   *PlanResult       // Plan with the original files, nil when not planned
+	Diff       *PlanDiff         // Changes the edit introduces over the baseline
+}
+
+// FileEdit is an edited file with its content before the edit
+type FileEdit struct {
+	Path     string
+	Original []byte // nil skips the baseline plan
+}
+
+// RootFinder maps edited files to the root modules that include them
+type RootFinder struct {
+	basePath   string
+	configured []string
+
+	// Loaded on first use
+	roots   []string
+	callers map[string][]string // local module directory -> directories calling it
+}
+
+// NewRootFinder creates a RootFinder for the directory tree under basePath. When
+// configuredRoots is set (paths or globs relative to basePath), only those directories
+// are roots; otherwise a root is any directory with a backend, cloud or provider block.
+func NewRootFinder(basePath string, configuredRoots []string) *RootFinder {
+	if basePath == "" {
+		basePath = "."
+	}
+	return &RootFinder{basePath: basePath, configured: configuredRoots}
+}
+
+// Roots returns all root module directories
+func (f *RootFinder) Roots() []string {
+	f.load()
+	return f.roots
+}
+
+// RootsFor returns the root modules affected by an edited file: its own directory
+// when that is a root, or every root that calls the directory as a local module
+func (f *RootFinder) RootsFor(file string) []string {
+	f.load()
+
+	dir := filepath.Clean(filepath.Dir(file))
+	seen := map[string]bool{dir: true}
+	queue := []string{dir}
+	var affected []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if f.isRoot(current) {
+			affected = append(affected, current)
+			continue
+		}
+
+		for _, caller := range f.callers[current] {
+			if !seen[caller] {
+				seen[caller] = true
+				queue = append(queue, caller)
+			}
+		}
+	}
+
+	sort.Strings(affected)
+	return affected
+}
+
+// isRoot reports whether dir is a root module
+func (f *RootFinder) isRoot(dir string) bool {
+	for _, root := range f.roots {
+		if root == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// load walks the tree once, collecting roots and local module calls
+func (f *RootFinder) load() {
+	if f.callers != nil {
+		return
+	}
+	f.callers = make(map[string][]string)
+
+	detected := map[string]bool{}
+	err := filepath.Walk(f.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// Skip provider caches and hidden directories
+			if path != f.basePath && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".tf" && ext != ".tofu" {
+			return nil
+		}
+
+		dir := filepath.Clean(filepath.Dir(path))
+		isRoot, sources := inspectFile(path)
+		if isRoot {
+			detected[dir] = true
+		}
+		for _, source := range sources {
+			callee := filepath.Clean(filepath.Join(dir, source))
+			f.callers[callee] = append(f.callers[callee], dir)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("path", f.basePath).Msg("failed to walk directory for root modules")
+	}
+
+	if len(f.configured) > 0 {
+		for _, pattern := range f.configured {
+			matches, err := filepath.Glob(filepath.Join(f.basePath, pattern))
+			if err != nil {
+				log.Warn().Err(err).Str("pattern", pattern).Msg("invalid root module pattern")
+				continue
+			}
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					f.roots = append(f.roots, filepath.Clean(match))
+				}
+			}
+		}
+	} else {
+		for dir := range detected {
+			f.roots = append(f.roots, dir)
+		}
+	}
+
+	sort.Strings(f.roots)
+}
+
+// inspectFile reports whether a file marks its directory as a root module and
+// returns the sources of local module calls it contains
+func inspectFile(path string) (bool, []string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, nil
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return false, nil
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	isRoot := false
+	var sources []string
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "provider":
+			isRoot = true
+		case "terraform":
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "backend" || nested.Type == "cloud" {
+					isRoot = true
+				}
+			}
+		case "module":
+			attr, ok := block.Body.Attributes["source"]
+			if !ok {
+				continue
+			}
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || val.IsNull() || !val.IsKnown() {
+				continue
+			}
+			source := val.AsString()
+			if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	return isRoot, sources
+}
+
+// RootPlanner runs init and plan in the root modules affected by edited files. Plans
+// run in sandbox copies of the repository, never in the working tree.
+type RootPlanner struct {
+	finder        *RootFinder
+	binaryPath    string
+	env           map[string]string
+	cache         *Cache
+	concurrency   int
+	keepSandbox   bool
+	level         ValidationLevel
+	mockProviders []string
+	artifactsDir  string
+	inputs        []RootInputs
+
+	// Saved plans, written to the manifest in artifactsDir
+	manifestMu sync.Mutex
+	manifest   []ArtifactEntry
+
+	// The plugin cache is not safe for concurrent installs, so inits sharing it run one at a time
+	initMu sync.Mutex
+}
+
+// NewRootPlanner creates a RootPlanner for the directory tree under basePath
+func NewRootPlanner(basePath string, configuredRoots []string, binaryPath string, env map[string]string) *RootPlanner {
+	return &RootPlanner{
+		finder:      NewRootFinder(basePath, configuredRoots),
+		binaryPath:  binaryPath,
+		env:         env,
+		concurrency: 1,
+		level:       ValidationPlan,
+	}
+}
+
+// SetArtifactsDir keeps every plan in dir, under <name>/<root>/ with a baseline/
+// subdirectory for the base branch plan, and lists them in dir/manifest.json
+func (p *RootPlanner) SetArtifactsDir(dir string) {
+	p.artifactsDir = dir
+}
+
+// SetValidationLevel sets how far each root is validated. Below plan, roots are only
+// initialized without a backend and validated, which needs no credentials.
+func (p *RootPlanner) SetValidationLevel(level ValidationLevel) {
+	p.level = level
+}
+
+// SetMockProviders replaces the plan at the plan level with a terraform test run in
+// which the given providers are mocked
+func (p *RootPlanner) SetMockProviders(providers []string) {
+	p.mockProviders = providers
+}
+
+// SetCache shares a plugin and module cache between the inits of all sandboxes
+func (p *RootPlanner) SetCache(cache *Cache) {
+	p.cache = cache
+}
+
+// SetConcurrency sets how many roots are planned at once
+func (p *RootPlanner) SetConcurrency(n int) {
+	if n > 0 {
+		p.concurrency = n
+	}
+}
+
+// SetKeepSandbox keeps the sandboxes after planning, for debugging
+func (p *RootPlanner) SetKeepSandbox(keep bool) {
+	p.keepSandbox = keep
+}
+
+// PlanRoots validates and plans every root module affected by the edited files, up to
+// the validation level. When the original content of the edits is known, each root is
+// also planned in a second sandbox with the original files restored, and the two plans
+// are compared so that changes already pending on the base branch are not attributed
+// to the edit. A root that fails to init or plan gets a failed result rather than
+// being skipped.
+func (p *RootPlanner) PlanRoots(ctx context.Context, name string, edits []FileEdit) []RootPlan {
+	seen := map[string]bool{}
+	var dirs, files []string
+	for _, edit := range edits {
+		files = append(files, edit.Path)
+		for _, dir := range p.finder.RootsFor(edit.Path) {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	if len(dirs) == 0 {
+		log.Warn().Strs("files", files).Msg("no root module found for edited files, skipping plan")
+		return nil
+	}
+
+	plans := make([]RootPlan, len(dirs))
+	for i, dir := range dirs {
+		plans[i].Dir = dir
+	}
+
+	edited, err := p.newSandbox(nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to create plan sandbox")
+		for i := range plans {
+			plans[i].Result = &PlanResult{ErrorMessage: err.Error()}
+		}
+		return plans
+	}
+	defer p.removeSandbox(edited)
+
+	realPlan := p.level == ValidationPlan && len(p.mockProviders) == 0
+
+	var baseline *Sandbox
+	if realPlan && baselineAvailable(edits) {
+		baseline, err = p.newSandbox(edits)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to create baseline sandbox, skipping baseline plan")
+		} else {
+			defer p.removeSandbox(baseline)
+		}
+	}
+
+	// Validate and plan every root in both sandboxes, up to concurrency roots at once.
+	// Both plans of a root share its backend state, so they run one after the other:
+	// backends with state locking would fail one of two concurrent plans.
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, p.concurrency)
+	for i := range plans {
+		plan := &plans[i]
+		artifactDir := p.artifactDir(name, plan.Dir)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if baseline != nil {
+				baselineDir := ""
+				if artifactDir != "" {
+					baselineDir = filepath.Join(artifactDir, "baseline")
+				}
+				plan.Baseline = p.planRoot(ctx, baseline, plan.Dir, baselineDir)
+			}
+			p.validateAndPlan(ctx, edited, plan, artifactDir)
+		}()
+	}
+	wg.Wait()
+
+	if p.artifactsDir != "" {
+		p.recordArtifacts(name, plans)
+	}
+
+	for i := range plans {
+		if plans[i].Baseline != nil && plans[i].Baseline.Success && plans[i].Result != nil && plans[i].Result.Success {
+			plans[i].Diff = DiffPlans(plans[i].Baseline, plans[i].Result)
+		}
+	}
+
+	return plans
+}
+
+// ValidateRoots runs init -backend=false and validate in a sandbox copy of every root
+// module that includes one of the files, regardless of the validation level, and
+// returns the errors of the roots that fail. Files no root includes cannot be
+// validated, which is an error too.
+func (p *RootPlanner) ValidateRoots(ctx context.Context, files []string) error {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, file := range files {
+		for _, dir := range p.finder.RootsFor(file) {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("no root module includes %s", strings.Join(files, ", "))
+	}
+
+	sandbox, err := p.newSandbox(nil)
+	if err != nil {
+		return err
+	}
+	defer p.removeSandbox(sandbox)
+
+	var failures []string
+	for _, dir := range dirs {
+		runner, workDir, err := p.newRunner(sandbox, dir)
+		if err == nil {
+			err = p.init(dir, workDir, func(upgrade bool) error {
+				return runner.InitWithoutBackend(ctx, upgrade)
+			})
+		}
+		if err == nil {
+			err = runner.Validate(ctx)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("root", dir).Msg("validation failed")
+			failures = append(failures, fmt.Sprintf("%s: %v", dir, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// baselineAvailable reports whether every edit carries its original content
+func baselineAvailable(edits []FileEdit) bool {
+	for _, edit := range edits {
+		if edit.Original == nil {
+			return false
+		}
+	}
+	return len(edits) > 0
+}
+
+// newSandbox copies the repository into a sandbox and writes the original
+// content of the given edits into it
+func (p *RootPlanner) newSandbox(originals []FileEdit) (*Sandbox, error) {
+	sandbox, err := NewSandbox(p.finder.basePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, edit := range originals {
+		if err := sandbox.WriteFile(edit.Path, edit.Original); err != nil {
+			sandbox.Remove()
+			return nil, err
+		}
+	}
+	return sandbox, nil
+}
+
+// removeSandbox deletes a sandbox unless sandboxes are kept
+func (p *RootPlanner) removeSandbox(sandbox *Sandbox) {
+	if p.keepSandbox {
+		log.Info().Str("sandbox", sandbox.Dir).Msg("keeping plan sandbox")
+		return
+	}
+	if err := sandbox.Remove(); err != nil {
+		log.Warn().Err(err).Str("sandbox", sandbox.Dir).Msg("failed to remove plan sandbox")
+	}
+}
+
+// validateAndPlan validates the sandbox copy of a root module up to the validation
+// level, planning it at the plan level once validation passes
+func (p *RootPlanner) validateAndPlan(ctx context.Context, sandbox *Sandbox, plan *RootPlan, artifactDir string) {
+	if p.level == ValidationNone {
+		return
+	}
+
+	runner, workDir, err := p.newRunner(sandbox, plan.Dir)
+	if err != nil {
+		plan.Validation = &ValidationResult{Level: ValidationInit, Errors: []string{err.Error()}}
+		if p.level == ValidationPlan {
+			plan.Result = &PlanResult{ErrorMessage: err.Error()}
+		}
+		return
+	}
+
+	validation := &ValidationResult{Level: ValidationInit}
+	plan.Validation = validation
+
+	// A root that fails validation is reported as a failed plan at the plan level
+	fail := func(err error) {
+		validation.Errors = []string{err.Error()}
+		if p.level == ValidationPlan && len(p.mockProviders) == 0 {
+			plan.Result = &PlanResult{ErrorMessage: err.Error()}
+		}
+	}
+
+	if err := p.init(plan.Dir, workDir, func(upgrade bool) error {
+		return runner.InitWithoutBackend(ctx, upgrade)
+	}); err != nil {
+		log.Warn().Err(err).Str("root", plan.Dir).Msg("terraform init -backend=false failed")
+		fail(err)
+		return
+	}
+
+	if p.level.Includes(ValidationValidate) {
+		validation.Level = ValidationValidate
+		if err := runner.Validate(ctx); err != nil {
+			log.Warn().Err(err).Str("root", plan.Dir).Msg("terraform validate failed")
+			fail(err)
+			return
+		}
+	}
+	validation.Success = true
+
+	if p.level != ValidationPlan {
+		return
+	}
+
+	if len(p.mockProviders) > 0 {
+		validation.Level = ValidationPlan
+		validation.Mocked = true
+		if inputs := p.inputsFor(plan.Dir); inputs != nil {
+			// terraform test has no workspaces; plan with the first one's var files
+			workspace := ""
+			if len(inputs.Workspaces) > 0 {
+				workspace = inputs.Workspaces[0]
+			}
+			runner.SetPlanInputs(inputs.varFilesFor(workspace), inputs.Vars)
+		}
+		if err := runner.MockPlan(ctx, p.mockProviders); err != nil {
+			log.Warn().Err(err).Str("root", plan.Dir).Msg("terraform plan with mock providers failed")
+			validation.Success = false
+			validation.Errors = []string{err.Error()}
+		}
+		return
+	}
+
+	plan.Result = p.planRoot(ctx, sandbox, plan.Dir, artifactDir)
+}
+
+// newRunner creates a runner for the sandbox copy of a root module
+func (p *RootPlanner) newRunner(sandbox *Sandbox, dir string) (*Runner, string, error) {
+	workDir, err := sandbox.Path(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	env := p.env
+	if p.cache != nil {
+		env = p.cache.Env(env)
+	}
+
+	runner, err := New(workDir, p.binaryPath, env)
+	if err != nil {
+		return nil, "", err
+	}
+	return runner, workDir, nil
+}
+
+// planRoot runs init and plan in the sandbox copy of a single root module, in each of
+// its workspaces, keeping the plan in artifactDir when set
+func (p *RootPlanner) planRoot(ctx context.Context, sandbox *Sandbox, dir, artifactDir string) *PlanResult {
+	runner, workDir, err := p.newRunner(sandbox, dir)
+	if err != nil {
+		return &PlanResult{ErrorMessage: err.Error()}
+	}
+	if artifactDir != "" {
+		runner.SetArtifactDir(artifactDir)
+	}
+
+	if err := p.init(dir, workDir, func(upgrade bool) error {
+		if upgrade {
+			return runner.InitUpgrade(ctx)
+		}
+		return runner.Init(ctx)
+	}); err != nil {
+		log.Warn().Err(err).Str("root", dir).Msg("terraform init failed")
+		return &PlanResult{ErrorMessage: err.Error()}
+	}
+
+	result, err := runner.PlanWorkspaces(ctx, p.inputsFor(dir))
+	if err != nil {
+		log.Warn().Err(err).Str("root", dir).Msg("terraform plan failed")
+		if result == nil {
+			result = &PlanResult{ErrorMessage: err.Error()}
+		}
+	}
+	return result
+}
+
+// init initializes a sandboxed root with initFn, seeding and updating the module cache
+func (p *RootPlanner) init(dir, workDir string, initFn func(upgrade bool) error) error {
+	if p.cache != nil {
+		p.initMu.Lock()
+		defer p.initMu.Unlock()
+		p.cache.RestoreModules(dir, workDir)
+	}
+
+	if err := initFn(false); err != nil {
+		// A provider update conflicts with the lock file until init upgrades it
+		log.Debug().Err(err).Str("root", dir).Msg("terraform init failed, retrying with -upgrade")
+		if err := initFn(true); err != nil {
+			return err
+		}
+	}
+
+	if p.cache != nil {
+		p.cache.SaveModules(dir, workDir)
+	}
+	return nil
+}
+
+// CombinePlans merges per-root plans into a single result: it succeeds only if every
+// root succeeded, and resource counts and detailed changes are summed
+func CombinePlans(plans []RootPlan) *PlanResult {
+	if len(plans) == 0 {
+		return nil
+	}
+
+	combined := &PlanResult{Success: true}
+	planned := false
+	var outputs, errors []string
+	for _, plan := range plans {
+		result := plan.Result
+		if result == nil {
+			continue
+		}
+		planned = true
+		if !result.Success {
+			combined.Success = false
+			errors = append(errors, plan.Dir+": "+result.ErrorMessage)
+		}
+		combined.HasChanges = combined.HasChanges || result.HasChanges
+		combined.ResourcesAdd += result.ResourcesAdd
+		combined.ResourcesChange += result.ResourcesChange
+		combined.ResourcesDestroy += result.ResourcesDestroy
+		combined.DetailedChanges = append(combined.DetailedChanges, result.DetailedChanges...)
+		if result.Output != "" {
+			outputs = append(outputs, plan.Dir+": "+result.Output)
+		}
+	}
+
+	if !planned {
+		return nil
+	}
+
+	combined.Output = strings.Join(outputs, "\n")
+	combined.ErrorMessage = strings.Join(errors, "\n")
+	return combined
+}
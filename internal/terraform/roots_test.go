@@ -0,0 +1,98 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTF(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRootFinderRootsFor(t *testing.T) {
+	base := t.TempDir()
+	writeTF(t, filepath.Join(base, "envs/prod/main.tf"), `
+terraform {
+  backend "s3" {}
+}
+
+module "network" {
+  source = "../../modules/network"
+}
+`)
+	writeTF(t, filepath.Join(base, "envs/staging/main.tf"), `
+provider "aws" {}
+
+module "network" {
+  source = "../../modules/network"
+}
+`)
+	writeTF(t, filepath.Join(base, "modules/network/main.tf"), `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+
+module "subnets" {
+  source = "./subnets"
+}
+`)
+	writeTF(t, filepath.Join(base, "modules/network/subnets/main.tf"), `resource "null_resource" "x" {}`)
+	writeTF(t, filepath.Join(base, "modules/orphan/main.tf"), `resource "null_resource" "x" {}`)
+
+	prod := filepath.Join(base, "envs/prod")
+	staging := filepath.Join(base, "envs/staging")
+
+	tests := []struct {
+		name       string
+		configured []string
+		file       string
+		want       []string
+	}{
+		{name: "file in a root", file: "envs/prod/main.tf", want: []string{prod}},
+		{name: "local module called by two roots", file: "modules/network/main.tf", want: []string{prod, staging}},
+		{name: "nested local module", file: "modules/network/subnets/main.tf", want: []string{prod, staging}},
+		{name: "module not called by any root", file: "modules/orphan/main.tf", want: nil},
+		{name: "configured roots", configured: []string{"envs/staging"}, file: "modules/network/main.tf", want: []string{staging}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder := NewRootFinder(base, tt.configured)
+			got := finder.RootsFor(filepath.Join(base, tt.file))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RootsFor(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinePlans(t *testing.T) {
+	if got := CombinePlans(nil); got != nil {
+		t.Errorf("CombinePlans(nil) = %+v, want nil", got)
+	}
+
+	combined := CombinePlans([]RootPlan{
+		{Dir: "envs/prod", Result: &PlanResult{Success: true, HasChanges: true, ResourcesChange: 1,
+			DetailedChanges: []ResourceChange{{Address: "aws_vpc.this", Action: []string{"update"}}}}},
+		{Dir: "envs/staging", Result: &PlanResult{Success: false, ErrorMessage: "boom"}},
+	})
+
+	if combined.Success {
+		t.Error("Success = true, want false when a root failed")
+	}
+	if !combined.HasChanges || combined.ResourcesChange != 1 || len(combined.DetailedChanges) != 1 {
+		t.Errorf("combined = %+v, want changes from envs/prod", combined)
+	}
+	if combined.ErrorMessage != "envs/staging: boom" {
+		t.Errorf("ErrorMessage = %q", combined.ErrorMessage)
+	}
+}
@@ -1,7 +1,9 @@
 package terraform
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,6 +25,16 @@ type PlanResult struct {
 	ResourcesChange int
 	ResourcesDestroy int
 	DetailedChanges []ResourceChange
+	Text         string         // human-readable plan from terraform show
+	Artifacts    *PlanArtifacts // saved plan files, nil unless an artifact directory is set
+	Workspaces   []WorkspaceResult // per-workspace plans when planned in several workspaces
+}
+
+// PlanArtifacts are the files a plan is saved to for review and CI upload
+type PlanArtifacts struct {
+	PlanFile string `json:"plan_file"` // binary plan, usable with terraform apply
+	JSONFile string `json:"json_file"` // terraform show -json output
+	TextFile string `json:"text_file"` // human-readable plan
 }
 
 // ResourceChange represents a detailed resource change from plan
@@ -30,13 +43,29 @@ type ResourceChange struct {
 	ResourceType string
 	Action       []string // create, update, delete, replace
 	ReplaceTriggers []string // attributes that trigger replacement
+	ChangedAttributes []string // top-level attributes whose value changes
+	ReplacePaths []AttributeChange // attributes that force replacement, with their values
+	ActionReason string // why terraform chose the action, e.g. replace_because_cannot_update
+	PreviousAddress string // address before a moved block, empty if not moved
+	Importing bool // the resource is imported by this plan
+	Workspace string // workspace the change is planned in, empty for the current one
+}
+
+// AttributeChange is the before and after value of an attribute, rendered for display
+type AttributeChange struct {
+	Path   string
+	Before string
+	After  string
 }
 
 // Runner executes Terraform commands
 type Runner struct {
-	workingDir string
-	binaryPath string
-	env        map[string]string
+	workingDir  string
+	binaryPath  string
+	env         map[string]string
+	artifactDir string
+	varFiles    []string
+	vars        map[string]string
 }
 
 // New creates a new Terraform Runner
@@ -50,13 +79,9 @@ func New(workingDir, binaryPath string, env map[string]string) (*Runner, error)
 		return nil, fmt.Errorf("working directory does not exist: %w", err)
 	}
 
-	// Find Terraform binary if not specified
-	if binaryPath == "" {
-		var err error
-		binaryPath, err = exec.LookPath("terraform")
-		if err != nil {
-			return nil, fmt.Errorf("terraform binary not found in PATH: %w", err)
-		}
+	binaryPath, err := LookPath(binaryPath)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Runner{
@@ -66,6 +91,28 @@ func New(workingDir, binaryPath string, env map[string]string) (*Runner, error)
 	}, nil
 }
 
+// LookPath finds the binary in PATH when it is not specified or given by name only
+// (e.g. "tofu"); a path to the binary is returned as is
+func LookPath(binaryPath string) (string, error) {
+	if binaryPath == "" {
+		binaryPath = "terraform"
+	}
+	if strings.ContainsRune(binaryPath, filepath.Separator) {
+		return binaryPath, nil
+	}
+
+	path, err := exec.LookPath(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("%s binary not found in PATH: %w", binaryPath, err)
+	}
+	return path, nil
+}
+
+// SetArtifactDir keeps the plans of this runner in dir, as plan.tfplan, plan.json and plan.txt
+func (r *Runner) SetArtifactDir(dir string) {
+	r.artifactDir = dir
+}
+
 // Init runs terraform init
 func (r *Runner) Init(ctx context.Context) error {
 	log.Info().Str("dir", r.workingDir).Msg("running terraform init")
@@ -83,6 +130,24 @@ func (r *Runner) Init(ctx context.Context) error {
 	return nil
 }
 
+// InitUpgrade runs terraform init -upgrade, which is needed when the dependency lock
+// file pins a provider version outside an updated constraint
+func (r *Runner) InitUpgrade(ctx context.Context) error {
+	log.Info().Str("dir", r.workingDir).Msg("running terraform init -upgrade")
+
+	tf, err := r.newTerraform()
+	if err != nil {
+		return err
+	}
+
+	if err := tf.Init(ctx, tfexec.Upgrade(true)); err != nil {
+		return fmt.Errorf("terraform init failed: %w", err)
+	}
+
+	log.Info().Msg("terraform init completed successfully")
+	return nil
+}
+
 // Plan runs terraform plan and returns the result
 func (r *Runner) Plan(ctx context.Context) (*PlanResult, error) {
 	log.Info().Str("dir", r.workingDir).Msg("running terraform plan")
@@ -92,11 +157,26 @@ func (r *Runner) Plan(ctx context.Context) (*PlanResult, error) {
 		return nil, err
 	}
 
-	// Create a temporary file for the plan output
+	// Write the plan to the artifact directory, or to a temporary file
 	planFile := filepath.Join(r.workingDir, ".terranovate-plan")
-	defer os.Remove(planFile)
+	if r.artifactDir != "" {
+		dir, err := filepath.Abs(r.artifactDir)
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+		}
+		planFile = filepath.Join(dir, "plan.tfplan")
+	} else {
+		// Absolute, since terraform runs in the working directory
+		if abs, err := filepath.Abs(planFile); err == nil {
+			planFile = abs
+		}
+		defer os.Remove(planFile)
+	}
 
-	hasChanges, err := tf.Plan(ctx, tfexec.Out(planFile))
+	hasChanges, err := tf.Plan(ctx, append(r.planOptions(), tfexec.Out(planFile))...)
 	if err != nil {
 		return &PlanResult{
 			Success:      false,
@@ -104,8 +184,19 @@ func (r *Runner) Plan(ctx context.Context) (*PlanResult, error) {
 		}, fmt.Errorf("terraform plan failed: %w", err)
 	}
 
-	// Get plan details
-	plan, err := tf.ShowPlanFile(ctx, planFile)
+	// Get plan details. The raw JSON is parsed here because it carries fields
+	// (action_reason) that tfjson does not model.
+	rawPlan, err := r.showJSON(ctx, planFile)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to read plan file, using basic result")
+		return &PlanResult{
+			Success:    true,
+			HasChanges: hasChanges,
+			Output:     "Plan completed but details unavailable",
+		}, nil
+	}
+
+	result, err := r.parsePlan(rawPlan, hasChanges)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to parse plan file, using basic result")
 		return &PlanResult{
@@ -115,89 +206,127 @@ func (r *Runner) Plan(ctx context.Context) (*PlanResult, error) {
 		}, nil
 	}
 
+	text, err := tf.ShowPlanFileRaw(ctx, planFile)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to render plan text")
+	}
+	result.Text = text
+
+	if r.artifactDir != "" {
+		result.Artifacts = r.saveArtifacts(planFile, rawPlan, text)
+	}
+
+	log.Info().
+		Bool("has_changes", hasChanges).
+		Int("add", result.ResourcesAdd).
+		Int("change", result.ResourcesChange).
+		Int("destroy", result.ResourcesDestroy).
+		Msg("terraform plan completed")
+
+	return result, nil
+}
+
+// showJSON returns the raw terraform show -json output of a plan file
+func (r *Runner) showJSON(ctx context.Context, planFile string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := r.command(ctx, "show", "-json", "-no-color", planFile)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("terraform show failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// saveArtifacts writes the JSON and text renderings next to a saved plan file
+func (r *Runner) saveArtifacts(planFile string, rawPlan []byte, text string) *PlanArtifacts {
+	dir := filepath.Dir(planFile)
+	artifacts := &PlanArtifacts{
+		PlanFile: planFile,
+		JSONFile: filepath.Join(dir, "plan.json"),
+		TextFile: filepath.Join(dir, "plan.txt"),
+	}
+
+	if err := os.WriteFile(artifacts.JSONFile, rawPlan, 0644); err != nil {
+		log.Warn().Err(err).Str("file", artifacts.JSONFile).Msg("failed to save plan JSON")
+	}
+	if err := os.WriteFile(artifacts.TextFile, []byte(text), 0644); err != nil {
+		log.Warn().Err(err).Str("file", artifacts.TextFile).Msg("failed to save plan text")
+	}
+
+	log.Info().Str("dir", dir).Msg("saved plan artifacts")
+	return artifacts
+}
+
+// command builds a command running the binary directly, for subcommands whose
+// output terraform-exec does not expose
+func (r *Runner) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, r.binaryPath, args...)
+	cmd.Dir = r.workingDir
+	for key, value := range r.environ() {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	return cmd
+}
+
+// parsePlan builds a PlanResult from the JSON output of terraform show
+func (r *Runner) parsePlan(rawPlan []byte, hasChanges bool) (*PlanResult, error) {
+	var plan tfjson.Plan
+	if err := json.Unmarshal(rawPlan, &plan); err != nil {
+		return nil, err
+	}
+
+	// action_reason is keyed by address (and deposed key) since tfjson drops it
+	var extras struct {
+		ResourceChanges []struct {
+			Address      string `json:"address"`
+			Deposed      string `json:"deposed"`
+			ActionReason string `json:"action_reason"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(rawPlan, &extras); err != nil {
+		return nil, err
+	}
+	actionReasons := make(map[string]string, len(extras.ResourceChanges))
+	for _, rc := range extras.ResourceChanges {
+		actionReasons[rc.Address+rc.Deposed] = rc.ActionReason
+	}
+
 	// Count resources and analyze detailed changes
 	resourcesAdd := 0
 	resourcesChange := 0
 	resourcesDestroy := 0
 	var detailedChanges []ResourceChange
 
-	if plan.ResourceChanges != nil {
-		for _, rc := range plan.ResourceChanges {
-			if rc.Change != nil {
-				actions := rc.Change.Actions
-
-				// Create detailed resource change entry
-				change := ResourceChange{
-					Address:      rc.Address,
-					ResourceType: rc.Type,
-					Action:       make([]string, 0, len(actions)),
-				}
-
-				// Convert actions to strings
-				for _, action := range actions {
-					change.Action = append(change.Action, string(action))
-				}
-
-				// Detect replacements and analyze replace triggers
-				isReplace := false
-				for _, action := range actions {
-					switch action {
-					case "create":
-						resourcesAdd++
-					case "update":
-						resourcesChange++
-					case "delete":
-						resourcesDestroy++
-					}
-
-					// Check if this is a replace operation (delete + create)
-					if action == "delete" {
-						for _, a := range actions {
-							if a == "create" {
-								isReplace = true
-								break
-							}
-						}
-					}
-				}
-
-				// Extract replace triggers from the action reasons
-				// The tfexec library provides this information in the ActionReason field
-				if isReplace {
-					// Try to identify what changed by looking at the resource type
-					// This is a simplified approach - full diff analysis would be more complex
-					if rc.Type != "" {
-						change.ReplaceTriggers = append(change.ReplaceTriggers,
-							"One or more immutable attributes changed")
-					}
-				}
-
-				detailedChanges = append(detailedChanges, change)
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil {
+			continue
+		}
+
+		for _, action := range rc.Change.Actions {
+			switch action {
+			case tfjson.ActionCreate:
+				resourcesAdd++
+			case tfjson.ActionUpdate:
+				resourcesChange++
+			case tfjson.ActionDelete:
+				resourcesDestroy++
 			}
 		}
-	}
 
-	// Generate human-readable output
-	output := r.formatPlanOutput(resourcesAdd, resourcesChange, resourcesDestroy)
+		detailedChanges = append(detailedChanges, newResourceChange(rc, actionReasons[rc.Address+rc.DeposedKey]))
+	}
 
-	result := &PlanResult{
+	return &PlanResult{
 		Success:          true,
 		HasChanges:       hasChanges,
-		Output:           output,
+		Output:           r.formatPlanOutput(resourcesAdd, resourcesChange, resourcesDestroy),
 		ResourcesAdd:     resourcesAdd,
 		ResourcesChange:  resourcesChange,
 		ResourcesDestroy: resourcesDestroy,
 		DetailedChanges:  detailedChanges,
-	}
-
-	log.Info().
-		Bool("has_changes", hasChanges).
-		Int("add", resourcesAdd).
-		Int("change", resourcesChange).
-		Int("destroy", resourcesDestroy).
-		Msg("terraform plan completed")
-
-	return result, nil
+	}, nil
 }
 
 // Validate runs terraform validate
@@ -217,7 +346,10 @@ func (r *Runner) Validate(ctx context.Context) error {
 	if !diags.Valid {
 		var errMsgs []string
 		for _, diag := range diags.Diagnostics {
-			errMsgs = append(errMsgs, diag.Summary)
+			if diag.Severity != tfjson.DiagnosticSeverityError {
+				continue
+			}
+			errMsgs = append(errMsgs, formatDiagnostic(diag))
 		}
 		return fmt.Errorf("validation errors: %s", strings.Join(errMsgs, "; "))
 	}
@@ -250,18 +382,38 @@ func (r *Runner) newTerraform() (*tfexec.Terraform, error) {
 		return nil, fmt.Errorf("failed to create terraform executor: %w", err)
 	}
 
-	// Set environment variables
-	if r.env != nil {
+	// Set environment variables on top of the process environment, which
+	// terraform-exec drops once an environment is set
+	if len(r.env) > 0 {
+		env := r.environ()
+		for _, key := range tfexec.ProhibitedEnv(env) {
+			delete(env, key)
+		}
 		for key, value := range r.env {
-			if err := tf.SetEnv(map[string]string{key: value}); err != nil {
-				return nil, fmt.Errorf("failed to set env var %s: %w", key, err)
-			}
+			env[key] = value
+		}
+		if err := tf.SetEnv(env); err != nil {
+			return nil, fmt.Errorf("failed to set env vars: %w", err)
 		}
 	}
 
 	return tf, nil
 }
 
+// environ returns the process environment with the configured variables applied
+func (r *Runner) environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	for key, value := range r.env {
+		env[key] = value
+	}
+	return env
+}
+
 // formatPlanOutput formats the plan result in a human-readable way
 func (r *Runner) formatPlanOutput(add, change, destroy int) string {
 	var parts []string
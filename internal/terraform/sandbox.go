@@ -0,0 +1,303 @@
+package terraform
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sandboxSkipDirs are directories never copied into a sandbox
+var sandboxSkipDirs = map[string]bool{
+	".git":              true,
+	".terraform":        true,
+	".terragrunt-cache": true,
+}
+
+// ignoredVarFiles are pathspecs of variable files copied into a sandbox even when git
+// ignores them, as they commonly hold local or secret values plans need
+var ignoredVarFiles = []string{"*.tfvars", "*.tfvars.json"}
+
+// Sandbox is a temporary copy of a repository where init and plan run without
+// leaving .terraform directories, plan files or lock file changes behind
+type Sandbox struct {
+	Dir    string // root of the copy
+	source string // absolute path of the copied tree
+}
+
+// NewSandbox copies the tree containing basePath into a temporary directory. Inside a
+// git repository the whole repository is copied, so that local modules outside
+// basePath resolve, using the tracked and untracked files git does not ignore plus
+// ignored variable files, so that plans read the same values as in the real tree.
+func NewSandbox(basePath string) (*Sandbox, error) {
+	source, files := sandboxFiles(basePath)
+
+	dir, err := os.MkdirTemp("", "terranovate-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	sandbox := &Sandbox{Dir: dir, source: source}
+
+	if files != nil {
+		for _, file := range files {
+			if err := copyFile(filepath.Join(source, file), filepath.Join(dir, file)); err != nil {
+				if os.IsNotExist(err) {
+					continue // deleted in the working tree
+				}
+				sandbox.Remove()
+				return nil, fmt.Errorf("failed to copy %s into sandbox: %w", file, err)
+			}
+		}
+	} else if err := copyTree(source, dir); err != nil {
+		sandbox.Remove()
+		return nil, fmt.Errorf("failed to copy %s into sandbox: %w", source, err)
+	}
+
+	log.Debug().Str("source", source).Str("sandbox", dir).Msg("created plan sandbox")
+	return sandbox, nil
+}
+
+// Path maps a path in the original tree to the same path in the sandbox
+func (s *Sandbox) Path(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(s.source, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the sandbox source %s", path, s.source)
+	}
+	return filepath.Join(s.Dir, rel), nil
+}
+
+// WriteFile writes content to the sandbox copy of a file in the original tree
+func (s *Sandbox) WriteFile(path string, content []byte) error {
+	target, err := s.Path(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, content, 0644)
+}
+
+// Remove deletes the sandbox
+func (s *Sandbox) Remove() error {
+	return os.RemoveAll(s.Dir)
+}
+
+// sandboxFiles returns the tree to copy for basePath and, inside a git repository,
+// the files to copy relative to it. A nil file list means copying the whole tree.
+func sandboxFiles(basePath string) (string, []string) {
+	abs, err := filepath.Abs(basePath)
+	if err != nil {
+		abs = basePath
+	}
+
+	out, err := exec.Command("git", "-C", abs, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return abs, nil
+	}
+	root := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("git", "-C", root, "ls-files", "-z", "--cached", "--others", "--exclude-standard").Output()
+	if err != nil {
+		return abs, nil
+	}
+
+	args := append([]string{"-C", root, "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--"}, ignoredVarFiles...)
+	ignored, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return abs, nil
+	}
+
+	var files []string
+	for _, file := range bytes.Split(append(out, ignored...), []byte{0}) {
+		if len(file) > 0 && !inSkippedDir(string(file)) {
+			files = append(files, filepath.FromSlash(string(file)))
+		}
+	}
+	return root, files
+}
+
+// inSkippedDir reports whether a slash-separated path lies in one of sandboxSkipDirs
+func inSkippedDir(path string) bool {
+	parts := strings.Split(path, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if sandboxSkipDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// copyTree copies a directory tree, skipping sandboxSkipDirs
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != src && sandboxSkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+// copyFile copies a regular file or symlink, creating parent directories
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil // a submodule or nested repository listed by git
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Cache holds the provider plugin cache and the module downloads shared by sandboxes,
+// so that repeated inits of the same root do not download everything again
+type Cache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewCache creates a cache under dir, or under the user cache directory when dir is empty
+func NewCache(dir string) (*Cache, error) {
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		dir = filepath.Join(userCache, "terranovate")
+	}
+
+	for _, sub := range []string{"plugins", "modules"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+
+	return &Cache{dir: dir}, nil
+}
+
+// Env returns env with TF_PLUGIN_CACHE_DIR pointing at the shared plugin cache,
+// unless it is already set in env or in the process environment
+func (c *Cache) Env(env map[string]string) map[string]string {
+	merged := make(map[string]string, len(env)+1)
+	for key, value := range env {
+		merged[key] = value
+	}
+	if _, ok := merged["TF_PLUGIN_CACHE_DIR"]; !ok && os.Getenv("TF_PLUGIN_CACHE_DIR") == "" {
+		merged["TF_PLUGIN_CACHE_DIR"] = filepath.Join(c.dir, "plugins")
+	}
+	return merged
+}
+
+// RestoreModules seeds the .terraform/modules directory of a sandboxed root with
+// the modules cached for root. init then downloads only modules whose source or
+// version changed.
+func (c *Cache) RestoreModules(root, dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := c.modulesDir(root)
+	if _, err := os.Stat(cached); err != nil {
+		return
+	}
+	if err := copyModules(cached, filepath.Join(dir, ".terraform", "modules")); err != nil {
+		log.Debug().Err(err).Str("root", root).Msg("failed to restore cached modules")
+	}
+}
+
+// SaveModules stores the modules installed in a sandboxed root for later inits
+func (c *Cache) SaveModules(root, dir string) {
+	installed := filepath.Join(dir, ".terraform", "modules")
+	if _, err := os.Stat(installed); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := c.modulesDir(root)
+	tmp := cached + ".tmp"
+	os.RemoveAll(tmp)
+	if err := copyModules(installed, tmp); err != nil {
+		log.Debug().Err(err).Str("root", root).Msg("failed to cache modules")
+		os.RemoveAll(tmp)
+		return
+	}
+	os.RemoveAll(cached)
+	if err := os.Rename(tmp, cached); err != nil {
+		log.Debug().Err(err).Str("root", root).Msg("failed to cache modules")
+	}
+}
+
+// modulesDir returns the cache directory of a root module
+func (c *Cache) modulesDir(root string) string {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(c.dir, "modules", hex.EncodeToString(sum[:8]))
+}
+
+// copyModules copies an installed modules directory, including the .git and
+// .terraform directories that copyTree skips
+func copyModules(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
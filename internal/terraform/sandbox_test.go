@@ -0,0 +1,133 @@
+package terraform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewSandbox(t *testing.T) {
+	base := t.TempDir()
+	writeTF(t, filepath.Join(base, "envs", "prod", "main.tf"), "edited")
+	writeTF(t, filepath.Join(base, "envs", "prod", ".terraform", "terraform.tfstate"), "{}")
+	writeTF(t, filepath.Join(base, "modules", "vpc", "main.tf"), "module")
+
+	sandbox, err := NewSandbox(base)
+	if err != nil {
+		t.Fatalf("NewSandbox() error = %v", err)
+	}
+	defer sandbox.Remove()
+
+	path, err := sandbox.Path(filepath.Join(base, "envs", "prod", "main.tf"))
+	if err != nil {
+		t.Fatalf("Path() error = %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "edited" {
+		t.Errorf("sandbox copy = %q, want edited", content)
+	}
+	if _, err := os.Stat(filepath.Join(sandbox.Dir, "modules", "vpc", "main.tf")); err != nil {
+		t.Errorf("local module not copied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sandbox.Dir, "envs", "prod", ".terraform")); !os.IsNotExist(err) {
+		t.Errorf(".terraform copied into sandbox, stat error = %v", err)
+	}
+
+	if _, err := sandbox.Path(filepath.Dir(base)); err == nil {
+		t.Error("Path() expected error for a path outside the source")
+	}
+
+	if err := sandbox.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(sandbox.Dir); !os.IsNotExist(err) {
+		t.Errorf("sandbox still exists after Remove()")
+	}
+}
+
+func TestNewSandboxIgnoredVarFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeTF(t, filepath.Join(repo, ".gitignore"), "*.tfvars\n*.tfvars.json\n.terraform/\nsecret.txt\n")
+	writeTF(t, filepath.Join(repo, "envs", "prod", "main.tf"), "root")
+	writeTF(t, filepath.Join(repo, "envs", "prod", "prod.tfvars"), "a = 1")
+	writeTF(t, filepath.Join(repo, "envs", "prod", "local.auto.tfvars"), "b = 2")
+	writeTF(t, filepath.Join(repo, "envs", "prod", "extra.tfvars.json"), "{}")
+	writeTF(t, filepath.Join(repo, "envs", "prod", "secret.txt"), "secret")
+	writeTF(t, filepath.Join(repo, "envs", "prod", ".terraform", "cached.tfvars"), "c = 3")
+
+	sandbox, err := NewSandbox(filepath.Join(repo, "envs", "prod"))
+	if err != nil {
+		t.Fatalf("NewSandbox() error = %v", err)
+	}
+	defer sandbox.Remove()
+
+	for _, file := range []string{"main.tf", "prod.tfvars", "local.auto.tfvars", "extra.tfvars.json"} {
+		if _, err := os.Stat(filepath.Join(sandbox.Dir, "envs", "prod", file)); err != nil {
+			t.Errorf("%s not copied into sandbox: %v", file, err)
+		}
+	}
+	for _, file := range []string{"secret.txt", filepath.Join(".terraform", "cached.tfvars")} {
+		if _, err := os.Stat(filepath.Join(sandbox.Dir, "envs", "prod", file)); !os.IsNotExist(err) {
+			t.Errorf("ignored %s copied into sandbox, stat error = %v", file, err)
+		}
+	}
+}
+
+func TestRootPlannerBaselineSandbox(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "main.tf")
+	writeTF(t, path, "edited")
+
+	planner := NewRootPlanner(base, nil, "", nil)
+	sandbox, err := planner.newSandbox([]FileEdit{{Path: path, Original: []byte("original")}})
+	if err != nil {
+		t.Fatalf("newSandbox() error = %v", err)
+	}
+	defer sandbox.Remove()
+
+	if content, _ := os.ReadFile(filepath.Join(sandbox.Dir, "main.tf")); string(content) != "original" {
+		t.Errorf("baseline sandbox content = %q, want original", content)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "edited" {
+		t.Errorf("working tree content = %q, want edited", content)
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	t.Setenv("TF_PLUGIN_CACHE_DIR", "")
+	env := cache.Env(map[string]string{"AWS_REGION": "eu-central-1"})
+	if env["TF_PLUGIN_CACHE_DIR"] != filepath.Join(cache.dir, "plugins") || env["AWS_REGION"] != "eu-central-1" {
+		t.Errorf("Env() = %v, want plugin cache and configured variables", env)
+	}
+	if env := cache.Env(map[string]string{"TF_PLUGIN_CACHE_DIR": "/custom"}); env["TF_PLUGIN_CACHE_DIR"] != "/custom" {
+		t.Errorf("Env() overrode configured TF_PLUGIN_CACHE_DIR: %v", env)
+	}
+
+	first := t.TempDir()
+	writeTF(t, filepath.Join(first, ".terraform", "modules", "modules.json"), `{"Modules":[]}`)
+	cache.SaveModules("envs/prod", first)
+
+	second := t.TempDir()
+	cache.RestoreModules("envs/prod", second)
+	if content, _ := os.ReadFile(filepath.Join(second, ".terraform", "modules", "modules.json")); string(content) != `{"Modules":[]}` {
+		t.Errorf("restored modules.json = %q", content)
+	}
+
+	other := t.TempDir()
+	cache.RestoreModules("envs/staging", other)
+	if _, err := os.Stat(filepath.Join(other, ".terraform")); !os.IsNotExist(err) {
+		t.Errorf("modules restored for a root without cache entry")
+	}
+}
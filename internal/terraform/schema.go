@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/heyjobs/terranovate/internal/scanner"
@@ -19,6 +22,28 @@ type SchemaChanges struct {
 	ChangedVarTypes     []VariableChange
 	RemovedOutputs      []OutputChange
 	AddedOutputs        []OutputChange
+	ChangedDefaults     []DefaultChange           // variables whose default value changed
+	ChangedAttributes   []VariableAttributeChange // variables that changed sensitive or nullable
+	CallSiteIssues      []CallSiteIssue // where our own module call breaks against the new version
+}
+
+// DefaultChange is a variable whose default value changed, which silently changes
+// the behavior of every call that does not set it
+type DefaultChange struct {
+	Name        string
+	OldDefault  string // JSON, e.g. true or "10.0.0.0/16"
+	NewDefault  string
+	AffectsCall bool   // our module call does not set the variable and gets the new default
+	File        string // module call relying on the default, when AffectsCall
+	Line        int
+}
+
+// VariableAttributeChange is a change of a variable's sensitive or nullable setting
+type VariableAttributeChange struct {
+	Name      string
+	Attribute string // sensitive or nullable
+	Old       bool
+	New       bool
 }
 
 // VariableChange represents a change in a module variable
@@ -47,16 +72,22 @@ type Variable struct {
 	Description string `json:"description"`
 	Default     interface{} `json:"default"`
 	Required    bool   `json:"required"`
+	Nullable    bool     `json:"nullable"`              // false when the variable sets nullable = false
+	Sensitive   bool     `json:"sensitive"`
+	Validations []string `json:"validations,omitempty"` // conditions of the validation blocks
 }
 
 // Output represents a module output
 type Output struct {
 	Description string `json:"description"`
+	Sensitive   bool   `json:"sensitive"`
 }
 
 // SchemaComparator compares module schemas between versions
 type SchemaComparator struct {
-	httpClient *http.Client
+	httpClient   *http.Client
+	registryHost string
+	cacheDir     string
 }
 
 // NewSchemaComparator creates a new schema comparator
@@ -65,63 +96,153 @@ func NewSchemaComparator() *SchemaComparator {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		registryHost: EngineTerraform.RegistryHost(),
 	}
 }
 
-// CompareSchemas compares schemas between two module versions
+// SetRegistryHost sets the registry queried for module sources without a hostname
+func (sc *SchemaComparator) SetRegistryHost(host string) {
+	if host != "" {
+		sc.registryHost = host
+	}
+}
+
+// SetCacheDir keeps the checkouts of git module sources under dir/git-modules
+// (default: terranovate in the user cache directory)
+func (sc *SchemaComparator) SetCacheDir(dir string) {
+	sc.cacheDir = dir
+}
+
+// CompareSchemas compares schemas between two module versions. Registry modules are
+// read from the registry API; git modules, and registry modules the registry has no
+// inputs for, are fetched at both refs and their variable and output blocks parsed.
 func (sc *SchemaComparator) CompareSchemas(ctx context.Context, module scanner.ModuleInfo, currentVersion, latestVersion string) (*SchemaChanges, error) {
-	// Only support registry modules for now
-	if module.SourceType != scanner.SourceTypeRegistry {
-		return nil, nil // Schema comparison not available for non-registry modules
+	var fetch func(ctx context.Context, source, version string) (*ModuleSchema, error)
+	switch module.SourceType {
+	case scanner.SourceTypeRegistry:
+		fetch = sc.fetchModuleSchema
+	case scanner.SourceTypeGit:
+		fetch = sc.fetchGitSchema
+	default:
+		return nil, nil // Local modules have no versions to compare
 	}
 
-	currentSchema, err := sc.fetchRegistrySchema(ctx, module.Source, currentVersion)
+	currentSchema, err := fetch(ctx, module.Source, currentVersion)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed to fetch current schema")
 		return nil, nil // Don't fail, just skip schema comparison
 	}
 
-	latestSchema, err := sc.fetchRegistrySchema(ctx, module.Source, latestVersion)
+	latestSchema, err := fetch(ctx, module.Source, latestVersion)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed to fetch latest schema")
 		return nil, nil
 	}
 
-	return sc.compareSchemaStructures(currentSchema, latestSchema), nil
+	// An empty schema means the inputs are unknown, not that the module dropped them all
+	if schemaEmpty(currentSchema) || schemaEmpty(latestSchema) {
+		log.Debug().Str("module", module.Name).Msg("module schema unknown, skipping schema comparison")
+		return nil, nil
+	}
+
+	changes := sc.compareSchemaStructures(currentSchema, latestSchema)
+
+	// Check our own call against the new version
+	issues, err := CheckCallSite(module, currentSchema, latestSchema)
+	if err != nil {
+		log.Debug().Err(err).Str("module", module.Name).Msg("failed to check module call site")
+	}
+	changes.CallSiteIssues = issues
+	if len(issues) > 0 {
+		changes.HasChanges = true
+	}
+	if err := MarkReliedDefaults(module, changes.ChangedDefaults); err != nil {
+		log.Debug().Err(err).Str("module", module.Name).Msg("failed to check module call for changed defaults")
+	}
+
+	return changes, nil
 }
 
-// fetchRegistrySchema fetches module schema from Terraform Registry
-func (sc *SchemaComparator) fetchRegistrySchema(ctx context.Context, source, version string) (*ModuleSchema, error) {
-	// Parse module source (namespace/name/provider)
-	parts := []rune(source)
-	var namespace, name, provider string
-
-	// Simple parsing - split by /
-	slashCount := 0
-	var part string
-	for _, r := range parts {
-		if r == '/' {
-			slashCount++
-			if slashCount == 1 {
-				namespace = part
-				part = ""
-			} else if slashCount == 2 {
-				name = part
-				part = ""
-			}
-		} else {
-			part += string(r)
+// schemaEmpty reports whether a schema declares no variables and no outputs
+func schemaEmpty(schema *ModuleSchema) bool {
+	return schema == nil || len(schema.Variables)+len(schema.Outputs) == 0
+}
+
+// fetchModuleSchema fetches the schema of a registry module, or of the submodule its
+// //subdir names, from the registry, or from the git repository the registry
+// downloads it from when the registry does not document its inputs (as private
+// registries often do not)
+func (sc *SchemaComparator) fetchModuleSchema(ctx context.Context, source, version string) (*ModuleSchema, error) {
+	schema, err := sc.fetchRegistrySchema(ctx, source, version)
+	if err == nil && !schemaEmpty(schema) {
+		return schema, nil
+	}
+
+	location, downloadErr := sc.registryDownloadSource(ctx, source, version)
+	if downloadErr != nil || !(strings.HasPrefix(location, "git::") || strings.HasPrefix(location, "git@")) {
+		if err != nil {
+			return nil, err
 		}
+		return schema, nil
+	}
+
+	_, _, subdir, _ := sc.registryModulePath(source)
+	location = gitSourceWithSubdir(location, subdir)
+	log.Debug().Str("source", source).Str("download", location).Msg("parsing registry module from its git source")
+	return sc.fetchGitSchema(ctx, location, "")
+}
+
+// registryModulePath splits a registry module source ([hostname/]namespace/name/provider,
+// with an optional //subdir) into the registry host, the three path parts and the
+// subdirectory of the submodule, "" for the root module
+func (sc *SchemaComparator) registryModulePath(source string) (string, []string, string, error) {
+	host := sc.registryHost
+	base, subdir, _ := strings.Cut(source, "//")
+	parts := strings.Split(base, "/")
+	if len(parts) == 4 && strings.Contains(parts[0], ".") {
+		host, parts = parts[0], parts[1:]
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", nil, "", fmt.Errorf("invalid module source format")
 	}
-	provider = part
+	return host, parts, strings.Trim(subdir, "/"), nil
+}
+
+// gitSourceWithSubdir appends the subdirectory of a submodule to the module
+// subdirectory of a git source, keeping its query
+func gitSourceWithSubdir(source, subdir string) string {
+	if subdir == "" {
+		return source
+	}
+	base, query, hasQuery := strings.Cut(source, "?")
+
+	start := 0
+	if i := strings.Index(base, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if strings.Contains(base[start:], "//") {
+		base = strings.TrimSuffix(base, "/") + "/" + subdir
+	} else {
+		base += "//" + subdir
+	}
+
+	if hasQuery {
+		return base + "?" + query
+	}
+	return base
+}
 
-	if namespace == "" || name == "" || provider == "" {
-		return nil, fmt.Errorf("invalid module source format")
+// fetchRegistrySchema fetches the schema of a module, or of the submodule its
+// //subdir names, from Terraform Registry
+func (sc *SchemaComparator) fetchRegistrySchema(ctx context.Context, source, version string) (*ModuleSchema, error) {
+	host, parts, subdir, err := sc.registryModulePath(source)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch module details from registry
-	url := fmt.Sprintf("https://registry.terraform.io/v1/modules/%s/%s/%s/%s",
-		namespace, name, provider, version)
+	url := fmt.Sprintf("https://%s/v1/modules/%s/%s/%s/%s",
+		host, parts[0], parts[1], parts[2], version)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -139,41 +260,45 @@ func (sc *SchemaComparator) fetchRegistrySchema(ctx context.Context, source, ver
 	}
 
 	var moduleData struct {
-		Root struct {
-			Inputs  []struct {
-				Name        string      `json:"name"`
-				Type        string      `json:"type"`
-				Description string      `json:"description"`
-				Default     interface{} `json:"default"`
-				Required    bool        `json:"required"`
-			} `json:"inputs"`
-			Outputs []struct {
-				Name        string `json:"name"`
-				Description string `json:"description"`
-			} `json:"outputs"`
-		} `json:"root"`
+		Root       registryModule   `json:"root"`
+		Submodules []registryModule `json:"submodules"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&moduleData); err != nil {
 		return nil, err
 	}
 
+	module := moduleData.Root
+	if subdir != "" {
+		found := false
+		for _, submodule := range moduleData.Submodules {
+			if strings.Trim(submodule.Path, "/") == subdir {
+				module, found = submodule, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("registry lists no submodule %s", subdir)
+		}
+	}
+
 	// Convert to our schema format
 	schema := &ModuleSchema{
 		Variables: make(map[string]Variable),
 		Outputs:   make(map[string]Output),
 	}
 
-	for _, input := range moduleData.Root.Inputs {
+	for _, input := range module.Inputs {
 		schema.Variables[input.Name] = Variable{
 			Type:        input.Type,
 			Description: input.Description,
 			Default:     input.Default,
 			Required:    input.Required,
+			Nullable:    true, // not reported by the registry
 		}
 	}
 
-	for _, output := range moduleData.Root.Outputs {
+	for _, output := range module.Outputs {
 		schema.Outputs[output.Name] = Output{
 			Description: output.Description,
 		}
@@ -182,6 +307,22 @@ func (sc *SchemaComparator) fetchRegistrySchema(ctx context.Context, source, ver
 	return schema, nil
 }
 
+// registryModule is the root module or a submodule in a registry module response
+type registryModule struct {
+	Path   string `json:"path"`
+	Inputs []struct {
+		Name        string      `json:"name"`
+		Type        string      `json:"type"`
+		Description string      `json:"description"`
+		Default     interface{} `json:"default"`
+		Required    bool        `json:"required"`
+	} `json:"inputs"`
+	Outputs []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"outputs"`
+}
+
 // compareSchemaStructures compares two schemas and returns the differences
 func (sc *SchemaComparator) compareSchemaStructures(current, latest *ModuleSchema) *SchemaChanges {
 	changes := &SchemaChanges{
@@ -212,7 +353,10 @@ func (sc *SchemaComparator) compareSchemaStructures(current, latest *ModuleSchem
 				})
 				changes.HasChanges = true
 			}
-		} else if currentVar.Type != latestVar.Type {
+			continue
+		}
+
+		if currentVar.Type != latestVar.Type {
 			// Variable type changed
 			changes.ChangedVarTypes = append(changes.ChangedVarTypes, VariableChange{
 				Name:        name,
@@ -221,8 +365,42 @@ func (sc *SchemaComparator) compareSchemaStructures(current, latest *ModuleSchem
 			})
 			changes.HasChanges = true
 		}
+
+		// Default flipped, e.g. enable_nat_gateway true → false
+		if !currentVar.Required && !latestVar.Required && !reflect.DeepEqual(currentVar.Default, latestVar.Default) {
+			changes.ChangedDefaults = append(changes.ChangedDefaults, DefaultChange{
+				Name:       name,
+				OldDefault: renderDefault(currentVar.Default),
+				NewDefault: renderDefault(latestVar.Default),
+			})
+			changes.HasChanges = true
+		}
+
+		if currentVar.Sensitive != latestVar.Sensitive {
+			changes.ChangedAttributes = append(changes.ChangedAttributes, VariableAttributeChange{
+				Name: name, Attribute: "sensitive", Old: currentVar.Sensitive, New: latestVar.Sensitive,
+			})
+			changes.HasChanges = true
+		}
+		if currentVar.Nullable != latestVar.Nullable {
+			changes.ChangedAttributes = append(changes.ChangedAttributes, VariableAttributeChange{
+				Name: name, Attribute: "nullable", Old: currentVar.Nullable, New: latestVar.Nullable,
+			})
+			changes.HasChanges = true
+		}
 	}
 
+	sort.Slice(changes.ChangedDefaults, func(i, j int) bool {
+		return changes.ChangedDefaults[i].Name < changes.ChangedDefaults[j].Name
+	})
+	sort.Slice(changes.ChangedAttributes, func(i, j int) bool {
+		a, b := changes.ChangedAttributes[i], changes.ChangedAttributes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Attribute < b.Attribute
+	})
+
 	// Check for removed variables
 	for name, currentVar := range current.Variables {
 		if _, exists := latest.Variables[name]; !exists {
@@ -260,6 +438,15 @@ func (sc *SchemaComparator) compareSchemaStructures(current, latest *ModuleSchem
 	return changes
 }
 
+// renderDefault renders a default value as JSON
+func renderDefault(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
 // HasBreakingSchemaChanges determines if schema changes are breaking
 func HasBreakingSchemaChanges(changes *SchemaChanges) bool {
 	if changes == nil {
@@ -270,5 +457,32 @@ func HasBreakingSchemaChanges(changes *SchemaChanges) bool {
 	return len(changes.AddedRequiredVars) > 0 ||
 		len(changes.RemovedVars) > 0 ||
 		len(changes.ChangedVarTypes) > 0 ||
-		len(changes.RemovedOutputs) > 0
+		len(changes.RemovedOutputs) > 0 ||
+		len(changes.CallSiteIssues) > 0
+}
+
+// BreakingSchemaChanges describes the breaking changes HasBreakingSchemaChanges counts,
+// one per entry, e.g. for an AI prompt
+func BreakingSchemaChanges(changes *SchemaChanges) []string {
+	if changes == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, issue := range changes.CallSiteIssues {
+		lines = append(lines, fmt.Sprintf("Our call breaks (%s:%d): %s", issue.File, issue.Line, issue.Message))
+	}
+	for _, v := range changes.AddedRequiredVars {
+		lines = append(lines, fmt.Sprintf("New required variable %s (%s): %s", v.Name, v.Type, v.Description))
+	}
+	for _, v := range changes.RemovedVars {
+		lines = append(lines, fmt.Sprintf("Removed variable %s (%s)", v.Name, v.Type))
+	}
+	for _, v := range changes.ChangedVarTypes {
+		lines = append(lines, fmt.Sprintf("Changed type of variable %s: %s", v.Name, v.Type))
+	}
+	for _, o := range changes.RemovedOutputs {
+		lines = append(lines, fmt.Sprintf("Removed output %s", o.Name))
+	}
+	return lines
 }
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -485,3 +486,40 @@ func TestSchemaChangesStructure(t *testing.T) {
 		t.Errorf("AddedOutputs length = %d, want 1", len(changes.AddedOutputs))
 	}
 }
+
+func TestCompareSchemaStructuresDefaultsAndAttributes(t *testing.T) {
+	comparator := NewSchemaComparator()
+
+	current := &ModuleSchema{Variables: map[string]Variable{
+		"enable_nat_gateway": {Type: "bool", Default: true, Nullable: true},
+		"cidr":               {Type: "string", Default: "10.0.0.0/16", Nullable: true},
+		"password":           {Type: "string", Required: true, Nullable: true},
+		"tags":               {Type: "map(string)", Default: map[string]interface{}{}, Nullable: true},
+	}}
+	latest := &ModuleSchema{Variables: map[string]Variable{
+		"enable_nat_gateway": {Type: "bool", Default: false, Nullable: true},
+		"cidr":               {Type: "string", Default: "10.0.0.0/16", Nullable: true},
+		"password":           {Type: "string", Required: true, Nullable: true, Sensitive: true},
+		"tags":               {Type: "map(string)", Default: map[string]interface{}{}, Nullable: false},
+	}}
+
+	changes := comparator.compareSchemaStructures(current, latest)
+
+	if !changes.HasChanges {
+		t.Error("HasChanges = false, want true")
+	}
+	wantDefaults := []DefaultChange{{Name: "enable_nat_gateway", OldDefault: "true", NewDefault: "false"}}
+	if !reflect.DeepEqual(changes.ChangedDefaults, wantDefaults) {
+		t.Errorf("ChangedDefaults = %+v, want %+v", changes.ChangedDefaults, wantDefaults)
+	}
+	wantAttributes := []VariableAttributeChange{
+		{Name: "password", Attribute: "sensitive", Old: false, New: true},
+		{Name: "tags", Attribute: "nullable", Old: true, New: false},
+	}
+	if !reflect.DeepEqual(changes.ChangedAttributes, wantAttributes) {
+		t.Errorf("ChangedAttributes = %+v, want %+v", changes.ChangedAttributes, wantAttributes)
+	}
+	if HasBreakingSchemaChanges(changes) {
+		t.Error("HasBreakingSchemaChanges() = true, want false for behavior changes only")
+	}
+}
@@ -0,0 +1,178 @@
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/rs/zerolog/log"
+)
+
+// ValidationLevel is how far the validation of an edited root module goes
+type ValidationLevel string
+
+const (
+	// ValidationNone skips validation
+	ValidationNone ValidationLevel = "none"
+
+	// ValidationInit runs init -backend=false, checking that modules and providers resolve
+	ValidationInit ValidationLevel = "init"
+
+	// ValidationValidate also runs validate, catching removed variables and type errors
+	ValidationValidate ValidationLevel = "validate"
+
+	// ValidationPlan also runs a full plan, which needs backend and provider credentials
+	// unless mock providers are configured
+	ValidationPlan ValidationLevel = "plan"
+)
+
+// validationOrder ranks the levels from least to most thorough
+var validationOrder = map[ValidationLevel]int{
+	ValidationNone:     0,
+	ValidationInit:     1,
+	ValidationValidate: 2,
+	ValidationPlan:     3,
+}
+
+// mockTestFile is the test file generated for plans with mock providers
+const mockTestFile = "terranovate_mock.tftest.hcl"
+
+// ParseValidationLevel parses a validation level, defaulting to plan
+func ParseValidationLevel(level string) (ValidationLevel, error) {
+	if level == "" {
+		return ValidationPlan, nil
+	}
+	l := ValidationLevel(strings.ToLower(level))
+	if _, ok := validationOrder[l]; !ok {
+		return "", fmt.Errorf("invalid validation level %q: must be none, init, validate or plan", level)
+	}
+	return l, nil
+}
+
+// Includes reports whether the level runs the other level's checks
+func (l ValidationLevel) Includes(other ValidationLevel) bool {
+	return validationOrder[l] >= validationOrder[other]
+}
+
+// ValidationResult is the outcome of the checks that run before, or instead of, a plan
+type ValidationResult struct {
+	Level   ValidationLevel // deepest check that ran
+	Success bool
+	Errors  []string
+	Mocked  bool // the plan ran with mock providers
+}
+
+// Summary describes the result in one line
+func (v *ValidationResult) Summary() string {
+	step := map[ValidationLevel]string{
+		ValidationInit:     "init -backend=false",
+		ValidationValidate: "validate",
+		ValidationPlan:     "plan",
+	}[v.Level]
+	if v.Mocked {
+		step = "plan with mock providers"
+	}
+
+	if v.Success {
+		return fmt.Sprintf("%s succeeded", step)
+	}
+	return fmt.Sprintf("%s failed", step)
+}
+
+// InitWithoutBackend runs terraform init -backend=false, which installs modules and
+// providers without touching remote state or needing backend credentials
+func (r *Runner) InitWithoutBackend(ctx context.Context, upgrade bool) error {
+	log.Info().Str("dir", r.workingDir).Msg("running terraform init -backend=false")
+
+	tf, err := r.newTerraform()
+	if err != nil {
+		return err
+	}
+
+	if err := tf.Init(ctx, tfexec.Backend(false), tfexec.Upgrade(upgrade)); err != nil {
+		return fmt.Errorf("terraform init failed: %w", err)
+	}
+
+	log.Info().Msg("terraform init completed successfully")
+	return nil
+}
+
+// MockPlan plans the configuration with terraform test, replacing the given providers
+// with mock_provider blocks so that no credentials are needed. It requires Terraform
+// 1.7 or OpenTofu 1.8 and writes a test file into the working directory.
+func (r *Runner) MockPlan(ctx context.Context, providers []string) error {
+	log.Info().Str("dir", r.workingDir).Strs("providers", providers).Msg("running terraform plan with mock providers")
+
+	var test strings.Builder
+	test.WriteString("# Generated by terranovate to plan with mock providers\n\n")
+	for _, provider := range providers {
+		test.WriteString(fmt.Sprintf("mock_provider %q {}\n", provider))
+	}
+	test.WriteString("\nrun \"terranovate_mock_plan\" {\n  command = plan\n}\n")
+
+	testPath := filepath.Join(r.workingDir, mockTestFile)
+	if err := os.WriteFile(testPath, []byte(test.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write mock test file: %w", err)
+	}
+	defer os.Remove(testPath)
+
+	// terraform-exec cannot filter test files, so run the binary directly
+	var stdout, stderr bytes.Buffer
+	cmd := r.command(ctx, append([]string{"test", "-json", "-filter=" + mockTestFile}, r.varArgs()...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		errors := testErrors(stdout.Bytes())
+		if len(errors) == 0 && stderr.Len() > 0 {
+			errors = []string{strings.TrimSpace(stderr.String())}
+		}
+		if len(errors) == 0 {
+			errors = []string{err.Error()}
+		}
+		return fmt.Errorf("mock plan failed: %s", strings.Join(errors, "; "))
+	}
+
+	log.Info().Msg("terraform plan with mock providers completed successfully")
+	return nil
+}
+
+// testErrors extracts the error diagnostics from terraform test -json output
+func testErrors(output []byte) []string {
+	var errors []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Level      string             `json:"@level"`
+			Type       string             `json:"type"`
+			Diagnostic *tfjson.Diagnostic `json:"diagnostic"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Level == "error" && line.Type == "diagnostic" && line.Diagnostic != nil {
+			errors = append(errors, formatDiagnostic(*line.Diagnostic))
+		}
+	}
+	return errors
+}
+
+// formatDiagnostic renders a diagnostic as "file:line: summary: detail"
+func formatDiagnostic(diag tfjson.Diagnostic) string {
+	msg := diag.Summary
+	if diag.Detail != "" {
+		msg += ": " + diag.Detail
+	}
+	if diag.Range != nil && diag.Range.Filename != "" {
+		msg = fmt.Sprintf("%s:%d: %s", diag.Range.Filename, diag.Range.Start.Line, msg)
+	}
+	return msg
+}
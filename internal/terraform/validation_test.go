@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestParseValidationLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    ValidationLevel
+		wantErr bool
+	}{
+		{level: "", want: ValidationPlan},
+		{level: "none", want: ValidationNone},
+		{level: "init", want: ValidationInit},
+		{level: "Validate", want: ValidationValidate},
+		{level: "plan", want: ValidationPlan},
+		{level: "apply", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := ParseValidationLevel(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseValidationLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseValidationLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationLevelIncludes(t *testing.T) {
+	if !ValidationPlan.Includes(ValidationValidate) {
+		t.Error("plan should include validate")
+	}
+	if ValidationInit.Includes(ValidationValidate) {
+		t.Error("init should not include validate")
+	}
+	if !ValidationValidate.Includes(ValidationValidate) {
+		t.Error("validate should include itself")
+	}
+}
+
+func TestValidationResultSummary(t *testing.T) {
+	tests := []struct {
+		result ValidationResult
+		want   string
+	}{
+		{ValidationResult{Level: ValidationInit, Success: true}, "init -backend=false succeeded"},
+		{ValidationResult{Level: ValidationValidate}, "validate failed"},
+		{ValidationResult{Level: ValidationPlan, Success: true, Mocked: true}, "plan with mock providers succeeded"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.result.Summary(); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestTestErrors(t *testing.T) {
+	output := []byte(`{"@level":"info","@message":"Terraform 1.9.0","type":"version"}
+{"@level":"error","@message":"Error: Reference to undeclared input variable","type":"diagnostic","diagnostic":{"severity":"error","summary":"Reference to undeclared input variable","detail":"An input variable with the name \"cidr\" has not been declared.","range":{"filename":"main.tf","start":{"line":4,"column":3,"byte":40},"end":{"line":4,"column":10,"byte":47}}}}
+{"@level":"info","@message":"Failure! 0 passed, 1 failed.","type":"test_summary"}
+`)
+
+	want := []string{`main.tf:4: Reference to undeclared input variable: An input variable with the name "cidr" has not been declared.`}
+	if got := testErrors(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("testErrors() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatDiagnostic(t *testing.T) {
+	got := formatDiagnostic(tfjson.Diagnostic{Summary: "Missing required argument"})
+	if got != "Missing required argument" {
+		t.Errorf("formatDiagnostic() = %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/rs/zerolog/log"
+)
+
+// workspacePlaceholder is replaced by the workspace name in var file paths
+const workspacePlaceholder = "{workspace}"
+
+// PlanInputs are the workspaces and variables a root module is planned with
+type PlanInputs struct {
+	Workspaces []string          // planned one after another, the current workspace when empty
+	VarFiles   []string          // relative to the root, "{workspace}" expands to the workspace
+	Vars       map[string]string // passed as -var name=value
+}
+
+// RootInputs assigns plan inputs to the root modules matching a path or glob
+// relative to the current directory
+type RootInputs struct {
+	Path string
+	PlanInputs
+}
+
+// WorkspaceResult is the plan of a root module in one workspace
+type WorkspaceResult struct {
+	Workspace string
+	Result    *PlanResult
+}
+
+// MatchRootInputs returns the inputs of the first entry whose path matches dir, or
+// nil when none does. Entry paths are relative to the current directory whatever path
+// was scanned or planned, so the same entry matches a root in every command.
+func MatchRootInputs(inputs []RootInputs, dir string) *PlanInputs {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	for i := range inputs {
+		pattern, err := filepath.Abs(inputs[i].Path)
+		if err != nil {
+			continue
+		}
+		if matched, err := filepath.Match(pattern, dir); err == nil && matched {
+			return &inputs[i].PlanInputs
+		}
+	}
+	return nil
+}
+
+// SetRootInputs sets the workspaces, var files and variables of the roots matching
+// each entry's path; the first matching entry wins
+func (p *RootPlanner) SetRootInputs(inputs []RootInputs) {
+	p.inputs = inputs
+}
+
+// inputsFor returns the plan inputs of a root module, nil for defaults
+func (p *RootPlanner) inputsFor(dir string) *PlanInputs {
+	return MatchRootInputs(p.inputs, dir)
+}
+
+// varFilesFor expands the workspace placeholder in the var file paths
+func (in *PlanInputs) varFilesFor(workspace string) []string {
+	files := make([]string, len(in.VarFiles))
+	for i, file := range in.VarFiles {
+		files[i] = strings.ReplaceAll(file, workspacePlaceholder, workspace)
+	}
+	return files
+}
+
+// SetPlanInputs sets the -var-file and -var arguments of plans
+func (r *Runner) SetPlanInputs(varFiles []string, vars map[string]string) {
+	r.varFiles = varFiles
+	r.vars = vars
+}
+
+// varArgs returns the -var-file and -var arguments for commands run directly
+func (r *Runner) varArgs() []string {
+	var args []string
+	for _, file := range r.varFiles {
+		args = append(args, "-var-file="+file)
+	}
+	for _, name := range r.varNames() {
+		args = append(args, "-var="+name+"="+r.vars[name])
+	}
+	return args
+}
+
+// planOptions returns the terraform-exec options for the var files and vars
+func (r *Runner) planOptions() []tfexec.PlanOption {
+	var opts []tfexec.PlanOption
+	for _, file := range r.varFiles {
+		opts = append(opts, tfexec.VarFile(file))
+	}
+	for _, name := range r.varNames() {
+		opts = append(opts, tfexec.Var(name+"="+r.vars[name]))
+	}
+	return opts
+}
+
+// varNames returns the names of the variables in sorted order
+func (r *Runner) varNames() []string {
+	names := make([]string, 0, len(r.vars))
+	for name := range r.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectWorkspace runs terraform workspace select
+func (r *Runner) SelectWorkspace(ctx context.Context, workspace string) error {
+	log.Info().Str("dir", r.workingDir).Str("workspace", workspace).Msg("selecting terraform workspace")
+
+	tf, err := r.newTerraform()
+	if err != nil {
+		return err
+	}
+
+	if err := tf.WorkspaceSelect(ctx, workspace); err != nil {
+		return fmt.Errorf("failed to select workspace %s: %w", workspace, err)
+	}
+	return nil
+}
+
+// PlanWorkspaces plans the working directory in every workspace of inputs, with the
+// workspace's var files and the variables, and aggregates the plans into one result
+// whose changes are labelled with their workspace. Without workspaces it is a single
+// Plan with the inputs. Each workspace keeps its artifacts in a subdirectory of the
+// artifact directory.
+func (r *Runner) PlanWorkspaces(ctx context.Context, inputs *PlanInputs) (*PlanResult, error) {
+	if inputs == nil {
+		return r.Plan(ctx)
+	}
+	if len(inputs.Workspaces) == 0 {
+		r.SetPlanInputs(inputs.varFilesFor(""), inputs.Vars)
+		return r.Plan(ctx)
+	}
+
+	artifactDir := r.artifactDir
+	defer func() { r.artifactDir = artifactDir }()
+
+	var results []WorkspaceResult
+	var firstErr error
+	for _, workspace := range inputs.Workspaces {
+		result, err := r.planWorkspace(ctx, inputs, workspace, artifactDir)
+		if err != nil {
+			log.Warn().Err(err).Str("dir", r.workingDir).Str("workspace", workspace).Msg("workspace plan failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		results = append(results, WorkspaceResult{Workspace: workspace, Result: result})
+	}
+
+	return CombineWorkspaces(results), firstErr
+}
+
+// planWorkspace selects a workspace and plans it
+func (r *Runner) planWorkspace(ctx context.Context, inputs *PlanInputs, workspace, artifactDir string) (*PlanResult, error) {
+	if err := r.SelectWorkspace(ctx, workspace); err != nil {
+		return &PlanResult{ErrorMessage: err.Error()}, err
+	}
+
+	r.SetPlanInputs(inputs.varFilesFor(workspace), inputs.Vars)
+	if artifactDir != "" {
+		r.artifactDir = filepath.Join(artifactDir, artifactName(workspace))
+	}
+
+	result, err := r.Plan(ctx)
+	if result == nil {
+		result = &PlanResult{ErrorMessage: err.Error()}
+	}
+	for i := range result.DetailedChanges {
+		result.DetailedChanges[i].Workspace = workspace
+	}
+	return result, err
+}
+
+// CombineWorkspaces merges the plans of one root module in several workspaces: it
+// succeeds only if every workspace succeeded, counts and changes are summed, and the
+// output and plan text are given per workspace
+func CombineWorkspaces(results []WorkspaceResult) *PlanResult {
+	combined := &PlanResult{Success: true, Workspaces: results}
+	var outputs, texts, errors []string
+	for _, ws := range results {
+		result := ws.Result
+		if !result.Success {
+			combined.Success = false
+			errors = append(errors, fmt.Sprintf("workspace %s: %s", ws.Workspace, result.ErrorMessage))
+		}
+		combined.HasChanges = combined.HasChanges || result.HasChanges
+		combined.ResourcesAdd += result.ResourcesAdd
+		combined.ResourcesChange += result.ResourcesChange
+		combined.ResourcesDestroy += result.ResourcesDestroy
+		combined.DetailedChanges = append(combined.DetailedChanges, result.DetailedChanges...)
+		if result.Output != "" {
+			outputs = append(outputs, fmt.Sprintf("workspace %s: %s", ws.Workspace, result.Output))
+		}
+		if result.Text != "" {
+			texts = append(texts, fmt.Sprintf("# Workspace: %s\n\n%s", ws.Workspace, result.Text))
+		}
+	}
+
+	combined.Output = strings.Join(outputs, "\n")
+	combined.Text = strings.Join(texts, "\n\n")
+	combined.ErrorMessage = strings.Join(errors, "\n")
+	return combined
+}
@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchRootInputs(t *testing.T) {
+	inputs := []RootInputs{
+		{Path: "infra/envs/prod", PlanInputs: PlanInputs{Workspaces: []string{"eu", "us"}}},
+		{Path: "infra/envs/*", PlanInputs: PlanInputs{VarFiles: []string{"common.tfvars"}}},
+	}
+	abs, err := filepath.Abs("infra/envs/prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want *PlanInputs
+	}{
+		{"first match wins", "infra/envs/prod", &inputs[0].PlanInputs},
+		{"glob", "infra/envs/staging", &inputs[1].PlanInputs},
+		{"cleaned path", "./infra/envs/prod/", &inputs[0].PlanInputs},
+		{"absolute path", abs, &inputs[0].PlanInputs},
+		{"no match", "infra/shared", nil},
+		{"not relative to the planned path", "envs/prod", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchRootInputs(inputs, tt.dir); got != tt.want {
+				t.Errorf("MatchRootInputs(%q) = %+v, want %+v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerVarArgs(t *testing.T) {
+	inputs := &PlanInputs{
+		VarFiles: []string{"common.tfvars", "vars/{workspace}.tfvars"},
+		Vars:     map[string]string{"region": "eu-west-1", "env": "prod"},
+	}
+
+	r := &Runner{}
+	r.SetPlanInputs(inputs.varFilesFor("prod"), inputs.Vars)
+
+	want := []string{
+		"-var-file=common.tfvars",
+		"-var-file=vars/prod.tfvars",
+		"-var=env=prod",
+		"-var=region=eu-west-1",
+	}
+	if got := r.varArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("varArgs() = %v, want %v", got, want)
+	}
+	if got := len(r.planOptions()); got != len(want) {
+		t.Errorf("planOptions() returned %d options, want %d", got, len(want))
+	}
+}
+
+func TestCombineWorkspaces(t *testing.T) {
+	combined := CombineWorkspaces([]WorkspaceResult{
+		{Workspace: "staging", Result: &PlanResult{
+			Success:          true,
+			HasChanges:       true,
+			Output:           "Plan: 1 to destroy",
+			ResourcesDestroy: 1,
+			DetailedChanges:  []ResourceChange{{Address: "aws_instance.web", Action: []string{"delete"}, Workspace: "staging"}},
+			Text:             "staging plan",
+		}},
+		{Workspace: "prod", Result: &PlanResult{ErrorMessage: "No value for required variable"}},
+	})
+
+	if combined.Success {
+		t.Error("Success = true, want false when a workspace failed")
+	}
+	if !combined.HasChanges || combined.ResourcesDestroy != 1 {
+		t.Errorf("HasChanges = %v, ResourcesDestroy = %d, want true and 1", combined.HasChanges, combined.ResourcesDestroy)
+	}
+	if len(combined.DetailedChanges) != 1 || combined.DetailedChanges[0].Workspace != "staging" {
+		t.Errorf("DetailedChanges = %+v, want the staging change", combined.DetailedChanges)
+	}
+	if want := "workspace staging: Plan: 1 to destroy"; combined.Output != want {
+		t.Errorf("Output = %q, want %q", combined.Output, want)
+	}
+	if want := "workspace prod: No value for required variable"; combined.ErrorMessage != want {
+		t.Errorf("ErrorMessage = %q, want %q", combined.ErrorMessage, want)
+	}
+	if want := "# Workspace: staging\n\nstaging plan"; combined.Text != want {
+		t.Errorf("Text = %q, want %q", combined.Text, want)
+	}
+	if len(combined.Workspaces) != 2 {
+		t.Errorf("Workspaces = %d, want 2", len(combined.Workspaces))
+	}
+}
+
+func TestDiffPlansWorkspaces(t *testing.T) {
+	baseline := &PlanResult{DetailedChanges: []ResourceChange{
+		{Address: "aws_instance.web", Action: []string{"delete"}, Workspace: "staging"},
+	}}
+	upgraded := &PlanResult{DetailedChanges: []ResourceChange{
+		{Address: "aws_instance.web", Action: []string{"delete"}, Workspace: "staging"},
+		{Address: "aws_instance.web", Action: []string{"delete"}, Workspace: "prod"},
+	}}
+
+	diff := DiffPlans(baseline, upgraded)
+
+	if len(diff.Drift) != 1 || diff.Drift[0].Workspace != "staging" {
+		t.Errorf("Drift = %+v, want the staging deletion", diff.Drift)
+	}
+	if len(diff.Deletions) != 1 || diff.Deletions[0].Workspace != "prod" {
+		t.Errorf("Deletions = %+v, want the prod deletion", diff.Deletions)
+	}
+
+	summary := AnalyzePlanDiff(diff)
+	if got := summary.ResourcesToDelete[0].Workspace; got != "prod" {
+		t.Errorf("summary workspace = %q, want prod", got)
+	}
+	if got, want := describeResourceChange(summary.ResourcesToDelete[0]), "- aws_instance.web [workspace prod]"; got != want {
+		t.Errorf("describeResourceChange() = %q, want %q", got, want)
+	}
+}
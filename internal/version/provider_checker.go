@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
-	"strings"
 
 	"github.com/hashicorp/go-version"
 	"github.com/heyjobs/terranovate/internal/ai"
@@ -24,12 +23,17 @@ type ProviderUpdateInfo struct {
 	BreakingChangeDetails string
 	ChangelogURL          string
 	UpdateType            UpdateType
+	SchemaChanges         interface{}    // Will hold *terraform.ProviderSchemaChanges
+	ReleaseNotes          *ReleaseNotes  // Notes of the versions between current and latest
 	AIAnalysis            *ai.AIAnalysis // AI-powered breaking change detection
+	HeldBackReason        string         // Why an available update was not reported as outdated
 }
 
 // CheckProviders checks for updates for the given providers
 func (c *Checker) CheckProviders(ctx context.Context, providers []scanner.ProviderInfo) ([]ProviderUpdateInfo, error) {
 	var updates []ProviderUpdateInfo
+	c.heldBackProviders = nil
+	c.providerFailures = nil
 
 	for _, provider := range providers {
 		updateInfo, err := c.checkProvider(ctx, provider)
@@ -38,33 +42,34 @@ func (c *Checker) CheckProviders(ctx context.Context, providers []scanner.Provid
 				Str("provider", provider.Name).
 				Str("file", fmt.Sprintf("%s:%d", provider.FilePath, provider.Line)).
 				Msg("failed to check provider version")
+			c.providerFailures = append(c.providerFailures, CheckFailure{
+				Kind:     "provider",
+				Name:     provider.Name,
+				Source:   provider.Source,
+				FilePath: provider.FilePath,
+				Line:     provider.Line,
+				Error:    err.Error(),
+			})
+			continue
+		}
+
+		if updateInfo.HeldBackReason != "" {
+			c.heldBackProviders = append(c.heldBackProviders, updateInfo)
+			log.Debug().
+				Str("provider", provider.Name).
+				Str("reason", updateInfo.HeldBackReason).
+				Msg("provider update held back")
 			continue
 		}
 
 		if updateInfo.IsOutdated {
-			// Perform AI analysis if analyzer is configured
-			if c.aiAnalyzer != nil {
-				aiAnalysis, err := c.aiAnalyzer.AnalyzeBreakingChanges(
-					ctx,
-					provider.Name,
-					updateInfo.CurrentVersion,
-					updateInfo.LatestVersion,
-					updateInfo.ChangelogURL,
-				)
-				if err != nil {
-					log.Warn().Err(err).
-						Str("provider", provider.Name).
-						Msg("AI analysis failed, skipping")
-				} else {
-					updateInfo.AIAnalysis = aiAnalysis
-					log.Debug().
-						Str("provider", provider.Name).
-						Bool("ai_breaking_changes", aiAnalysis.HasBreakingChanges).
-						Str("confidence", aiAnalysis.Confidence).
-						Msg("AI analysis completed")
-				}
+			if c.releaseNotes {
+				updateInfo.ReleaseNotes = c.providerReleaseNotes(ctx, provider, updateInfo.CurrentVersion, updateInfo.LatestVersion)
 			}
 
+			// Perform AI analysis if analyzer is configured
+			c.AnalyzeProviderUpdate(ctx, &updateInfo, nil)
+
 			updates = append(updates, updateInfo)
 			log.Info().
 				Str("provider", provider.Name).
@@ -84,8 +89,8 @@ func (c *Checker) checkProvider(ctx context.Context, provider scanner.ProviderIn
 		CurrentVersion: extractVersionFromConstraint(provider.Version),
 	}
 
-	// Parse provider source (namespace/name)
-	parts := strings.Split(provider.Source, "/")
+	// Parse provider source ([hostname/]namespace/name)
+	host, parts := splitRegistrySource(provider.Source, 2, c.registryHost)
 	if len(parts) < 2 {
 		return updateInfo, fmt.Errorf("invalid provider source format: %s", provider.Source)
 	}
@@ -93,9 +98,9 @@ func (c *Checker) checkProvider(ctx context.Context, provider scanner.ProviderIn
 	namespace := parts[0]
 	providerType := parts[1]
 
-	// Query Terraform Registry API for provider versions
-	url := fmt.Sprintf("https://registry.terraform.io/v1/providers/%s/%s/versions",
-		namespace, providerType)
+	// Query the provider registry API for provider versions
+	url := fmt.Sprintf("https://%s/v1/providers/%s/%s/versions",
+		host, namespace, providerType)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -162,6 +167,9 @@ func (c *Checker) checkProvider(ctx context.Context, provider scanner.ProviderIn
 		}
 
 		updateInfo.IsOutdated = c.shouldUpdate(currentVersion, latestVersion)
+		if !updateInfo.IsOutdated {
+			updateInfo.HeldBackReason = c.heldBackReason(currentVersion, latestVersion)
+		}
 
 		// Detect breaking changes and update type
 		updateInfo.UpdateType = c.detectUpdateType(currentVersion, latestVersion)
@@ -182,8 +190,8 @@ func (c *Checker) checkProvider(ctx context.Context, provider scanner.ProviderIn
 	}
 
 	// Set changelog URL
-	updateInfo.ChangelogURL = fmt.Sprintf("https://registry.terraform.io/providers/%s/%s/%s",
-		namespace, providerType, latestVersion.String())
+	updateInfo.ChangelogURL = fmt.Sprintf("https://%s/providers/%s/%s/%s",
+		host, namespace, providerType, latestVersion.String())
 
 	return updateInfo, nil
 }
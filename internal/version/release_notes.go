@@ -0,0 +1,339 @@
+package version
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/hashicorp/go-version"
+	"github.com/heyjobs/terranovate/internal/ai"
+	"github.com/heyjobs/terranovate/internal/scanner"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultReleaseNotesLimit bounds the aggregated release notes of one update, so they
+// fit in an AI prompt and a PR body
+const DefaultReleaseNotesLimit = 16000
+
+// minNotesPerVersion is the smallest share of the limit a single version is cut to;
+// with more versions than fit, only the newest are kept
+const minNotesPerVersion = 500
+
+// maxReleasePages bounds how many pages of GitHub releases are listed per repository
+const maxReleasePages = 3
+
+// changelogHeading matches version headings of a CHANGELOG.md, such as "## [5.1.0] - 2024-01-01",
+// "## v5.1.0 (January 1, 2024)" or "# 5.1.0"
+var changelogHeading = regexp.MustCompile(`^#{1,3}\s+\[?v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)\]?`)
+
+// ReleaseNotes are the release notes of the versions an update moves across
+type ReleaseNotes struct {
+	Repository string         // owner/repo the notes were read from
+	Source     string         // "releases" or "CHANGELOG.md"
+	Versions   []VersionNotes // newest first
+	Truncated  bool           // notes were cut or dropped to stay within the limit
+}
+
+// VersionNotes are the release notes of one version
+type VersionNotes struct {
+	Version string
+	URL     string
+	Body    string
+}
+
+// Text renders the notes as one markdown section per version, newest first
+func (n *ReleaseNotes) Text() string {
+	if n == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, v := range n.Versions {
+		text.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", v.Version, strings.TrimSpace(v.Body)))
+	}
+	if n.Truncated {
+		text.WriteString("(Release notes truncated.)\n")
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// MentionsBreakingChanges reports whether any version's notes announce a breaking change
+func (n *ReleaseNotes) MentionsBreakingChanges() bool {
+	if n == nil {
+		return false
+	}
+	for _, v := range n.Versions {
+		for _, line := range strings.Split(v.Body, "\n") {
+			if ai.AnnouncesBreakingChange(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReleaseNotes enables fetching the release notes of outdated modules and providers,
+// bounded to limit bytes per update (DefaultReleaseNotesLimit when limit is 0)
+func (c *Checker) SetReleaseNotes(enabled bool, limit int) {
+	c.releaseNotes = enabled
+	if limit <= 0 {
+		limit = DefaultReleaseNotesLimit
+	}
+	c.releaseNotesLimit = limit
+}
+
+// moduleReleaseNotes returns the release notes between the current and the latest
+// version of a module, or nil when its repository has none
+func (c *Checker) moduleReleaseNotes(ctx context.Context, module scanner.ModuleInfo, current, latest string) *ReleaseNotes {
+	source := module.Source
+	if module.SourceType == scanner.SourceTypeRegistry {
+		host, parts := splitRegistrySource(module.Source, 3, c.registryHost)
+		if len(parts) < 3 {
+			return nil
+		}
+		var err error
+		source, err = c.registrySource(ctx, fmt.Sprintf("https://%s/v1/modules/%s/%s/%s", host, parts[0], parts[1], parts[2]))
+		if err != nil {
+			log.Debug().Err(err).Str("module", module.Name).Msg("failed to look up module repository")
+			return nil
+		}
+	}
+
+	return c.releaseNotesFor(ctx, module.Name, source, current, latest)
+}
+
+// providerReleaseNotes returns the release notes between the current and the latest
+// version of a provider, or nil when its repository has none
+func (c *Checker) providerReleaseNotes(ctx context.Context, provider scanner.ProviderInfo, current, latest string) *ReleaseNotes {
+	host, parts := splitRegistrySource(provider.Source, 2, c.registryHost)
+	if len(parts) < 2 {
+		return nil
+	}
+	source, err := c.registrySource(ctx, fmt.Sprintf("https://%s/v1/providers/%s/%s", host, parts[0], parts[1]))
+	if err != nil {
+		log.Debug().Err(err).Str("provider", provider.Name).Msg("failed to look up provider repository")
+		return nil
+	}
+
+	return c.releaseNotesFor(ctx, provider.Name, source, current, latest)
+}
+
+// releaseNotesFor fetches the notes from the GitHub repository of source, logging
+// rather than failing, since release notes only add context to an update
+func (c *Checker) releaseNotesFor(ctx context.Context, name, source, current, latest string) *ReleaseNotes {
+	owner, repo, err := c.parseGitSource(source)
+	if err != nil {
+		log.Debug().Str("dependency", name).Str("source", source).Msg("release notes are only read from GitHub repositories")
+		return nil
+	}
+
+	notes, err := c.FetchReleaseNotes(ctx, owner, repo, current, latest)
+	if err != nil {
+		log.Debug().Err(err).Str("dependency", name).Msg("failed to fetch release notes")
+		return nil
+	}
+	if notes != nil {
+		log.Debug().
+			Str("dependency", name).
+			Str("repository", notes.Repository).
+			Int("versions", len(notes.Versions)).
+			Msg("fetched release notes")
+	}
+	return notes
+}
+
+// registrySource returns the source repository URL a registry reports for a module
+// or provider
+func (c *Checker) registrySource(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	var registryResp struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registryResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if registryResp.Source == "" {
+		return "", fmt.Errorf("registry reports no source repository")
+	}
+	return registryResp.Source, nil
+}
+
+// FetchReleaseNotes collects the notes of every version after current up to and
+// including latest from the GitHub releases of owner/repo, falling back to the
+// sections of its CHANGELOG.md. It returns nil when neither has notes for the range.
+func (c *Checker) FetchReleaseNotes(ctx context.Context, owner, repo, current, latest string) (*ReleaseNotes, error) {
+	from, err := version.NewVersion(strings.TrimPrefix(current, "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid current version: %w", err)
+	}
+	to, err := version.NewVersion(strings.TrimPrefix(latest, "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid latest version: %w", err)
+	}
+
+	notes := &ReleaseNotes{Repository: owner + "/" + repo, Source: "releases"}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for page := 0; page < maxReleasePages; page++ {
+		releases, resp, err := c.githubClient.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, release := range releases {
+			if release.GetDraft() || strings.TrimSpace(release.GetBody()) == "" {
+				continue
+			}
+			if ver, ok := versionInRange(release.GetTagName(), from, to); ok {
+				notes.Versions = append(notes.Versions, VersionNotes{
+					Version: ver.Original(),
+					URL:     release.GetHTMLURL(),
+					Body:    release.GetBody(),
+				})
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if len(notes.Versions) == 0 {
+		changelog, err := c.fetchChangelog(ctx, owner, repo)
+		if err != nil {
+			log.Debug().Err(err).Str("repository", notes.Repository).Msg("no CHANGELOG.md found")
+			return nil, nil
+		}
+		notes.Source = "CHANGELOG.md"
+		for _, section := range ParseChangelog(changelog) {
+			if _, ok := versionInRange(section.Version, from, to); ok {
+				notes.Versions = append(notes.Versions, section)
+			}
+		}
+	}
+
+	if len(notes.Versions) == 0 {
+		return nil, nil
+	}
+
+	sortNotes(notes.Versions)
+	boundNotes(notes, c.releaseNotesLimit)
+	return notes, nil
+}
+
+// fetchChangelog reads CHANGELOG.md from the default branch of owner/repo
+func (c *Checker) fetchChangelog(ctx context.Context, owner, repo string) (string, error) {
+	file, _, _, err := c.githubClient.Repositories.GetContents(ctx, owner, repo, "CHANGELOG.md", nil)
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		return "", fmt.Errorf("CHANGELOG.md is not a file")
+	}
+	return file.GetContent()
+}
+
+// ParseChangelog splits a CHANGELOG.md into one section per version heading
+func ParseChangelog(changelog string) []VersionNotes {
+	var sections []VersionNotes
+	var body strings.Builder
+
+	flush := func() {
+		if len(sections) > 0 {
+			sections[len(sections)-1].Body = strings.TrimSpace(body.String())
+		}
+		body.Reset()
+	}
+
+	lines := bufio.NewScanner(strings.NewReader(changelog))
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lines.Scan() {
+		line := lines.Text()
+		if m := changelogHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			sections = append(sections, VersionNotes{Version: m[1]})
+			continue
+		}
+		if len(sections) > 0 {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+	flush()
+
+	return sections
+}
+
+// versionInRange parses a tag or heading version and reports whether it lies after
+// from and at or before to
+func versionInRange(tag string, from, to *version.Version) (*version.Version, bool) {
+	ver, err := version.NewVersion(strings.TrimPrefix(tag, "v"))
+	if err != nil {
+		return nil, false
+	}
+	return ver, ver.GreaterThan(from) && !ver.GreaterThan(to)
+}
+
+// sortNotes orders notes newest version first
+func sortNotes(notes []VersionNotes) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, errA := version.NewVersion(notes[i].Version)
+		b, errB := version.NewVersion(notes[j].Version)
+		if errA != nil || errB != nil {
+			return false
+		}
+		return a.GreaterThan(b)
+	})
+}
+
+// boundNotes keeps the notes within limit bytes: when they exceed it, every version
+// gets an equal share, and with more versions than fit at minNotesPerVersion each,
+// only the newest are kept
+func boundNotes(notes *ReleaseNotes, limit int) {
+	if limit <= 0 {
+		limit = DefaultReleaseNotesLimit
+	}
+
+	total := 0
+	for _, v := range notes.Versions {
+		total += len(v.Body)
+	}
+	if total <= limit {
+		return
+	}
+	notes.Truncated = true
+
+	if max := limit / minNotesPerVersion; max > 0 && len(notes.Versions) > max {
+		notes.Versions = notes.Versions[:max]
+	}
+
+	share := limit / len(notes.Versions)
+	for i, v := range notes.Versions {
+		if len(v.Body) <= share {
+			continue
+		}
+		cut := strings.LastIndex(v.Body[:share], "\n")
+		if cut <= 0 {
+			cut = share
+		}
+		notes.Versions[i].Body = strings.TrimSpace(strings.ToValidUTF8(v.Body[:cut], "")) + "\n…"
+	}
+}
@@ -0,0 +1,213 @@
+package version
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestParseChangelog(t *testing.T) {
+	changelog := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [5.1.0] - 2024-03-01
+
+### Features
+- Add ipv6 support
+
+## v5.0.0 (January 1, 2024)
+
+### ⚠ BREAKING CHANGES
+- Remove enable_classiclink
+
+# 4.9.1
+- Fix tags
+`
+
+	got := ParseChangelog(changelog)
+	want := []VersionNotes{
+		{Version: "5.1.0", Body: "### Features\n- Add ipv6 support"},
+		{Version: "5.0.0", Body: "### ⚠ BREAKING CHANGES\n- Remove enable_classiclink"},
+		{Version: "4.9.1", Body: "- Fix tags"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseChangelog() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMentionsBreakingChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "breaking heading", body: "### ⚠ BREAKING CHANGES\n- Remove enable_classiclink", want: true},
+		{name: "conventional commit marker", body: "- feat(vpc)!: require azs", want: true},
+		{name: "features only", body: "### Features\n- Add ipv6 support", want: false},
+		{name: "word containing breaking", body: "- Fix nonbreaking typo", want: false},
+		{name: "negated", body: "No breaking changes in this release\n- Add ipv6 support", want: false},
+		{name: "non-breaking", body: "- Non-breaking: add outputs", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := &ReleaseNotes{Versions: []VersionNotes{{Version: "5.0.0", Body: tt.body}}}
+			if got := notes.MentionsBreakingChanges(); got != tt.want {
+				t.Errorf("MentionsBreakingChanges() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	var none *ReleaseNotes
+	if none.MentionsBreakingChanges() {
+		t.Error("MentionsBreakingChanges() = true for nil notes")
+	}
+}
+
+func TestBoundNotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		bodies        []int
+		limit         int
+		wantVersions  int
+		wantTruncated bool
+	}{
+		{name: "within limit", bodies: []int{100, 200}, limit: 1000, wantVersions: 2},
+		{name: "shared limit", bodies: []int{900, 900}, limit: 1000, wantVersions: 2, wantTruncated: true},
+		{name: "too many versions", bodies: []int{400, 400, 400, 400}, limit: 1000, wantVersions: 2, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := &ReleaseNotes{}
+			for i, n := range tt.bodies {
+				notes.Versions = append(notes.Versions, VersionNotes{
+					Version: string(rune('a' + i)),
+					Body:    strings.Repeat("line\n", n/5),
+				})
+			}
+
+			boundNotes(notes, tt.limit)
+
+			if len(notes.Versions) != tt.wantVersions {
+				t.Errorf("boundNotes() kept %d versions, want %d", len(notes.Versions), tt.wantVersions)
+			}
+			if notes.Truncated != tt.wantTruncated {
+				t.Errorf("boundNotes() Truncated = %t, want %t", notes.Truncated, tt.wantTruncated)
+			}
+			if notes.Versions[0].Version != "a" {
+				t.Errorf("boundNotes() dropped the newest version")
+			}
+			if text := notes.Text(); tt.wantTruncated && len(text) > tt.limit+200 {
+				t.Errorf("Text() is %d bytes, want about %d", len(text), tt.limit)
+			}
+		})
+	}
+}
+
+func TestFetchReleaseNotes(t *testing.T) {
+	releases := []map[string]interface{}{
+		{"tag_name": "v6.0.0", "body": "- Too new", "html_url": "https://github.com/acme/mod/releases/tag/v6.0.0"},
+		{"tag_name": "v5.1.0", "body": "- Add ipv6 support", "html_url": "https://github.com/acme/mod/releases/tag/v5.1.0"},
+		{"tag_name": "v5.0.1", "body": "", "html_url": "https://github.com/acme/mod/releases/tag/v5.0.1"},
+		{"tag_name": "v5.0.0", "body": "- Remove enable_classiclink", "html_url": "https://github.com/acme/mod/releases/tag/v5.0.0"},
+		{"tag_name": "v4.9.0", "body": "- Current", "html_url": "https://github.com/acme/mod/releases/tag/v4.9.0"},
+		{"tag_name": "v5.0.2", "body": "- Draft", "draft": true},
+	}
+	changelog := "# Changelog\n\n## 2.1.0\n- Newer\n\n## 2.0.0\n- Breaking\n\n## 1.0.0\n- Initial\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/mod/releases":
+			json.NewEncoder(w).Encode(releases)
+		case "/repos/acme/changelog/releases":
+			json.NewEncoder(w).Encode([]interface{}{})
+		case "/repos/acme/changelog/contents/CHANGELOG.md":
+			json.NewEncoder(w).Encode(map[string]string{
+				"type":     "file",
+				"encoding": "base64",
+				"content":  base64.StdEncoding.EncodeToString([]byte(changelog)),
+			})
+		case "/repos/acme/none/releases":
+			json.NewEncoder(w).Encode([]interface{}{})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := New("", true, false, false, nil)
+	checker.githubClient = github.NewClient(nil)
+	checker.githubClient.BaseURL, _ = url.Parse(server.URL + "/")
+	checker.SetReleaseNotes(true, 0)
+
+	tests := []struct {
+		name         string
+		repo         string
+		current      string
+		latest       string
+		wantSource   string
+		wantVersions []string
+	}{
+		{
+			name:         "github releases",
+			repo:         "mod",
+			current:      "4.9.0",
+			latest:       "v5.1.0",
+			wantSource:   "releases",
+			wantVersions: []string{"5.1.0", "5.0.0"},
+		},
+		{
+			name:         "changelog fallback",
+			repo:         "changelog",
+			current:      "v1.0.0",
+			latest:       "2.1.0",
+			wantSource:   "CHANGELOG.md",
+			wantVersions: []string{"2.1.0", "2.0.0"},
+		},
+		{
+			name:    "no notes",
+			repo:    "none",
+			current: "1.0.0",
+			latest:  "2.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := checker.FetchReleaseNotes(context.Background(), "acme", tt.repo, tt.current, tt.latest)
+			if err != nil {
+				t.Fatalf("FetchReleaseNotes() error = %v", err)
+			}
+			if tt.wantVersions == nil {
+				if notes != nil {
+					t.Errorf("FetchReleaseNotes() = %+v, want nil", notes)
+				}
+				return
+			}
+
+			if notes.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", notes.Source, tt.wantSource)
+			}
+			if notes.Repository != "acme/"+tt.repo {
+				t.Errorf("Repository = %q, want acme/%s", notes.Repository, tt.repo)
+			}
+			var versions []string
+			for _, v := range notes.Versions {
+				versions = append(versions, v.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.wantVersions) {
+				t.Errorf("Versions = %v, want %v", versions, tt.wantVersions)
+			}
+		})
+	}
+}
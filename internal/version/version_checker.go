@@ -31,8 +31,21 @@ type UpdateInfo struct {
 	ChangelogURL          string
 	UpdateType            UpdateType
 	ResourceChanges       *ResourceChangesSummary
-	SchemaChanges         interface{} // Will hold *terraform.SchemaChanges
-	AIAnalysis            *ai.AIAnalysis // AI-powered breaking change detection
+	SchemaChanges         interface{}         // Will hold *terraform.SchemaChanges
+	ReleaseNotes          *ReleaseNotes       // Notes of the versions between current and latest
+	AIAnalysis            *ai.AIAnalysis      // AI-powered breaking change detection
+	AIMigration           *ai.MigrationResult // AI-proposed edits of our module call, set by pr --ai-migrate
+	HeldBackReason        string              // Why an available update was not reported as outdated
+}
+
+// CheckFailure records a dependency whose version check failed
+type CheckFailure struct {
+	Kind     string // "module" or "provider"
+	Name     string
+	Source   string
+	FilePath string
+	Line     int
+	Error    string
 }
 
 // ResourceChangesSummary summarizes infrastructure changes from terraform plan
@@ -44,6 +57,7 @@ type ResourceChangesSummary struct {
 	TotalReplace     int
 	TotalDelete      int
 	TotalModify      int
+	Drift            []ResourceChange // Changes already planned on the base branch, not caused by the update
 }
 
 // ResourceChange represents a single resource change
@@ -52,6 +66,19 @@ type ResourceChange struct {
 	ResourceType string
 	Action       string
 	Reason       string
+	Attributes   []string // Attributes changed by the update, for in-place modifications
+	ActionReason string   // Why terraform chose the action, e.g. replace_because_cannot_update
+	Triggers     []AttributeChange // Attributes forcing replacement, with their values
+	MovedFrom    string   // Previous address when the resource is moved
+	Imported     bool     // The resource is imported
+	Workspace    string   // Workspace the change is planned in, empty for a root without workspaces
+}
+
+// AttributeChange is the before and after value of an attribute, rendered for display
+type AttributeChange struct {
+	Path   string
+	Before string
+	After  string
 }
 
 // UpdateType indicates the type of version update
@@ -71,6 +98,9 @@ const (
 	UpdateTypeUnknown UpdateType = "unknown"
 )
 
+// DefaultRegistryHost is the registry queried for sources without a hostname
+const DefaultRegistryHost = "registry.terraform.io"
+
 // Checker checks for module version updates
 type Checker struct {
 	httpClient     *http.Client
@@ -81,11 +111,95 @@ type Checker struct {
 	ignoreModules  []string
 	cache          *cache.RepositoryCache
 	aiAnalyzer     AIAnalyzer // Optional AI analyzer for breaking change detection
+	registryHost   string     // Default registry for sources without a hostname
+
+	// Release notes fetched for outdated dependencies, see SetReleaseNotes
+	releaseNotes      bool
+	releaseNotesLimit int
+
+	// Dependencies skipped during Check and CheckProviders, kept for reporting
+	heldBack          []UpdateInfo
+	heldBackProviders []ProviderUpdateInfo
+	failures          []CheckFailure
+	providerFailures  []CheckFailure
 }
 
 // AIAnalyzer interface for AI-powered breaking change detection
 type AIAnalyzer interface {
-	AnalyzeBreakingChanges(ctx context.Context, moduleName, currentVersion, latestVersion, changelogURL string) (*ai.AIAnalysis, error)
+	AnalyzeBreakingChanges(ctx context.Context, req ai.AnalysisRequest) (*ai.AIAnalysis, error)
+}
+
+// analysisRequest describes an update to the AI analyzer with its release notes and
+// breaking schema changes as evidence
+func analysisRequest(name, source, currentVersion, latestVersion, changelogURL string, notes *ReleaseNotes, schemaChanges []string) ai.AnalysisRequest {
+	req := ai.AnalysisRequest{
+		Name:           name,
+		Source:         source,
+		CurrentVersion: currentVersion,
+		LatestVersion:  latestVersion,
+		ChangelogURL:   changelogURL,
+		SchemaChanges:  schemaChanges,
+	}
+	if notes != nil {
+		for _, v := range notes.Versions {
+			req.ReleaseNotes = append(req.ReleaseNotes, ai.ReleaseNote{Version: v.Version, Body: v.Body})
+		}
+		req.ReleaseNotesTruncated = notes.Truncated
+	}
+	return req
+}
+
+// analyze runs the AI analysis of an update, nil when it fails
+func (c *Checker) analyze(ctx context.Context, kind string, req ai.AnalysisRequest) *ai.AIAnalysis {
+	analysis, err := c.aiAnalyzer.AnalyzeBreakingChanges(ctx, req)
+	if err != nil {
+		log.Warn().Err(err).
+			Str(kind, req.Name).
+			Msg("AI analysis failed, skipping")
+		return nil
+	}
+
+	log.Debug().
+		Str(kind, req.Name).
+		Bool("ai_breaking_changes", analysis.HasBreakingChanges).
+		Str("confidence", analysis.Confidence).
+		Int("schema_changes", len(req.SchemaChanges)).
+		Msg("AI analysis completed")
+	return analysis
+}
+
+// AnalyzeUpdate runs the AI analysis of a module update with the breaking schema
+// changes found by diffing both versions, for callers that diff the schemas after
+// Check. Without an AI analyzer it does nothing.
+func (c *Checker) AnalyzeUpdate(ctx context.Context, update *UpdateInfo, schemaChanges []string) {
+	if c.aiAnalyzer == nil {
+		return
+	}
+	update.AIAnalysis = c.analyze(ctx, "module", analysisRequest(
+		update.Module.Name,
+		update.Module.Source,
+		update.CurrentVersion,
+		update.LatestVersion,
+		update.ChangelogURL,
+		update.ReleaseNotes,
+		schemaChanges,
+	))
+}
+
+// AnalyzeProviderUpdate is AnalyzeUpdate for a provider update
+func (c *Checker) AnalyzeProviderUpdate(ctx context.Context, update *ProviderUpdateInfo, schemaChanges []string) {
+	if c.aiAnalyzer == nil {
+		return
+	}
+	update.AIAnalysis = c.analyze(ctx, "provider", analysisRequest(
+		update.Provider.Name,
+		update.Provider.Source,
+		update.CurrentVersion,
+		update.LatestVersion,
+		update.ChangelogURL,
+		update.ReleaseNotes,
+		schemaChanges,
+	))
 }
 
 // New creates a new version Checker
@@ -125,6 +239,7 @@ func New(githubToken string, skipPrerelease, patchOnly, minorOnly bool, ignoreMo
 		ignoreModules:  ignoreModules,
 		cache:          repoCache,
 		aiAnalyzer:     nil, // Will be set via SetAIAnalyzer if needed
+		registryHost:   DefaultRegistryHost,
 	}
 }
 
@@ -133,14 +248,49 @@ func (c *Checker) SetAIAnalyzer(analyzer AIAnalyzer) {
 	c.aiAnalyzer = analyzer
 }
 
+// SetRegistryHost sets the registry queried for module and provider sources that
+// do not name a hostname, e.g. registry.opentofu.org for OpenTofu
+func (c *Checker) SetRegistryHost(host string) {
+	if host != "" {
+		c.registryHost = host
+	}
+}
+
+// HeldBack returns the module updates skipped by the last Check, either because
+// the module is ignored or because the update policy holds the latest version back
+func (c *Checker) HeldBack() []UpdateInfo {
+	return c.heldBack
+}
+
+// HeldBackProviders returns the provider updates held back by the update policy
+// during the last CheckProviders
+func (c *Checker) HeldBackProviders() []ProviderUpdateInfo {
+	return c.heldBackProviders
+}
+
+// Failures returns the modules whose version check failed during the last Check and
+// the providers whose check failed during the last CheckProviders
+func (c *Checker) Failures() []CheckFailure {
+	var failures []CheckFailure
+	failures = append(failures, c.failures...)
+	return append(failures, c.providerFailures...)
+}
+
 // Check checks for updates for the given modules
 func (c *Checker) Check(ctx context.Context, modules []scanner.ModuleInfo) ([]UpdateInfo, error) {
 	var updates []UpdateInfo
+	c.heldBack = nil
+	c.failures = nil
 
 	for _, module := range modules {
 		// Skip ignored modules
 		if c.isIgnored(module.Name) {
 			log.Debug().Str("module", module.Name).Msg("skipping ignored module")
+			c.heldBack = append(c.heldBack, UpdateInfo{
+				Module:         module,
+				CurrentVersion: extractVersionFromConstraint(module.Version),
+				HeldBackReason: "listed in version_check.ignore_modules",
+			})
 			continue
 		}
 
@@ -170,33 +320,34 @@ func (c *Checker) Check(ctx context.Context, modules []scanner.ModuleInfo) ([]Up
 			log.Warn().Err(err).
 				Str("module", module.Name).
 				Msg("failed to check module version")
+			c.failures = append(c.failures, CheckFailure{
+				Kind:     "module",
+				Name:     module.Name,
+				Source:   module.Source,
+				FilePath: module.FilePath,
+				Line:     module.Line,
+				Error:    err.Error(),
+			})
+			continue
+		}
+
+		if updateInfo.HeldBackReason != "" {
+			c.heldBack = append(c.heldBack, updateInfo)
+			log.Debug().
+				Str("module", module.Name).
+				Str("reason", updateInfo.HeldBackReason).
+				Msg("update held back")
 			continue
 		}
 
 		if updateInfo.IsOutdated {
-			// Perform AI analysis if analyzer is configured
-			if c.aiAnalyzer != nil {
-				aiAnalysis, err := c.aiAnalyzer.AnalyzeBreakingChanges(
-					ctx,
-					module.Name,
-					updateInfo.CurrentVersion,
-					updateInfo.LatestVersion,
-					updateInfo.ChangelogURL,
-				)
-				if err != nil {
-					log.Warn().Err(err).
-						Str("module", module.Name).
-						Msg("AI analysis failed, skipping")
-				} else {
-					updateInfo.AIAnalysis = aiAnalysis
-					log.Debug().
-						Str("module", module.Name).
-						Bool("ai_breaking_changes", aiAnalysis.HasBreakingChanges).
-						Str("confidence", aiAnalysis.Confidence).
-						Msg("AI analysis completed")
-				}
+			if c.releaseNotes {
+				updateInfo.ReleaseNotes = c.moduleReleaseNotes(ctx, module, updateInfo.CurrentVersion, updateInfo.LatestVersion)
 			}
 
+			// Perform AI analysis if analyzer is configured
+			c.AnalyzeUpdate(ctx, &updateInfo, nil)
+
 			updates = append(updates, updateInfo)
 			log.Info().
 				Str("module", module.Name).
@@ -216,8 +367,8 @@ func (c *Checker) checkRegistryModule(ctx context.Context, module scanner.Module
 		CurrentVersion: extractVersionFromConstraint(module.Version),
 	}
 
-	// Parse module source (namespace/name/provider)
-	parts := strings.Split(module.Source, "/")
+	// Parse module source ([hostname/]namespace/name/provider)
+	host, parts := splitRegistrySource(module.Source, 3, c.registryHost)
 	if len(parts) < 3 {
 		return updateInfo, fmt.Errorf("invalid registry source format: %s", module.Source)
 	}
@@ -226,9 +377,9 @@ func (c *Checker) checkRegistryModule(ctx context.Context, module scanner.Module
 	name := parts[1]
 	provider := parts[2]
 
-	// Query Terraform Registry API
-	url := fmt.Sprintf("https://registry.terraform.io/v1/modules/%s/%s/%s/versions",
-		namespace, name, provider)
+	// Query the module registry API
+	url := fmt.Sprintf("https://%s/v1/modules/%s/%s/%s/versions",
+		host, namespace, name, provider)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -297,6 +448,9 @@ func (c *Checker) checkRegistryModule(ctx context.Context, module scanner.Module
 		}
 
 		updateInfo.IsOutdated = c.shouldUpdate(currentVersion, latestVersion)
+		if !updateInfo.IsOutdated {
+			updateInfo.HeldBackReason = c.heldBackReason(currentVersion, latestVersion)
+		}
 
 		// Detect breaking changes and update type
 		updateInfo.UpdateType = c.detectUpdateType(currentVersion, latestVersion)
@@ -318,8 +472,8 @@ func (c *Checker) checkRegistryModule(ctx context.Context, module scanner.Module
 	}
 
 	// Set changelog URL
-	updateInfo.ChangelogURL = fmt.Sprintf("https://registry.terraform.io/modules/%s/%s/%s/%s",
-		namespace, name, provider, latestVersion.String())
+	updateInfo.ChangelogURL = fmt.Sprintf("https://%s/modules/%s/%s/%s/%s",
+		host, namespace, name, provider, latestVersion.String())
 
 	return updateInfo, nil
 }
@@ -413,6 +567,9 @@ func (c *Checker) checkGitModule(ctx context.Context, module scanner.ModuleInfo)
 		current, err := version.NewVersion(strings.TrimPrefix(currentVersion, "v"))
 		if err == nil {
 			updateInfo.IsOutdated = c.shouldUpdate(current, latestVersion)
+			if !updateInfo.IsOutdated {
+				updateInfo.HeldBackReason = c.heldBackReason(current, latestVersion)
+			}
 
 			// Detect breaking changes and update type
 			updateInfo.UpdateType = c.detectUpdateType(current, latestVersion)
@@ -500,6 +657,24 @@ func (c *Checker) shouldUpdate(current, latest *version.Version) bool {
 	return true
 }
 
+// heldBackReason explains why shouldUpdate rejected a newer version, or returns
+// an empty string when the current version is already the latest
+func (c *Checker) heldBackReason(current, latest *version.Version) string {
+	if current.GreaterThanOrEqual(latest) {
+		return ""
+	}
+
+	if c.patchOnly {
+		return fmt.Sprintf("held back by version_check.patch_only (latest is %s)", latest.String())
+	}
+
+	if c.minorOnly {
+		return fmt.Sprintf("held back by version_check.minor_only (latest is %s)", latest.String())
+	}
+
+	return ""
+}
+
 // isIgnored checks if a module should be ignored
 func (c *Checker) isIgnored(moduleName string) bool {
 	for _, ignored := range c.ignoreModules {
@@ -546,6 +721,18 @@ func (c *Checker) detectUpdateType(current, latest *version.Version) UpdateType
 	return UpdateTypeUnknown
 }
 
+// splitRegistrySource splits a registry address into its hostname and the remaining
+// parts. A source has an explicit hostname when it has more than n parts and the first
+// one contains a dot (e.g. registry.opentofu.org/hashicorp/aws); otherwise defaultHost
+// is returned.
+func splitRegistrySource(source string, n int, defaultHost string) (string, []string) {
+	parts := strings.Split(source, "/")
+	if len(parts) > n && strings.Contains(parts[0], ".") {
+		return strings.ToLower(parts[0]), parts[1:]
+	}
+	return defaultHost, parts
+}
+
 // extractVersionFromConstraint extracts a clean version string from a Terraform version constraint
 // Supports: "~> 5.0", ">= 5.0.0", "= 5.0.0", "5.0.0"
 func extractVersionFromConstraint(versionConstraint string) string {
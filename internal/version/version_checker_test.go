@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	gversion "github.com/hashicorp/go-version"
+	"github.com/heyjobs/terranovate/internal/ai"
 	"github.com/heyjobs/terranovate/internal/scanner"
 )
 
@@ -478,6 +481,34 @@ func TestDetectUpdateTypeAllScenarios(t *testing.T) {
 	}
 }
 
+func TestSplitRegistrySource(t *testing.T) {
+	tests := []struct {
+		source    string
+		n         int
+		wantHost  string
+		wantParts []string
+	}{
+		{"hashicorp/aws", 2, DefaultRegistryHost, []string{"hashicorp", "aws"}},
+		{"registry.opentofu.org/hashicorp/aws", 2, "registry.opentofu.org", []string{"hashicorp", "aws"}},
+		{"terraform-aws-modules/vpc/aws", 3, DefaultRegistryHost, []string{"terraform-aws-modules", "vpc", "aws"}},
+		{"app.terraform.io/acme/vpc/aws", 3, "app.terraform.io", []string{"acme", "vpc", "aws"}},
+		{"terraform-aws-modules/iam/aws//modules/iam-role", 3, DefaultRegistryHost,
+			[]string{"terraform-aws-modules", "iam", "aws", "", "modules", "iam-role"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			host, parts := splitRegistrySource(tt.source, tt.n, DefaultRegistryHost)
+			if host != tt.wantHost {
+				t.Errorf("host = %q, want %q", host, tt.wantHost)
+			}
+			if !reflect.DeepEqual(parts, tt.wantParts) {
+				t.Errorf("parts = %v, want %v", parts, tt.wantParts)
+			}
+		})
+	}
+}
+
 func TestExtractVersionFromConstraintEdgeCases(t *testing.T) {
 	tests := []struct {
 		input string
@@ -597,3 +628,148 @@ func TestUpdateInfoStructure(t *testing.T) {
 func parseVersion(v string) (*gversion.Version, error) {
 	return gversion.NewVersion(v)
 }
+
+func TestHeldBackReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		patchOnly  bool
+		minorOnly  bool
+		current    string
+		latest     string
+		wantReason string
+	}{
+		{
+			name:       "already latest",
+			patchOnly:  true,
+			current:    "2.0.0",
+			latest:     "2.0.0",
+			wantReason: "",
+		},
+		{
+			name:       "no policy",
+			current:    "1.0.0",
+			latest:     "2.0.0",
+			wantReason: "",
+		},
+		{
+			name:       "patch only policy",
+			patchOnly:  true,
+			current:    "1.0.0",
+			latest:     "1.1.0",
+			wantReason: "held back by version_check.patch_only (latest is 1.1.0)",
+		},
+		{
+			name:       "minor only policy",
+			minorOnly:  true,
+			current:    "1.0.0",
+			latest:     "2.0.0",
+			wantReason: "held back by version_check.minor_only (latest is 2.0.0)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := New("", false, tt.patchOnly, tt.minorOnly, nil)
+			current := gversion.Must(gversion.NewVersion(tt.current))
+			latest := gversion.Must(gversion.NewVersion(tt.latest))
+
+			got := checker.heldBackReason(current, latest)
+			if got != tt.wantReason {
+				t.Errorf("heldBackReason() = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckRecordsIgnoredModules(t *testing.T) {
+	checker := New("", false, false, false, []string{"legacy"})
+
+	modules := []scanner.ModuleInfo{
+		{
+			Name:       "legacy",
+			Source:     "terraform-aws-modules/vpc/aws",
+			Version:    "~> 3.0",
+			SourceType: scanner.SourceTypeRegistry,
+		},
+	}
+
+	updates, err := checker.Check(context.Background(), modules)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	if len(updates) != 0 {
+		t.Errorf("Check() returned %d updates, want 0", len(updates))
+	}
+
+	heldBack := checker.HeldBack()
+	if len(heldBack) != 1 {
+		t.Fatalf("HeldBack() returned %d entries, want 1", len(heldBack))
+	}
+
+	if heldBack[0].CurrentVersion != "3.0" {
+		t.Errorf("HeldBack()[0].CurrentVersion = %q, want 3.0", heldBack[0].CurrentVersion)
+	}
+
+	if heldBack[0].HeldBackReason == "" {
+		t.Error("HeldBack()[0].HeldBackReason should explain why the module was skipped")
+	}
+}
+
+func TestCheckResetsFailures(t *testing.T) {
+	checker := New("", false, false, false, nil)
+
+	modules := []scanner.ModuleInfo{
+		{Name: "broken", Source: "vpc/aws", Version: "1.0.0", SourceType: scanner.SourceTypeRegistry},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := checker.Check(context.Background(), modules); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if failures := checker.Failures(); len(failures) != 1 || failures[0].Name != "broken" {
+			t.Fatalf("Failures() after Check #%d = %+v, want only broken", i+1, failures)
+		}
+	}
+
+	// Checking providers keeps the failures of the module check
+	if _, err := checker.CheckProviders(context.Background(), nil); err != nil {
+		t.Fatalf("CheckProviders() error = %v", err)
+	}
+	if failures := checker.Failures(); len(failures) != 1 {
+		t.Errorf("Failures() after CheckProviders = %+v, want the module failure", failures)
+	}
+}
+
+func TestAnalyzeUpdateSchemaChanges(t *testing.T) {
+	ctx := context.Background()
+	update := UpdateInfo{
+		Module:         scanner.ModuleInfo{Name: "vpc", Source: "terraform-aws-modules/vpc/aws"},
+		CurrentVersion: "4.0.0",
+		LatestVersion:  "5.0.0",
+		ReleaseNotes:   &ReleaseNotes{Versions: []VersionNotes{{Version: "5.0.0", Body: "* Bug fixes"}}},
+	}
+
+	checker := New("", false, false, false, nil)
+	checker.AnalyzeUpdate(ctx, &update, []string{"Removed variable enable_classiclink (bool)"})
+	if update.AIAnalysis != nil {
+		t.Fatalf("AnalyzeUpdate() without analyzer = %+v, want nil", update.AIAnalysis)
+	}
+
+	client := &ai.FakeClient{Responses: []string{
+		`{"has_breaking_changes": true, "summary": "Drops EC2-Classic.", "details": [{"text": "enable_classiclink was removed", "evidence": ["S1"]}]}`,
+	}}
+	checker.SetAIAnalyzer(ai.New(client))
+	checker.AnalyzeUpdate(ctx, &update, []string{"Removed variable enable_classiclink (bool)"})
+
+	if len(client.Prompts) != 1 || !strings.Contains(client.Prompts[0], "[S1] schema diff: Removed variable enable_classiclink (bool)") {
+		t.Fatalf("prompts = %q, want one listing the schema change as S1", client.Prompts)
+	}
+	analysis := update.AIAnalysis
+	if analysis == nil || len(analysis.Details) != 1 || len(analysis.Details[0].Citations) != 1 || analysis.Details[0].Citations[0].Source != "schema diff" {
+		t.Fatalf("AIAnalysis = %+v, want a detail citing the schema diff", analysis)
+	}
+	if analysis.Confidence != "high" {
+		t.Errorf("Confidence = %q, want high", analysis.Confidence)
+	}
+}
@@ -30,16 +30,60 @@ type Config struct {
 
 // TerraformConfig holds Terraform-related configuration
 type TerraformConfig struct {
+	// Engine that runs plans: terraform, tofu or auto (default: auto, detected from
+	// .opentofu-version, .tofu files and the binaries in PATH)
+	Engine string `yaml:"engine,omitempty"`
+
 	// Path to Terraform binary (optional, will use PATH if not specified)
 	BinaryPath string `yaml:"binary_path,omitempty"`
 
 	// Working directory for Terraform operations
 	WorkingDir string `yaml:"working_dir,omitempty"`
 
+	// Root modules to plan, as paths or globs relative to the scan path
+	// (default: directories with a backend, cloud or provider block)
+	RootModules []string `yaml:"root_modules,omitempty"`
+
+	// Workspaces, var files and variables to plan root modules with, matched by path
+	// in order (default: the current workspace without var files)
+	RootSettings []RootSettingsConfig `yaml:"root_settings,omitempty"`
+
+	// How far PR branches are validated: none, init, validate or plan (default: plan).
+	// init and validate run without backend or provider credentials.
+	ValidationLevel string `yaml:"validation_level,omitempty"`
+
+	// Providers replaced by mock_provider blocks at the plan level, which then plans
+	// with terraform test instead of terraform plan (requires Terraform 1.7+)
+	MockProviders []string `yaml:"mock_providers,omitempty"`
+
+	// Directory where pr saves every plan (binary, JSON and text) with a manifest.json
+	// listing them (optional, plans are discarded if not set)
+	ArtifactsDir string `yaml:"artifacts_dir,omitempty"`
+
+	// Directory of the provider plugin and module cache shared by plans
+	// (default: terranovate in the user cache directory)
+	CacheDir string `yaml:"cache_dir,omitempty"`
+
 	// Additional environment variables
 	Env map[string]string `yaml:"env,omitempty"`
 }
 
+// RootSettingsConfig holds the plan inputs of the root modules matching a path
+type RootSettingsConfig struct {
+	// Path or glob of the root modules, relative to the directory terranovate runs in
+	Path string `yaml:"path"`
+
+	// Workspaces to select and plan one after another
+	Workspaces []string `yaml:"workspaces,omitempty"`
+
+	// Files passed as -var-file, relative to the root module. {workspace} is replaced
+	// by the workspace being planned, e.g. vars/{workspace}.tfvars
+	VarFiles []string `yaml:"var_files,omitempty"`
+
+	// Variables passed as -var name=value
+	Vars map[string]string `yaml:"vars,omitempty"`
+}
+
 // GitHubConfig holds GitHub API configuration
 type GitHubConfig struct {
 	// GitHub token for API authentication
@@ -62,6 +106,72 @@ type GitHubConfig struct {
 
 	// PR reviewers to assign
 	Reviewers []string `yaml:"reviewers,omitempty"`
+
+	// Dependency dashboard issue settings
+	Dashboard DashboardConfig `yaml:"dashboard,omitempty"`
+
+	// Auto-merge policy for low-risk updates
+	AutoMerge AutoMergeConfig `yaml:"auto_merge,omitempty"`
+
+	// Time windows in which PRs may be opened
+	Schedule ScheduleConfig `yaml:"schedule,omitempty"`
+
+	// Maximum number of open Terranovate PRs (0 = unlimited)
+	PRConcurrentLimit int `yaml:"pr_concurrent_limit,omitempty"`
+
+	// Maximum number of Terranovate PRs created per hour (0 = unlimited)
+	PRHourlyLimit int `yaml:"pr_hourly_limit,omitempty"`
+}
+
+// ScheduleConfig holds the time windows in which PRs may be opened
+type ScheduleConfig struct {
+	// Cron-like windows (minute hour day-of-month month day-of-week), e.g. "* 6-11 * * mon-fri"
+	Windows []string `yaml:"windows,omitempty"`
+
+	// Timezone of the windows (default: UTC)
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// DashboardConfig holds dependency dashboard issue settings
+type DashboardConfig struct {
+	// Maintain a dependency dashboard issue on every pr run
+	Enabled bool `yaml:"enabled"`
+
+	// Issue title (default: Terranovate Dependency Dashboard)
+	Title string `yaml:"title,omitempty"`
+}
+
+// AutoMergeConfig holds the auto-merge policy for update PRs
+type AutoMergeConfig struct {
+	// Enable GitHub auto-merge on PRs that satisfy the policy
+	Enabled bool `yaml:"enabled"`
+
+	// Merge method: merge, squash or rebase (default: squash)
+	MergeMethod string `yaml:"merge_method,omitempty"`
+
+	// Update types eligible for auto-merge (default: patch)
+	UpdateTypes []string `yaml:"update_types,omitempty"`
+
+	// Maximum in-place resource modifications allowed in the plan (default: 0)
+	MaxResourceChanges int `yaml:"max_resource_changes,omitempty"`
+
+	// Package rules overriding the policy for matching modules/providers
+	PackageRules []AutoMergeRule `yaml:"package_rules,omitempty"`
+}
+
+// AutoMergeRule overrides the auto-merge policy for matching dependencies
+type AutoMergeRule struct {
+	// Glob patterns matched against module/provider names and sources
+	Match []string `yaml:"match"`
+
+	// Enable or disable auto-merge for matching dependencies
+	AutoMerge *bool `yaml:"automerge,omitempty"`
+
+	// Update types eligible for auto-merge
+	UpdateTypes []string `yaml:"update_types,omitempty"`
+
+	// Maximum in-place resource modifications allowed in the plan
+	MaxResourceChanges *int `yaml:"max_resource_changes,omitempty"`
 }
 
 // NotifierConfig holds notification configuration
@@ -120,6 +230,13 @@ type VersionCheckConfig struct {
 	// - "minor-and-above": Show minor and major updates (hide patches)
 	// - "critical-only": Show only major updates (same as major-only)
 	DisplayFilter string `yaml:"display_filter,omitempty"`
+
+	// Fetch the release notes of the versions an update moves across, from GitHub
+	// releases or CHANGELOG.md, for AI analysis and PR bodies
+	ReleaseNotes bool `yaml:"release_notes"`
+
+	// Maximum size of the release notes of one update, in bytes (default: 16000)
+	ReleaseNotesMaxBytes int `yaml:"release_notes_max_bytes,omitempty"`
 }
 
 // OpenAIConfig holds OpenAI API configuration
@@ -142,6 +259,65 @@ type OpenAIConfig struct {
 	// - "medium": Show only medium and high confidence assessments
 	// - "high": Show only high confidence assessments
 	MinConfidence string `yaml:"min_confidence,omitempty"`
+
+	// Backend that answers: "openai" (default, any OpenAI-compatible API, configured
+	// by the fields above), "anthropic", "ollama" or "fake" (offline, deterministic)
+	Backend string `yaml:"backend,omitempty"`
+
+	// Request timeout of the openai backend in seconds (default: 30)
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+
+	// Maximum tokens of an openai backend response (default: 1000)
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+
+	// Anthropic Messages API settings, used with backend: anthropic
+	Anthropic LLMBackendConfig `yaml:"anthropic,omitempty"`
+
+	// Ollama settings, used with backend: ollama
+	Ollama LLMBackendConfig `yaml:"ollama,omitempty"`
+
+	// Hours an analysis is reused from the cache directory before the model is asked
+	// again (default: 720, i.e. 30 days)
+	CacheTTLHours int `yaml:"cache_ttl_hours,omitempty"`
+}
+
+// LLMBackendConfig returns the settings of the selected backend
+func (c OpenAIConfig) LLMBackendConfig() LLMBackendConfig {
+	switch c.Backend {
+	case "anthropic":
+		return c.Anthropic
+	case "ollama":
+		return c.Ollama
+	case "", "openai":
+		return LLMBackendConfig{
+			APIKey:         c.APIKey,
+			Model:          c.Model,
+			BaseURL:        c.BaseURL,
+			TimeoutSeconds: c.TimeoutSeconds,
+			MaxTokens:      c.MaxTokens,
+		}
+	default:
+		return LLMBackendConfig{}
+	}
+}
+
+// LLMBackendConfig holds the settings of an AI backend other than openai. Empty
+// fields use the defaults of the backend.
+type LLMBackendConfig struct {
+	// API key (Anthropic: can also be set via ANTHROPIC_API_KEY environment variable)
+	APIKey string `yaml:"api_key,omitempty"`
+
+	// Model to use (default: claude-3-5-haiku-latest for anthropic, llama3.1 for ollama)
+	Model string `yaml:"model,omitempty"`
+
+	// Base URL of the API (default: https://api.anthropic.com/v1, http://localhost:11434)
+	BaseURL string `yaml:"base_url,omitempty"`
+
+	// Request timeout in seconds (default: 30 for anthropic, 120 for ollama)
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+
+	// Maximum tokens of a response (default: 1000)
+	MaxTokens int `yaml:"max_tokens,omitempty"`
 }
 
 // Load reads and parses the configuration file
@@ -171,6 +347,18 @@ func (c *Config) setDefaults() {
 		c.GitHub.BaseBranch = "main"
 	}
 
+	if c.GitHub.Dashboard.Title == "" {
+		c.GitHub.Dashboard.Title = "Terranovate Dependency Dashboard"
+	}
+
+	if c.GitHub.AutoMerge.MergeMethod == "" {
+		c.GitHub.AutoMerge.MergeMethod = "squash"
+	}
+
+	if len(c.GitHub.AutoMerge.UpdateTypes) == 0 {
+		c.GitHub.AutoMerge.UpdateTypes = []string{"patch"}
+	}
+
 	if c.Notifier.OutputFormat == "" {
 		c.Notifier.OutputFormat = "text"
 	}
@@ -215,6 +403,11 @@ func (c *Config) overrideWithEnv() {
 	if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
 		c.OpenAI.BaseURL = baseURL
 	}
+
+	// Anthropic API key from environment
+	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
+		c.OpenAI.Anthropic.APIKey = apiKey
+	}
 }
 
 // Validate checks if the configuration is valid
@@ -239,6 +432,13 @@ func Default() *Config {
 		GitHub: GitHubConfig{
 			BaseBranch: "main",
 			BaseURL:    "https://api.github.com",
+			Dashboard: DashboardConfig{
+				Title: "Terranovate Dependency Dashboard",
+			},
+			AutoMerge: AutoMergeConfig{
+				MergeMethod: "squash",
+				UpdateTypes: []string{"patch"},
+			},
 		},
 		Notifier: NotifierConfig{
 			OutputFormat: "text",
@@ -249,6 +449,7 @@ func Default() *Config {
 		},
 		VersionCheck: VersionCheckConfig{
 			SkipPrerelease: true,
+			ReleaseNotes:   true,
 		},
 	}
 
@@ -250,6 +250,10 @@ func TestDefault(t *testing.T) {
 		{"Terraform.WorkingDir", cfg.Terraform.WorkingDir, "."},
 		{"GitHub.BaseBranch", cfg.GitHub.BaseBranch, "main"},
 		{"GitHub.BaseURL", cfg.GitHub.BaseURL, "https://api.github.com"},
+		{"GitHub.Dashboard.Enabled", cfg.GitHub.Dashboard.Enabled, false},
+		{"GitHub.Dashboard.Title", cfg.GitHub.Dashboard.Title, "Terranovate Dependency Dashboard"},
+		{"GitHub.AutoMerge.Enabled", cfg.GitHub.AutoMerge.Enabled, false},
+		{"GitHub.AutoMerge.MergeMethod", cfg.GitHub.AutoMerge.MergeMethod, "squash"},
 		{"Notifier.OutputFormat", cfg.Notifier.OutputFormat, "text"},
 		{"Scanner.Include", len(cfg.Scanner.Include), 1},
 		{"Scanner.Recursive", cfg.Scanner.Recursive, true},
@@ -296,6 +300,12 @@ func TestSetDefaults(t *testing.T) {
 				if !cfg.Scanner.Recursive {
 					t.Error("Recursive = false, want true")
 				}
+				if cfg.GitHub.Dashboard.Title != "Terranovate Dependency Dashboard" {
+					t.Errorf("Dashboard.Title = %s, want Terranovate Dependency Dashboard", cfg.GitHub.Dashboard.Title)
+				}
+				if len(cfg.GitHub.AutoMerge.UpdateTypes) != 1 || cfg.GitHub.AutoMerge.UpdateTypes[0] != "patch" {
+					t.Errorf("AutoMerge.UpdateTypes = %v, want [patch]", cfg.GitHub.AutoMerge.UpdateTypes)
+				}
 			},
 		},
 		{
@@ -401,3 +411,33 @@ version_check:
 		t.Errorf("BaseBranch = %s, want main (default)", cfg.GitHub.BaseBranch)
 	}
 }
+
+func TestOpenAIConfigLLMBackendConfig(t *testing.T) {
+	cfg := OpenAIConfig{
+		APIKey:         "sk-openai",
+		Model:          "gpt-4o",
+		BaseURL:        "https://api.openai.com/v1",
+		TimeoutSeconds: 10,
+		MaxTokens:      500,
+		Anthropic:      LLMBackendConfig{APIKey: "sk-ant", Model: "claude-3-5-sonnet-latest"},
+		Ollama:         LLMBackendConfig{BaseURL: "http://gpu-box:11434", TimeoutSeconds: 300},
+	}
+
+	tests := []struct {
+		backend string
+		want    LLMBackendConfig
+	}{
+		{"", LLMBackendConfig{APIKey: "sk-openai", Model: "gpt-4o", BaseURL: "https://api.openai.com/v1", TimeoutSeconds: 10, MaxTokens: 500}},
+		{"openai", LLMBackendConfig{APIKey: "sk-openai", Model: "gpt-4o", BaseURL: "https://api.openai.com/v1", TimeoutSeconds: 10, MaxTokens: 500}},
+		{"anthropic", LLMBackendConfig{APIKey: "sk-ant", Model: "claude-3-5-sonnet-latest"}},
+		{"ollama", LLMBackendConfig{BaseURL: "http://gpu-box:11434", TimeoutSeconds: 300}},
+		{"fake", LLMBackendConfig{}},
+	}
+
+	for _, tt := range tests {
+		cfg.Backend = tt.backend
+		if got := cfg.LLMBackendConfig(); got != tt.want {
+			t.Errorf("LLMBackendConfig() for backend %q = %+v, want %+v", tt.backend, got, tt.want)
+		}
+	}
+}